@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/detect"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/state"
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt [path|branch]...",
+	Short: "Take over worktrees created without wt",
+	Long: `Take over worktrees created with git worktree add or another tool
+(default: all that wt has no record of). wt records them as if it had created
+them, so wt inspect, wt ls --json and the detailed selector view show when
+they were created.
+
+wt init offers this for the worktrees it finds.`,
+	RunE: runAdopt,
+}
+
+func init() {
+	rootCmd.AddCommand(adoptCmd)
+}
+
+func runAdopt(cmd *cobra.Command, args []string) error {
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	records := loadRecords()
+
+	var adopt []git.Worktree
+	for _, arg := range args {
+		wt, ok := git.FindWorktree(worktrees, arg)
+		if !ok {
+			return fmt.Errorf("no worktree found for %q", arg)
+		}
+		if wt.IsMain {
+			return fmt.Errorf("%s is the main worktree", wt.Path)
+		}
+		if _, ok := records.Worktrees[wt.Path]; ok {
+			log.Infof("%s is already known to wt", wt.Path)
+			continue
+		}
+		adopt = append(adopt, *wt)
+	}
+	if len(args) == 0 {
+		adopt = unadoptedWorktrees(worktrees, records)
+	}
+	if len(adopt) == 0 {
+		log.Infof("No worktrees to adopt.")
+		return nil
+	}
+	return adoptWorktrees(adopt, records)
+}
+
+// unadoptedWorktrees returns the linked worktrees wt has no record of.
+func unadoptedWorktrees(worktrees []git.Worktree, records state.Records) []git.Worktree {
+	var found []git.Worktree
+	for _, wt := range worktrees {
+		if _, ok := records.Worktrees[wt.Path]; !ok && !wt.IsMain {
+			found = append(found, wt)
+		}
+	}
+	return found
+}
+
+// adoptWorktrees records worktrees created outside wt, dating them by when
+// their .git file was written.
+func adoptWorktrees(worktrees []git.Worktree, records state.Records) error {
+	gitDir, err := git.CommonDir()
+	if err != nil {
+		return err
+	}
+	err = state.UpdateRecords(state.RecordsPath(gitDir), func(r *state.Records) error {
+		for _, wt := range worktrees {
+			r.Set(wt.Path, state.Record{
+				CreatedAt:     worktreeCreated(records, wt.Path).UTC(),
+				InitialBranch: wt.Branch,
+				Adopted:       true,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record adopted worktrees: %w", err)
+	}
+	for _, wt := range worktrees {
+		fmt.Printf("Adopted %s\n", wt.Path)
+	}
+	return nil
+}
+
+// existingLayout inspects the linked worktrees of the repository for wt init,
+// telling what it found on stderr.
+func existingLayout(worktrees []git.Worktree) detect.Layout {
+	mainWt, ok := git.MainWorktree(worktrees)
+	if !ok || mainWt.Bare {
+		return detect.Layout{}
+	}
+	var paths []string
+	for _, wt := range worktrees {
+		if !wt.IsMain {
+			paths = append(paths, wt.Path)
+		}
+	}
+	tracked, err := git.TrackedNames(mainWt.Path)
+	if err != nil {
+		tracked = map[string]bool{}
+	}
+	layout := detect.Worktrees(mainWt.Path, paths, tracked)
+	for _, finding := range layout.Findings {
+		fmt.Fprintf(os.Stderr, "Detected %s\n", finding)
+	}
+	return layout
+}
+
+// coveredBy reports whether one of patterns already matches name.
+func coveredBy(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/log"
+)
+
+var ciCmd = &cobra.Command{
+	Use:   "ci <branch>",
+	Short: "Create or reuse a worktree for a CI pipeline, without prompts",
+	Long: `Create a worktree for branch the way wt add does, for use in CI pipelines,
+and print it as JSON on stdout:
+
+  {"path": "...", "branch": "feature", "base": "main", "created": true}
+
+Nothing is interactive and the branch is used as given, without
+preprocessing, so the same branch always lands in the same worktree. When a
+worktree for branch already exists it is reused as is ("created": false).
+A new branch starts from --base (default: base_branch), which must exist.
+
+copy_patterns, templates and pre_add_hooks apply as in wt add. post_hooks
+run too, unless --no-hooks skips them or --hooks-profile runs a named set
+from hook_profiles instead. Repository commands still need trust: run wt
+trust beforehand or set WT_TRUST_ALL=1 in the pipeline.
+
+wt ci teardown removes the worktree again.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCI,
+}
+
+var ciTeardownCmd = &cobra.Command{
+	Use:   "teardown <branch|path>",
+	Short: "Remove a worktree created by wt ci",
+	Long: `Remove the worktree of branch (or at path), even with uncommitted changes or
+unpushed commits, since pipelines leave build output behind. A worktree that
+doesn't exist is not an error, so teardown can run unconditionally at the end
+of a pipeline. --delete-branch deletes the local branch as well.
+
+Only worktrees wt ci created are torn down; --force removes others too.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCITeardown,
+}
+
+var (
+	ciBase         string
+	ciNoHooks      bool
+	ciHooksProfile string
+	ciDeleteBranch bool
+	ciForce        bool
+)
+
+func init() {
+	ciCmd.Flags().StringVar(&ciBase, "base", "", "Base branch for a new branch (default: base_branch from config)")
+	ciCmd.Flags().BoolVar(&ciNoHooks, "no-hooks", false, "Skip post_hooks")
+	ciCmd.Flags().StringVar(&ciHooksProfile, "hooks-profile", "", "Run the hooks of hook_profiles.<name> instead of post_hooks")
+	ciCmd.MarkFlagsMutuallyExclusive("no-hooks", "hooks-profile")
+
+	ciTeardownCmd.Flags().BoolVar(&ciDeleteBranch, "delete-branch", false, "Delete the worktree's local branch too")
+	ciTeardownCmd.Flags().BoolVarP(&ciForce, "force", "f", false, "Tear down a worktree wt ci didn't create")
+
+	ciCmd.AddCommand(ciTeardownCmd)
+	rootCmd.AddCommand(ciCmd)
+}
+
+// ciResult is what wt ci prints on stdout.
+type ciResult struct {
+	Path    string `json:"path"`
+	Branch  string `json:"branch"`
+	Base    string `json:"base"`
+	Created bool   `json:"created"`
+}
+
+func runCI(cmd *cobra.Command, args []string) error {
+	branch := args[0]
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadFromDir(repoRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	base := ciBase
+	if base == "" {
+		base = cfg.BaseBranch
+	}
+
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	for _, wt := range worktrees {
+		if wt.Branch == branch {
+			log.Infof("Reusing worktree: %s", wt.Path)
+			return printCIResult(ciResult{Path: wt.Path, Branch: branch, Base: worktreeBase(cfg, loadRecords(), wt.Path)})
+		}
+	}
+
+	if local, remote := git.BranchExists(branch); !local && !remote && !git.RefExists(base) {
+		return fmt.Errorf("base branch %q not found (fetch it first, or pass --base)", base)
+	}
+	if err := ensureTrusted(repoRoot); err != nil {
+		return err
+	}
+
+	a := &adder{
+		cfg:          cfg,
+		repoRoot:     repoRoot,
+		baseBranch:   base,
+		copyPatterns: cfg.CopyPatterns,
+		postHooks:    cfg.PostHooks,
+		direnv:       cfg.Direnv,
+		ci:           true,
+	}
+	a.lfs, _ = git.ParseLFSMode(cfg.LFS)
+	switch {
+	case ciNoHooks:
+		if len(a.postHooks) > 0 {
+			log.Infof("Skipping post_hooks (--no-hooks)")
+		}
+		a.postHooks, a.direnv = nil, false
+	case ciHooksProfile != "":
+		list, ok := cfg.HookProfiles[ciHooksProfile]
+		if !ok {
+			return fmt.Errorf("unknown hook profile %q (defined: %s)", ciHooksProfile, strings.Join(hookProfileNames(cfg), ", "))
+		}
+		a.postHooks = list
+	}
+	a.sparsePaths = cfg.SparsePaths
+
+	wt, err := a.add(branch)
+	if err != nil {
+		return err
+	}
+	log.Infof("Worktree created at: %s", wt.path)
+	return printCIResult(ciResult{Path: wt.path, Branch: branch, Base: worktreeBase(cfg, loadRecords(), wt.path), Created: true})
+}
+
+func printCIResult(result ciResult) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+func runCITeardown(cmd *cobra.Command, args []string) error {
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	wt, ok := git.FindWorktree(worktrees, args[0])
+	if !ok {
+		log.Infof("No worktree for %s; nothing to tear down.", args[0])
+		return nil
+	}
+	if wt.IsMain {
+		return fmt.Errorf("%s is the main worktree", wt.Path)
+	}
+	if !loadRecords().Worktrees[wt.Path].CI && !ciForce {
+		return fmt.Errorf("%s was not created by wt ci (use --force to remove it anyway)", wt.Path)
+	}
+
+	log.Infof("Removing worktree: %s", wt.Path)
+	if err := removeWorktree(wt.Path, true); err != nil {
+		return err
+	}
+	if ciDeleteBranch && wt.Branch != "" {
+		if err := git.DeleteBranch(wt.Branch); err != nil {
+			return err
+		}
+		log.Infof("Deleted branch %s", wt.Branch)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/state"
+	"github.com/default-anton/wt/internal/tui"
+)
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Prune stale and expired worktrees and delete orphan directories",
+	Long: `Run git worktree prune, then look for directories inside worktree_dir
+that were worktrees but are no longer registered (leftovers from crashes or
+manual deletions) and offer to delete them. Only directories with the .git
+file of a linked worktree, or that wt created, count; ones kept with
+wt rm --keep-dir don't. When worktree_dir holds the repository itself (e.g.
+worktree_dir = ".." for sibling directories), no directories are deleted.
+
+Worktrees created with wt add --ttl whose time is up are offered for removal
+too; ones with uncommitted changes are skipped.`,
+	Args: cobra.NoArgs,
+	RunE: runClean,
+}
+
+var cleanYes bool
+
+func init() {
+	cleanCmd.Flags().BoolVarP(&cleanYes, "yes", "y", false, "Remove all orphan directories and expired worktrees without prompting")
+
+	rootCmd.AddCommand(cleanCmd)
+}
+
+func runClean(cmd *cobra.Command, args []string) error {
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+
+	pruned, err := git.PruneWorktrees()
+	if err != nil {
+		return err
+	}
+	if pruned != "" {
+		log.Infof("%s", pruned)
+	}
+
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	worktreeDir, err := git.GetWorktreeDir(cfg.WorktreeDir)
+	if err != nil {
+		return err
+	}
+	records := loadRecords()
+	var orphans []string
+	if repoDir := repoDir(worktrees); containsPath(worktreeDir, repoDir) {
+		fmt.Fprintf(os.Stderr, "Warning: not looking for orphan directories: worktree_dir %s holds the repository %s\n", worktreeDir, repoDir)
+	} else if orphans, err = findOrphanDirs(worktreeDir, worktrees, records); err != nil {
+		return err
+	}
+	expired := expiredWorktrees(records, worktrees)
+	if len(orphans) == 0 && len(expired) == 0 {
+		log.Infof("No orphan directories or expired worktrees found.")
+		return nil
+	}
+
+	var items []tui.Item
+	for _, wt := range worktrees {
+		if expired[wt.Path] {
+			items = append(items, tui.Item{Label: wt.Label() + " (expired)", Value: wt.Path, Path: wt.Path})
+		}
+	}
+	for _, dir := range orphans {
+		items = append(items, tui.Item{Label: filepath.Base(dir), Value: dir, Path: dir})
+	}
+
+	var selected []string
+	if cleanYes {
+		if len(orphans) > 0 {
+			fmt.Fprintf(os.Stderr, "Directories in %s that are no longer registered worktrees:\n", worktreeDir)
+			for _, dir := range orphans {
+				fmt.Fprintf(os.Stderr, "  %s\n", dir)
+			}
+		}
+		for _, item := range items {
+			selected = append(selected, item.Value)
+		}
+	} else {
+		fmt.Fprintf(os.Stderr, "Expired worktrees and directories in %s that are not registered worktrees:\n", worktreeDir)
+		selected, err = tui.MultiSelect(items)
+		if err != nil {
+			return err
+		}
+		if len(selected) == 0 {
+			return quietExit(cmd, errCancelled)
+		}
+	}
+
+	removed := 0
+	for _, path := range selected {
+		if expired[path] {
+			fmt.Printf("Removing worktree: %s\n", path)
+			err := removeWorktree(path, false)
+			var unpushed *git.UnpushedError
+			if errors.Is(err, git.ErrDirtyWorktree) {
+				fmt.Fprintf(os.Stderr, "Skipped %s: contains modified or untracked files (use wt rm -f)\n", path)
+				continue
+			}
+			if errors.As(err, &unpushed) {
+				fmt.Fprintf(os.Stderr, "Skipped %s: has %d commit(s) that are on no remote (use wt rm -f)\n", path, unpushed.Commits)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			removed++
+			continue
+		}
+		fmt.Printf("Deleting: %s\n", path)
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", path, err)
+		}
+	}
+	collectGarbage(cfg, removed)
+	return nil
+}
+
+// findOrphanDirs returns the directories directly inside worktreeDir that
+// were worktrees, going by the .git file git leaves in them or by wt's
+// records, but are neither registered worktrees nor contain one. Other
+// directories are never orphans.
+func findOrphanDirs(worktreeDir string, worktrees []git.Worktree, records state.Records) ([]string, error) {
+	entries, err := os.ReadDir(worktreeDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(worktreeDir, entry.Name())
+		if holdsWorktree(dir, worktrees) || !wasWorktree(dir, records) {
+			continue
+		}
+		orphans = append(orphans, dir)
+	}
+	return orphans, nil
+}
+
+// wasWorktree reports whether dir has the .git file of a linked worktree or
+// a record of wt creating it, and wasn't kept by wt rm --keep-dir.
+func wasWorktree(dir string, records state.Records) bool {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	if records.IsKept(dir) {
+		return false
+	}
+	if _, ok := records.Worktrees[dir]; ok {
+		return true
+	}
+	info, err := os.Lstat(filepath.Join(dir, ".git"))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// repoDir returns the directory of the repository: the main worktree, or
+// the git directory of a bare repository.
+func repoDir(worktrees []git.Worktree) string {
+	if root := mainWorktreeRoot(worktrees); root != "" {
+		return root
+	}
+	dir, _ := git.CommonDir()
+	return dir
+}
+
+// containsPath reports whether path is dir or inside it, comparing resolved
+// paths.
+func containsPath(dir, path string) bool {
+	if path == "" {
+		return false
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+func holdsWorktree(dir string, worktrees []git.Worktree) bool {
+	// Compare resolved paths: git reports worktrees with symlinks resolved.
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	for _, wt := range worktrees {
+		if wt.Path == dir || strings.HasPrefix(wt.Path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
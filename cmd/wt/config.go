@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/runner"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and edit configuration",
+	Long: `Inspect and edit wt configuration.
+
+The effective configuration is the global file
+($XDG_CONFIG_HOME/wt/config.toml or ~/.config/wt/config.toml) overlaid
+with the repository's .wt.toml. Keys set in .wt.toml win.`,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print the effective configuration",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigList,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a key",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a top-level key in the repo (or global) config",
+	Long: `Set a top-level key in .wt.toml, or in the global config with --global.
+
+The value is a TOML literal; bare words are quoted automatically:
+
+  wt config set base_branch develop
+  wt config set copy_patterns '[".env*", "!.env.example"]'`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the repo (or global) config in $EDITOR",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigEdit,
+}
+
+var configGlobal bool
+
+func init() {
+	configSetCmd.Flags().BoolVar(&configGlobal, "global", false, "Write to the global config file")
+	configEditCmd.Flags().BoolVar(&configGlobal, "global", false, "Edit the global config file")
+
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configEditCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func loadRepoConfig() (*config.Config, error) {
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.LoadFromDir(repoRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return cfg, nil
+}
+
+func runConfigList(cmd *cobra.Command, args []string) error {
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+
+	if len(cfg.Sources) == 0 {
+		fmt.Println("# No config files found; showing defaults")
+	}
+	for _, source := range cfg.Sources {
+		fmt.Printf("# Loaded: %s\n", source)
+	}
+	return toml.NewEncoder(os.Stdout).Encode(cfg)
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	key := args[0]
+
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+
+	values, err := cfg.Values()
+	if err != nil {
+		return err
+	}
+
+	value, ok := values[key]
+	if !ok {
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return fmt.Errorf("unknown config key: %s (known keys: %s)", key, strings.Join(keys, ", "))
+	}
+
+	if s, ok := value.(string); ok {
+		fmt.Println(s)
+		return nil
+	}
+	return toml.NewEncoder(os.Stdout).Encode(map[string]any{key: value})
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	key, literal := args[0], args[1]
+
+	value, err := config.ParseValue(key, literal)
+	if err != nil {
+		return err
+	}
+
+	path, err := configFilePath(configGlobal)
+	if err != nil {
+		return err
+	}
+
+	if err := config.SetRaw(path, key, value); err != nil {
+		return fmt.Errorf("failed to update %s: %w", path, err)
+	}
+	log.Infof("Updated %s in %s", key, path)
+	return nil
+}
+
+func runConfigEdit(cmd *cobra.Command, args []string) error {
+	path, err := configFilePath(configGlobal)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// Run through the shell so editors configured with arguments (e.g. "code -w") work.
+	editCmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	editCmd.Stdin = os.Stdin
+	editCmd.Stdout = os.Stdout
+	editCmd.Stderr = os.Stderr
+	if err := runner.Run(editCmd); err != nil {
+		return fmt.Errorf("editor failed: %w", err)
+	}
+
+	if _, err := config.LoadFromDir(filepath.Dir(path)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s does not parse: %v\n", path, err)
+	}
+	return nil
+}
+
+// configFilePath returns the repo's .wt.toml, or the global config file if global is set.
+func configFilePath(global bool) (string, error) {
+	if global {
+		return config.GlobalConfigPath()
+	}
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(repoRoot, config.ConfigFileName), nil
+}
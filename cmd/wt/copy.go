@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/copy"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/log"
+)
+
+var copyCmd = &cobra.Command{
+	Use:   "copy [path|branch]",
+	Short: "Copy copy_patterns from the main worktree into an existing worktree",
+	Long: `Run the copy_patterns step of wt add again for an existing worktree
+(default: the current one), copying from the main worktree.
+
+Files already in the worktree are left alone, like in wt add; --update
+replaces the ones whose copy in the main worktree was modified more recently,
+e.g. after .env files drifted. --scope uses the copy_patterns of a
+[packages.<name>] entry instead.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runCopy,
+}
+
+var (
+	copyUpdate bool
+	copyScope  string
+)
+
+func init() {
+	copyCmd.Flags().BoolVar(&copyUpdate, "update", false, "Overwrite files whose source in the main worktree is newer")
+	copyCmd.Flags().StringVar(&copyScope, "scope", "", "Use the copy patterns of this [packages.<name>] entry")
+	rootCmd.AddCommand(copyCmd)
+}
+
+func runCopy(cmd *cobra.Command, args []string) error {
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	target, err := targetWorktree(worktrees, args)
+	if err != nil {
+		return err
+	}
+	source, ok := git.MainWorktree(worktrees)
+	if !ok || source.Bare {
+		return fmt.Errorf("the main worktree has no working tree to copy from")
+	}
+	if target.Path == source.Path {
+		return fmt.Errorf("%s is the main worktree, which copies come from; name a worktree to copy into", target.Path)
+	}
+
+	patterns, scopeDir := cfg.CopyPatterns, ""
+	if copyScope != "" {
+		pkg, ok := cfg.Packages[copyScope]
+		if !ok {
+			return fmt.Errorf("unknown package %q (defined: %s)", copyScope, strings.Join(packageNames(cfg), ", "))
+		}
+		patterns, scopeDir = pkg.CopyPatterns, pkg.Path
+	}
+	if len(patterns) == 0 {
+		log.Infof("No copy_patterns configured.")
+		return nil
+	}
+
+	log.Infof("Copying files from %s...", source.Path)
+	srcDir, destDir := filepath.Join(source.Path, scopeDir), filepath.Join(target.Path, scopeDir)
+	copied, err := copy.CopyFilesWith(patterns, srcDir, destDir, copy.Options{Update: copyUpdate})
+	if err != nil {
+		return fmt.Errorf("failed to copy files: %w", err)
+	}
+	if len(copied) == 0 {
+		log.Infof("Everything is up to date.")
+	}
+	return nil
+}
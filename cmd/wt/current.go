@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/git"
+)
+
+var currentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "Print the worktree the current directory is in",
+	Long: `Print the branch, base branch, path, main repository root and status of the
+worktree containing the current directory, for shell prompts and scripts.
+It runs no more than git worktree list and git status (as dirty_check
+allows), so it is cheap enough for a prompt; wt inspect shows more.
+
+The base branch is the one wt add created the worktree from, or base_branch
+when wt didn't create it. It is left out for the main worktree.
+
+The status is unknown when git status fails; uncommitted changes are also
+unknown with dirty_check = "off". The JSON then leaves out what is unknown,
+with status_error saying why.
+
+Outside a worktree wt current fails with exit code 1.`,
+	Args: cobra.NoArgs,
+	RunE: runCurrent,
+}
+
+var currentJSON bool
+
+func init() {
+	currentCmd.Flags().BoolVar(&currentJSON, "json", false, "Print the worktree as a JSON object")
+	rootCmd.AddCommand(currentCmd)
+}
+
+// currentWorktree is what wt current reports. Dirty and Unpushed are nil
+// when they are unknown, with StatusError saying why.
+type currentWorktree struct {
+	Path        string `json:"path"`
+	Branch      string `json:"branch,omitempty"`
+	Detached    bool   `json:"detached"`
+	Base        string `json:"base,omitempty"`
+	RepoRoot    string `json:"repo_root,omitempty"`
+	Main        bool   `json:"main"`
+	Dirty       *bool  `json:"dirty,omitempty"`
+	Unpushed    *int   `json:"unpushed,omitempty"`
+	StatusError string `json:"status_error,omitempty"`
+}
+
+func runCurrent(cmd *cobra.Command, args []string) error {
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	wt, err := git.CurrentWorktree(worktrees)
+	if err != nil {
+		return err
+	}
+
+	cur := currentWorktree{
+		Path:     wt.Path,
+		Branch:   wt.Branch,
+		Detached: wt.Detached,
+		RepoRoot: mainWorktreeRoot(worktrees),
+		Main:     wt.IsMain,
+	}
+	if !wt.IsMain {
+		cur.Base = worktreeBase(cfg, loadRecords(), wt.Path)
+	}
+	if wt.Bare {
+		cur.StatusError = "bare repository"
+	} else {
+		check := dirtyCheck(cfg)
+		status, err := git.GetStatusWith(wt.Path, check)
+		switch {
+		case err != nil:
+			cur.StatusError = err.Error()
+		case check == git.DirtyOff:
+			cur.Unpushed = &status.Unpushed
+			cur.StatusError = `uncommitted changes not checked (dirty_check = "off")`
+		default:
+			cur.Dirty, cur.Unpushed = &status.Dirty, &status.Unpushed
+		}
+	}
+
+	if currentJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cur)
+	}
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Printf("%-10s %s\n", name+":", value)
+		}
+	}
+	field("Branch", wt.Label())
+	field("Base", cur.Base)
+	field("Path", cur.Path)
+	field("Repo root", cur.RepoRoot)
+	field("Status", currentStatus(cur))
+	return nil
+}
+
+// currentStatus describes the status of cur for wt current's text output:
+// "clean", a status badge, or "unknown" for what wasn't found out.
+func currentStatus(cur currentWorktree) string {
+	var status git.Status
+	if cur.Unpushed != nil {
+		status.Unpushed = *cur.Unpushed
+	}
+	if cur.Dirty != nil {
+		status.Dirty = *cur.Dirty
+	}
+	switch {
+	case cur.Dirty != nil && !status.Risky():
+		return "clean"
+	case cur.Dirty != nil:
+		return statusBadge(status)
+	case status.Unpushed > 0:
+		return statusBadge(status) + ", uncommitted changes unknown"
+	default:
+		return "unknown"
+	}
+}
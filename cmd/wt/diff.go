@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/git"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [path|branch]",
+	Short: "Summarize what a worktree's branch changed since its base branch",
+	Long: `List the commits of a worktree's branch (default: the current worktree) that
+its base branch lacks, followed by a diffstat of the files they changed.
+
+The base is the branch the worktree was created from (base_branch when wt
+has no record of it), or --base; origin's copy of it is used when there is
+one. Uncommitted changes are counted but not part of the diff.
+
+--patch shows the full diff instead, through git's pager.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDiff,
+}
+
+var (
+	diffBase  string
+	diffPatch bool
+)
+
+func init() {
+	diffCmd.Flags().StringVar(&diffBase, "base", "", "Compare with this branch instead of the worktree's base branch")
+	diffCmd.Flags().BoolVarP(&diffPatch, "patch", "p", false, "Show the full diff in git's pager")
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	target, err := targetWorktree(worktrees, args)
+	if err != nil {
+		return err
+	}
+	if target.Bare {
+		return fmt.Errorf("%s is a bare repository and has no working tree", target.Path)
+	}
+
+	base := diffBase
+	if base == "" {
+		base = worktreeBase(cfg, loadRecords(), target.Path)
+	}
+	ref := baseRef(base)
+	if !git.RefExists(ref) {
+		return fmt.Errorf("base branch %s not found", base)
+	}
+
+	if diffPatch {
+		return git.ShowDiff(target.Path, ref)
+	}
+
+	commits, err := git.Commits(target.Path, ref)
+	if err != nil {
+		return err
+	}
+	stat, err := git.DiffStat(target.Path, ref)
+	if err != nil {
+		return err
+	}
+
+	if len(commits) == 0 {
+		fmt.Printf("%s has no commits that %s lacks\n", target.Label(), ref)
+	} else {
+		fmt.Printf("%s: %d commit(s) not on %s\n", target.Label(), len(commits), ref)
+		for _, commit := range commits {
+			fmt.Printf("  %s\n", commit)
+		}
+	}
+	if stat != "" {
+		fmt.Println()
+		fmt.Println(stat)
+	}
+	if changes, err := git.Changes(target.Path); err == nil && len(changes) > 0 {
+		fmt.Printf("\nUncommitted: %d file(s), not included above\n", len(changes))
+	}
+	return nil
+}
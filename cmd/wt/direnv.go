@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/default-anton/wt/internal/copy"
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/runner"
+	"github.com/default-anton/wt/internal/state"
+)
+
+// envrc is the file direnv loads.
+const envrc = ".envrc"
+
+// allowDirenv copies the main worktree's .envrc into the worktree at path when
+// it has none (an untracked .envrc isn't checked out), then runs direnv allow
+// there, so direnv doesn't block it on the first cd. A .envrc the branch
+// brings is only allowed when it matches the main worktree's, which the user
+// already reviewed; otherwise direnv keeps blocking it. A missing direnv
+// only warns.
+func (a *adder) allowDirenv(path string, rec *state.Record) error {
+	own, err := os.ReadFile(filepath.Join(path, envrc))
+	if err == nil {
+		main, err := os.ReadFile(filepath.Join(a.repoRoot, envrc))
+		if err != nil || !bytes.Equal(own, main) {
+			fmt.Fprintf(os.Stderr, "Warning: %s in %s differs from the main worktree's; review it and run direnv allow\n", envrc, path)
+			return nil
+		}
+	} else if os.IsNotExist(err) {
+		if _, err := os.Stat(filepath.Join(a.repoRoot, envrc)); err != nil {
+			log.Explainf("direnv: no %s in the repository, nothing to allow", envrc)
+			return nil
+		}
+		copied, err := copy.CopyPaths([]string{envrc}, a.repoRoot, path, copy.Options{})
+		if err != nil {
+			return fmt.Errorf("failed to copy files: %w", err)
+		}
+		rec.Copied = append(rec.Copied, copied...)
+	}
+
+	if _, err := exec.LookPath("direnv"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: direnv = true but direnv is not installed; %s stays blocked\n", envrc)
+		return nil
+	}
+	log.Infof("Allowing %s for direnv...", envrc)
+	if err := runner.Run(exec.Command("direnv", "allow", path)); err != nil {
+		return fmt.Errorf("direnv allow failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/wtenv"
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env [path|branch]",
+	Short: "Print the environment for a worktree",
+	Long: `Print the WT_* variables and env file contents for a worktree.
+
+Defaults to the worktree containing the current directory. The output is
+meant to be evaluated by the shell:
+
+  eval "$(wt env)"`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEnv,
+}
+
+var envJSON bool
+
+func init() {
+	envCmd.Flags().BoolVar(&envJSON, "json", false, "Print variables as a JSON object")
+
+	rootCmd.AddCommand(envCmd)
+}
+
+// worktreeEnv returns the WT_* variables of a worktree as KEY=VALUE pairs.
+// Env files are left out: they may hold secrets that don't belong in a
+// multiplexer's environment.
+func worktreeEnv(cfg *config.Config, path, branch string) []string {
+	repoRoot := path
+	if worktrees, err := git.ListWorktrees(); err == nil {
+		if mainWt, ok := git.MainWorktree(worktrees); ok {
+			repoRoot = mainWt.Path
+		}
+	}
+	return infoEnv(wtenv.Info{
+		WorktreePath: path,
+		Branch:       branch,
+		BaseBranch:   cfg.BaseBranch,
+		RepoRoot:     repoRoot,
+	})
+}
+
+// infoEnv returns the WT_* variables describing info as KEY=VALUE pairs.
+func infoEnv(info wtenv.Info) []string {
+	vars, _ := wtenv.Build(info, nil)
+	env := make([]string, len(vars))
+	for i, v := range vars {
+		env[i] = v.Key + "=" + v.Value
+	}
+	return env
+}
+
+func runEnv(cmd *cobra.Command, args []string) error {
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+
+	target, err := targetWorktree(worktrees, args)
+	if err != nil {
+		return err
+	}
+
+	repoRoot := target.Path
+	if mainWt, ok := git.MainWorktree(worktrees); ok {
+		repoRoot = mainWt.Path
+	}
+
+	cfg, err := config.LoadFromDir(repoRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	vars, err := wtenv.Build(wtenv.Info{
+		WorktreePath: target.Path,
+		Branch:       target.Branch,
+		BaseBranch:   cfg.BaseBranch,
+		RepoRoot:     repoRoot,
+	}, cfg.EnvFiles)
+	if err != nil {
+		return err
+	}
+
+	if envJSON {
+		out, err := wtenv.FormatJSON(vars)
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+		return nil
+	}
+
+	fmt.Print(wtenv.FormatShell(vars))
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes, documented for people writing their own shell wrappers.
+const (
+	exitError       = 1   // any failure
+	exitNoWorktrees = 2   // nothing to select from
+	exitCancelled   = 130 // the user dismissed a prompt (ESC/Ctrl+C), like fzf
+)
+
+// exitStatus ends the command with a specific exit code. Its message, if any,
+// has already been shown to the user.
+type exitStatus struct {
+	code   int
+	reason string
+}
+
+func (e *exitStatus) Error() string {
+	return e.reason
+}
+
+var (
+	errCancelled   = &exitStatus{code: exitCancelled, reason: "cancelled"}
+	errNoWorktrees = &exitStatus{code: exitNoWorktrees, reason: "no worktrees"}
+)
+
+// quietExit returns err without cobra printing it or the command usage.
+func quietExit(cmd *cobra.Command, err *exitStatus) error {
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return err
+}
+
+func exitCode(err error) int {
+	var status *exitStatus
+	if errors.As(err, &status) {
+		return status.code
+	}
+	return exitError
+}
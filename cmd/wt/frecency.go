@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/state"
+	"github.com/default-anton/wt/internal/tui"
+)
+
+// loadUsage reads the repository's worktree usage. Ordering is a
+// convenience, so a broken usage file only warns.
+func loadUsage() (state.Usage, string) {
+	gitDir, err := git.CommonDir()
+	if err != nil {
+		return state.Usage{}, ""
+	}
+	path := state.UsagePath(gitDir)
+	usage, err := state.LoadUsage(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return usage, path
+}
+
+// sortByFrecency puts the worktrees entered most often and most recently
+// first, keeping git's order among equals.
+func sortByFrecency(items []tui.Item, usage state.Usage, now time.Time) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return usage.Frecency(items[i].Value, now) > usage.Frecency(items[j].Value, now)
+	})
+}
+
+// recordVisit counts a visit to path, forgetting worktrees that no longer
+// exist.
+func recordVisit(usagePath, path string, worktrees []git.Worktree) {
+	if usagePath == "" {
+		return
+	}
+	err := state.UpdateUsage(usagePath, func(usage *state.Usage) error {
+		usage.Keep(worktreePaths(worktrees))
+		usage.Visit(path, time.Now())
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record worktree usage: %v\n", err)
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/hooks"
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/styles"
+)
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "List or run post-creation hooks",
+}
+
+var hooksRunCmd = &cobra.Command{
+	Use:   "run [path|branch]",
+	Short: "Run the post-creation hooks in an existing worktree",
+	Long: `Run post_hooks again in an existing worktree (default: the current one),
+e.g. after one failed during wt add or when the setup changed.
+
+Hooks run in order with their if_branch, if_exists and if_changed guards,
+like in wt add; if_changed compares against the main worktree. --hook
+limits the run to the named hooks, --force ignores the guards, and --scope
+runs the hooks of a [packages.<name>] entry.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runHooksRun,
+}
+
+var hooksLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List the post-creation hooks",
+	Args:  cobra.NoArgs,
+	RunE:  runHooksLs,
+}
+
+var (
+	hooksNames []string
+	hooksForce bool
+	hooksScope string
+)
+
+func init() {
+	hooksRunCmd.Flags().StringArrayVar(&hooksNames, "hook", nil, "Only run the hook with this name (repeatable)")
+	hooksRunCmd.Flags().BoolVar(&hooksForce, "force", false, "Run hooks even if their if_branch, if_exists or if_changed guard does not match")
+	hooksRunCmd.Flags().StringVar(&hooksScope, "scope", "", "Run the hooks of this [packages.<name>] entry")
+
+	hooksCmd.AddCommand(hooksRunCmd)
+	hooksCmd.AddCommand(hooksLsCmd)
+	rootCmd.AddCommand(hooksCmd)
+}
+
+func runHooksRun(cmd *cobra.Command, args []string) error {
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadFromDir(repoRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	target, err := targetWorktree(worktrees, args)
+	if err != nil {
+		return err
+	}
+	if target.Bare {
+		return fmt.Errorf("%s is a bare repository and has no working tree", target.Path)
+	}
+
+	list, scopeDir := cfg.PostHooks, ""
+	if hooksScope != "" {
+		pkg, ok := cfg.Packages[hooksScope]
+		if !ok {
+			return fmt.Errorf("unknown package %q (defined: %s)", hooksScope, strings.Join(packageNames(cfg), ", "))
+		}
+		list, scopeDir = pkg.PostHooks, pkg.Path
+	}
+	list, err = selectHooks(list, hooksNames)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		log.Infof("No post_hooks configured.")
+		return nil
+	}
+	if hooksForce {
+		for i := range list {
+			list[i].IfBranch, list[i].IfExists, list[i].IfChanged = "", "", ""
+		}
+	}
+
+	if err := ensureTrusted(repoRoot); err != nil {
+		return err
+	}
+	root, srcDir := mainWorktreeRoot(worktrees), ""
+	if root != "" && root != target.Path {
+		srcDir = filepath.Join(root, scopeDir)
+	}
+	results, err := hooks.Run(list, filepath.Join(target.Path, scopeDir), srcDir, root, target.Branch)
+	printHookSummary(results)
+	return err
+}
+
+// printHookSummary lists how each hook ended and how long it took, so a
+// failure or a slow hook stands out after their interleaved output.
+func printHookSummary(results []hooks.Result) {
+	if len(results) == 0 {
+		return
+	}
+	width := 0
+	for _, r := range results {
+		width = max(width, len(r.Name))
+	}
+	log.Infof("Hooks:")
+	for _, r := range results {
+		var mark, detail string
+		switch r.Status {
+		case hooks.OK:
+			mark, detail = styles.MatchStyle.Render("✓"), roundDuration(r.Duration)
+		case hooks.Failed:
+			mark, detail = styles.DirtyStyle.Render("✗"), roundDuration(r.Duration)+" failed"
+		default:
+			mark, detail = styles.DimStyle.Render("-"), "skipped"
+		}
+		log.Infof("  %s %-*s  %s", mark, width, r.Name, styles.DimStyle.Render(detail))
+	}
+}
+
+// selectHooks returns the hooks with the given names, in config order, or
+// all of them when no names are given.
+func selectHooks(list []config.Hook, names []string) ([]config.Hook, error) {
+	if len(names) == 0 {
+		return append([]config.Hook(nil), list...), nil
+	}
+	want := make(map[string]bool, len(names))
+	for _, name := range names {
+		want[name] = true
+	}
+	var selected []config.Hook
+	for _, h := range list {
+		if want[h.Name] {
+			selected = append(selected, h)
+			delete(want, h.Name)
+		}
+	}
+	for _, name := range names {
+		if want[name] {
+			return nil, fmt.Errorf("no hook named %q (see wt hooks ls)", name)
+		}
+	}
+	return selected, nil
+}
+
+func runHooksLs(cmd *cobra.Command, args []string) error {
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+	printHooks("post_hooks", cfg.PostHooks)
+	for _, name := range packageNames(cfg) {
+		printHooks("packages."+name+".post_hooks", cfg.Packages[name].PostHooks)
+	}
+	return nil
+}
+
+func printHooks(section string, list []config.Hook) {
+	if len(list) == 0 {
+		return
+	}
+	fmt.Println(styles.DimStyle.Render(section))
+	for _, h := range list {
+		fmt.Printf("  %s  %s%s\n", styles.BranchStyle.Render(h.Name), h.Run, styles.DimStyle.Render(hookGuards(h)))
+	}
+}
+
+// hookGuards describes the preset of a hook and where and when it runs, e.g.
+// " (use bundler, dir web, if_branch hotfix/*)".
+func hookGuards(h config.Hook) string {
+	var guards []string
+	if h.Use != "" {
+		guards = append(guards, "use "+h.Use)
+	}
+	if h.Dir != "" {
+		guards = append(guards, "dir "+h.Dir)
+	}
+	if h.IfBranch != "" {
+		guards = append(guards, "if_branch "+h.IfBranch)
+	}
+	if h.IfExists != "" {
+		guards = append(guards, "if_exists "+h.IfExists)
+	}
+	if h.IfChanged != "" {
+		guards = append(guards, "if_changed "+h.IfChanged)
+	}
+	if len(guards) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(guards, ", ") + ")"
+}
+
+// roundDuration shortens d to milliseconds below a second and to tenths of
+// a second above.
+func roundDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/state"
+	"github.com/default-anton/wt/internal/styles"
+	"github.com/default-anton/wt/internal/term"
+)
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect [path|branch]",
+	Short: "Show everything wt knows about a worktree",
+	Long: `Show one worktree (default: the current one) in detail: branch, commit,
+upstream and base with ahead/behind counts, uncommitted files, how wt add
+created it (input, copied paths, hooks and how they ended), note, preview
+URL, expiry, and the tmux windows it is open in.
+
+The wt cd and wt rm selectors show the same details for the highlighted
+worktree with CTRL+O.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runInspect,
+}
+
+var inspectJSON bool
+
+func init() {
+	inspectCmd.Flags().BoolVar(&inspectJSON, "json", false, "Print the details as a JSON object")
+	rootCmd.AddCommand(inspectCmd)
+}
+
+// inspection is everything wt knows about one worktree.
+type inspection struct {
+	git.Worktree
+	Upstream       string   `json:"upstream,omitempty"`
+	UpstreamAhead  int      `json:"upstream_ahead"`
+	UpstreamBehind int      `json:"upstream_behind"`
+	Base           string   `json:"base,omitempty"`
+	BaseAhead      int      `json:"base_ahead"`
+	BaseBehind     int      `json:"base_behind"`
+	Changes        []string `json:"changes"`
+	Note           string   `json:"note,omitempty"`
+	URL            string   `json:"url,omitempty"`
+	Tmux           []string `json:"tmux,omitempty"`
+	*state.Record
+}
+
+// inspector gathers inspections, loading what is shared between worktrees
+// once.
+type inspector struct {
+	cfg       *config.Config
+	records   state.Records
+	entries   map[string]state.Entry
+	locations map[string][]term.TmuxLocation
+}
+
+func newInspector(cfg *config.Config, worktrees []git.Worktree) *inspector {
+	in := &inspector{cfg: cfg, locations: term.TmuxLocations(worktreePaths(worktrees))}
+	in.records = loadRecords()
+	if store, err := openStateStore(cfg); err == nil {
+		if st, err := store.Load(); err == nil {
+			in.entries = st.Worktrees
+		}
+	}
+	return in
+}
+
+func (in *inspector) inspect(wt git.Worktree) inspection {
+	ins := inspection{Worktree: wt, Changes: []string{}}
+	if rec, ok := in.records.Worktrees[wt.Path]; ok {
+		ins.Record = &rec
+	}
+	for _, loc := range in.locations[wt.Path] {
+		ins.Tmux = append(ins.Tmux, loc.String())
+	}
+	if e, ok := in.entries[wt.Branch]; ok && wt.Branch != "" {
+		ins.Note, ins.URL = e.Note, e.URL
+	}
+	if wt.Bare {
+		return ins
+	}
+
+	if ins.Upstream = git.Upstream(wt.Path); ins.Upstream != "" {
+		ins.UpstreamAhead, ins.UpstreamBehind, _ = git.AheadBehind(wt.Path, ins.Upstream)
+	}
+	ins.Base = in.cfg.BaseBranch
+	if ins.Record != nil && ins.Record.BaseBranch != "" {
+		ins.Base = ins.Record.BaseBranch
+	}
+	if ins.Base != "" && ins.Base != wt.Branch && git.RefExists(ins.Base) {
+		ins.BaseAhead, ins.BaseBehind, _ = git.AheadBehind(wt.Path, ins.Base)
+	} else {
+		ins.Base = ""
+	}
+	if changes, err := git.Changes(wt.Path); err == nil {
+		ins.Changes = changes
+	}
+	return ins
+}
+
+// selectorDetails returns a tui.Options.Details function showing the
+// inspection of a worktree by path. The shared data is only loaded once the
+// details are first asked for.
+func selectorDetails(cfg *config.Config, worktrees []git.Worktree) func(string) string {
+	var in *inspector
+	return func(path string) string {
+		wt, ok := git.FindWorktree(worktrees, path)
+		if !ok {
+			return ""
+		}
+		if in == nil {
+			in = newInspector(cfg, worktrees)
+		}
+		var buf bytes.Buffer
+		printInspection(&buf, in.inspect(*wt))
+		return buf.String()
+	}
+}
+
+func runInspect(cmd *cobra.Command, args []string) error {
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	target, err := targetWorktree(worktrees, args)
+	if err != nil {
+		return err
+	}
+	ins := newInspector(cfg, worktrees).inspect(*target)
+	if inspectJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(ins)
+	}
+	printInspection(os.Stdout, ins)
+	return nil
+}
+
+func printInspection(w io.Writer, ins inspection) {
+	field := func(name, format string, args ...any) {
+		fmt.Fprintf(w, "  %-10s %s\n", name+":", fmt.Sprintf(format, args...))
+	}
+
+	fmt.Fprintln(w, styles.BranchStyle.Render(ins.Label()))
+	field("Path", "%s", ins.Path)
+	if ins.Commit != "" {
+		field("Commit", "%s", ins.Commit[:min(len(ins.Commit), 7)])
+	}
+	if ins.Upstream != "" {
+		field("Upstream", "%s (%d ahead, %d behind)", ins.Upstream, ins.UpstreamAhead, ins.UpstreamBehind)
+	} else if !ins.Bare {
+		field("Upstream", "none")
+	}
+	if ins.Base != "" {
+		field("Base", "%s (%d ahead, %d behind)", ins.Base, ins.BaseAhead, ins.BaseBehind)
+	}
+	if rec := ins.Record; rec != nil {
+		created := rec.CreatedAt.Local().Format("2006-01-02 15:04")
+		if rec.Input != "" && rec.Input != rec.InitialBranch {
+			created += fmt.Sprintf(" from %q", rec.Input)
+		}
+		if rec.Scope != "" {
+			created += " (scope " + rec.Scope + ")"
+		}
+		if rec.Profile != "" {
+			created += " (profile " + rec.Profile + ")"
+		}
+		if rec.Adopted {
+			created += " (adopted)"
+		}
+		field("Created", "%s", created)
+		if len(rec.Copied) > 0 {
+			field("Copied", "%s", strings.Join(rec.Copied, ", "))
+		}
+		if len(rec.Hooks) > 0 {
+			hooks := make([]string, len(rec.Hooks))
+			for i, h := range rec.Hooks {
+				hooks[i] = fmt.Sprintf("%s (%s)", h.Name, h.Status)
+			}
+			field("Hooks", "%s", strings.Join(hooks, ", "))
+		}
+	}
+	if ins.Record != nil && !ins.ExpiresAt.IsZero() {
+		field("Expires", "%s", ins.ExpiresAt.Local().Format("2006-01-02 15:04"))
+	}
+	if ins.Note != "" {
+		field("Note", "%s", ins.Note)
+	}
+	if ins.URL != "" {
+		field("URL", "%s", ins.URL)
+	}
+	if len(ins.Tmux) > 0 {
+		field("tmux", "%s", strings.Join(ins.Tmux, ", "))
+	}
+	if ins.Bare {
+		return
+	}
+	if len(ins.Changes) == 0 {
+		field("Changes", "none")
+		return
+	}
+	field("Changes", "%d", len(ins.Changes))
+	for _, change := range ins.Changes {
+		fmt.Fprintf(w, "    %s\n", change)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/log"
+)
+
+var (
+	quiet   bool
+	verbose bool
+	trace   bool
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Suppress informational messages on stderr (warnings and errors are still shown)")
+	rootCmd.PersistentFlags().BoolVar(&trace, "trace", false, "Print every external command (git, cp, tmux, hooks) before running it, as a shell line you can paste")
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Log every git/cp/hook command wt runs, with timings (or set WT_DEBUG=1)")
+}
+
+// setupLog applies --quiet and --trace, and turns on debug logging for
+// --verbose or a truthy WT_DEBUG.
+func setupLog() {
+	debug, _ := strconv.ParseBool(os.Getenv("WT_DEBUG"))
+	log.SetQuiet(quiet)
+	log.SetTrace(trace)
+	log.Enable(verbose || debug)
+}
+
+// explainConfig explains which config files the settings of this run come from.
+func explainConfig(cfg *config.Config) {
+	if len(cfg.Sources) == 0 {
+		log.Explainf("config: no %s or global config found, using defaults", config.ConfigFileName)
+		return
+	}
+	log.Explainf("config: read %s (later files override earlier ones)", strings.Join(cfg.Sources, ", "))
+}
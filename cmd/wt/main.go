@@ -1,22 +1,35 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/default-anton/wt/internal/config"
 	"github.com/default-anton/wt/internal/copy"
+	"github.com/default-anton/wt/internal/detect"
+	"github.com/default-anton/wt/internal/du"
 	"github.com/default-anton/wt/internal/git"
 	"github.com/default-anton/wt/internal/hooks"
+	"github.com/default-anton/wt/internal/log"
 	"github.com/default-anton/wt/internal/preprocess"
+	"github.com/default-anton/wt/internal/state"
 	"github.com/default-anton/wt/internal/styles"
+	"github.com/default-anton/wt/internal/term"
+	"github.com/default-anton/wt/internal/tmpl"
 	"github.com/default-anton/wt/internal/tui"
+	"github.com/default-anton/wt/internal/wtenv"
 )
 
 var (
@@ -25,49 +38,234 @@ var (
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
 var rootCmd = &cobra.Command{
-	Use:     "wt",
-	Short:   "Git worktree manager",
-	Long:    `A fast CLI tool for managing git worktrees with fuzzy selection.`,
+	Use:   "wt",
+	Short: "Git worktree manager",
+	Long: `A fast CLI tool for managing git worktrees with fuzzy selection.
+
+Exit codes:
+  0    success
+  1    error
+  2    no worktrees to select from
+  130  selection cancelled (ESC or Ctrl+C)`,
 	Version: version,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		setupLog()
+		tui.SetNoTUI(noTUI)
+		return applySettings()
+	},
+}
+
+// noTUI replaces the full-screen selectors with numbered lists on stdin.
+var noTUI bool
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&noTUI, "no-tui", false, "Pick from numbered lists on stdin instead of the full-screen finder (the fallback without a terminal)")
+}
+
+// applySettings activates the theme named by WT_THEME, or else by the theme
+// config key, reduced motion if WT_REDUCED_MOTION or reduced_motion asks
+// for it, the selector_view and finder, the hook_output mode and the git
+// backend from WT_GIT_BACKEND or git_backend. Config errors are left for the
+// command itself to report.
+func applySettings() error {
+	name := os.Getenv("WT_THEME")
+	reduced, _ := strconv.ParseBool(os.Getenv("WT_REDUCED_MOTION"))
+	view := tui.ViewNormal
+	finder := tui.FinderBuiltin
+	backend := os.Getenv("WT_GIT_BACKEND")
+	if repoRoot, err := git.GetRepoRoot(); err == nil {
+		if cfg, err := config.LoadFromDir(repoRoot); err == nil {
+			if name == "" {
+				name = cfg.Theme
+			}
+			if backend == "" {
+				backend = cfg.GitBackend
+			}
+			reduced = reduced || cfg.ReducedMotion
+			view, _ = tui.ParseView(cfg.SelectorView)
+			finder, _ = tui.ParseFinder(cfg.Finder)
+			hooks.SetOutput(cfg.HookOutput)
+		}
+	}
+	log.SetReducedMotion(reduced)
+	tui.SetReducedMotion(reduced)
+	tui.SetView(view)
+	if exe, err := os.Executable(); err == nil {
+		tui.SetFinder(finder, exe, "inspect")
+	} else {
+		tui.SetFinder(finder)
+	}
+	theme, err := styles.LookupTheme(name)
+	if err != nil {
+		return fmt.Errorf("WT_THEME: %w", err)
+	}
+	styles.Apply(theme)
+	// An invalid git_backend is reported with the rest of the config.
+	if b, err := git.ParseBackend(backend); err == nil {
+		git.UseBackend(b)
+	} else if os.Getenv("WT_GIT_BACKEND") != "" {
+		return fmt.Errorf("WT_GIT_BACKEND: %w", err)
+	}
+	return nil
 }
 
 var addCmd = &cobra.Command{
-	Use:   "add <input>",
-	Short: "Create a new worktree",
+	Use:   "add <input>...",
+	Short: "Create new worktree(s)",
 	Long: `Create a new git worktree.
 
 If a preprocessing script is configured, the input is passed to it
-to generate the branch name. Otherwise, input is used as the branch name.`,
-	Args: cobra.ExactArgs(1),
+to generate the branch name; the built-in preprocess modes (slug, jira,
+preprocess_match) rewrite it without a script. Otherwise, input is used as
+the branch name.
+
+--no-preprocess uses the input as the branch name as-is, and --preprocess
+runs a one-off command (like preprocess_command) instead of the configured
+preprocessing.
+
+--no-copy and --no-hooks skip copy_patterns and post_hooks, e.g. to quickly
+look at a branch; --only-worktree skips preprocessing, copying and hooks alike.
+
+With --detach, the input is a ref (tag, commit SHA, or remote branch) and the
+worktree is checked out at it without creating a branch.
+
+With --empty, the worktree starts on a new orphan branch with no history and
+no files (for docs, gh-pages, or spikes). The branch is created by its first
+commit.
+
+With --ttl (e.g. 2d, 1w, 12h), the worktree is recorded as temporary: once the
+time is up, wt ls flags it as expired and wt clean offers to remove it.
+
+Several inputs (or --stdin, one input per line) create a batch of worktrees.
+Each one is set up independently and a summary is printed at the end.
+
+--sparse <profile> checks out only the directories of a [sparse_profiles]
+entry (cone-mode sparse checkout), overriding sparse_paths.
+
+--profile <name> sets the worktree up with the copy_patterns, post_hooks and
+sparse_paths of a [profiles.<name>] entry, for the parts of a monorepo that
+need different setup. Keys the profile leaves out keep their usual values.
+
+--explain prints why each step went the way it did (config files read,
+base branch, existing or new branch, directory name, copied and skipped
+paths, hook guards) to help debug a team's configuration.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && !addStdin {
+			return fmt.Errorf("requires at least 1 input (or --stdin)")
+		}
+		return nil
+	},
 	RunE: runAdd,
 }
 
 var (
 	addBase      string
-	addTmux      bool
+	addDetach    bool
+	addEmpty     bool
+	addExplain   bool
+	addNoCopy    bool
+	addNoHooks   bool
+	addNoPrep    bool
+	addNoVerify  bool
+	addOnlyWt    bool
+	addOpen      openFlags
+	addPrep      string
 	addPrintPath bool
+	addProfile   string
+	addScope     string
+	addSparse    string
+	addStdin     bool
+	addTTL       string
 )
 
 func init() {
 	addCmd.Flags().StringVar(&addBase, "base", "", "Base branch for new branches (overrides config)")
-	addCmd.Flags().BoolVarP(&addTmux, "tmux", "t", false, "Open in new tmux pane")
+	addCmd.Flags().BoolVar(&addDetach, "detach", false, "Check out the input ref (tag, SHA, remote branch) without creating a branch")
+	addCmd.Flags().BoolVar(&addEmpty, "empty", false, "Start the worktree on a new orphan branch with no history or files")
+	addCmd.MarkFlagsMutuallyExclusive("base", "detach", "empty")
+	addCmd.Flags().BoolVar(&addNoPrep, "no-preprocess", false, "Use the input as the branch name, skipping preprocess, preprocess_script and preprocess_command")
+	addCmd.Flags().StringVar(&addPrep, "preprocess", "", "Derive the branch name with this command instead of the configured preprocessing")
+	addCmd.MarkFlagsMutuallyExclusive("no-preprocess", "preprocess", "detach")
+	addCmd.Flags().BoolVar(&addNoCopy, "no-copy", false, "Skip copy_patterns")
+	addCmd.Flags().BoolVar(&addNoHooks, "no-hooks", false, "Skip post_hooks")
+	addCmd.Flags().BoolVar(&addOnlyWt, "only-worktree", false, "Just create the worktree: same as --no-preprocess --no-copy --no-hooks")
+	addCmd.MarkFlagsMutuallyExclusive("only-worktree", "preprocess")
+	addCmd.Flags().BoolVar(&addNoVerify, "no-verify", false, "Skip the [branch_rules] naming checks for new branches and the pre_add_hooks")
+	addCmd.Flags().BoolVar(&addExplain, "explain", false, "Explain each decision (branch, base, directory, copies, hooks) on stderr")
+	addOpen.register(addCmd)
 	addCmd.Flags().BoolVar(&addPrintPath, "print-path", false, "Print worktree path (for shell integration)")
+	addCmd.Flags().StringVar(&addScope, "scope", "", "Only run copy patterns and hooks of this [packages.<name>] entry")
+	addCmd.Flags().StringVar(&addSparse, "sparse", "", "Check out only the directories of this [sparse_profiles] entry")
+	addCmd.Flags().StringVar(&addProfile, "profile", "", "Use the copy_patterns, post_hooks and sparse_paths of this [profiles.<name>] entry")
+	addCmd.MarkFlagsMutuallyExclusive("scope", "profile")
+	addCmd.MarkFlagsMutuallyExclusive("sparse", "empty")
+	addCmd.Flags().BoolVar(&addStdin, "stdin", false, "Read inputs from stdin, one per line")
+	addCmd.Flags().StringVar(&addTTL, "ttl", "", "Mark the worktree as expiring after this long (e.g. 2d, 1w, 12h)")
+	addCmd.MarkFlagsMutuallyExclusive("ttl", "detach")
 
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(cdCmd)
 	rootCmd.AddCommand(removeCmd)
 	rootCmd.AddCommand(lsCmd)
+	initCmd.Flags().BoolVar(&initDetect, "detect", false, "Suggest copy_patterns and post_hooks from the project files in this repository")
+	initCmd.Flags().BoolVar(&initForce, "force", false, "Regenerate an existing config after showing the changes (keeps a .bak copy)")
+	initCmd.Flags().BoolVarP(&initYes, "yes", "y", false, "Don't ask: with --force replace the config, and adopt existing worktrees")
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(shellInitCmd)
 }
 
+// adder holds the per-invocation state shared by every input of `wt add`.
+type adder struct {
+	cfg          *config.Config
+	repoRoot     string
+	baseBranch   string
+	copyPatterns []string
+	postHooks    []config.Hook
+	scopeDir     string
+	ttl          time.Duration
+	lfs          git.LFSMode
+	sparsePaths  []string
+	direnv       bool
+	ci           bool // record the worktree as created by wt ci
+
+	// Preprocessing pipeline; at most one of the two is set.
+	prepScripts  []string
+	prepCommands []string
+	prepBuiltin  *preprocess.Builtin
+}
+
+// created describes a worktree set up by `wt add`.
+type created struct {
+	path   string
+	branch string // empty for detached worktrees
+	label  string
+}
+
 func runAdd(cmd *cobra.Command, args []string) error {
-	input := args[0]
+	log.SetExplain(addExplain)
+	inputs := args
+	if addStdin {
+		stdinInputs, err := readInputs(os.Stdin)
+		if err != nil {
+			return err
+		}
+		inputs = append(inputs, stdinInputs...)
+	}
+	if len(inputs) == 0 {
+		return fmt.Errorf("no inputs given")
+	}
+	var ttl time.Duration
+	if addTTL != "" {
+		var err error
+		if ttl, err = state.ParseTTL(addTTL); err != nil {
+			return err
+		}
+	}
 
 	repoRoot, err := git.GetRepoRoot()
 	if err != nil {
@@ -78,124 +276,748 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
-
-	branch, err := preprocess.Run(cfg.PreprocessScript, input, repoRoot)
-	if err != nil {
+	if err := ensureTrusted(repoRoot); err != nil {
 		return err
 	}
+	if addProfile != "" {
+		profile, ok := cfg.Profiles[addProfile]
+		if !ok {
+			return fmt.Errorf("unknown profile %q (defined: %s)", addProfile, strings.Join(profileNames(cfg), ", "))
+		}
+		cfg = cfg.WithProfile(profile)
+		log.Explainf("profile: using the settings of profiles.%s", addProfile)
+	}
 
-	fmt.Fprintf(os.Stderr, "Branch name: %s\n", branch)
-
-	baseBranch := cfg.BaseBranch
+	a := &adder{
+		cfg:          cfg,
+		repoRoot:     repoRoot,
+		baseBranch:   cfg.BaseBranch,
+		copyPatterns: cfg.CopyPatterns,
+		postHooks:    cfg.PostHooks,
+		direnv:       cfg.Direnv,
+		ttl:          ttl,
+		prepScripts:  cfg.PreprocessScript,
+		prepCommands: cfg.PreprocessCommand,
+	}
+	explainConfig(cfg)
+	a.lfs, _ = git.ParseLFSMode(cfg.LFS)
+	a.prepBuiltin, _ = preprocess.ParseBuiltin(cfg.Preprocess, cfg.PreprocessMatch, cfg.PreprocessReplace)
+	if addOnlyWt {
+		addNoPrep, addNoCopy, addNoHooks = true, true, true
+	}
+	switch {
+	case addNoPrep:
+		a.prepScripts, a.prepCommands, a.prepBuiltin = nil, nil, nil
+	case addPrep != "":
+		a.prepScripts, a.prepCommands, a.prepBuiltin = nil, []string{addPrep}, nil
+	}
 	if addBase != "" {
-		baseBranch = addBase
+		a.baseBranch = addBase
+		log.Explainf("base branch: %s (from --base)", addBase)
+	} else {
+		log.Explainf("base branch: %s (base_branch setting; --base overrides it)", a.baseBranch)
+	}
+	if addScope != "" {
+		pkg, ok := cfg.Packages[addScope]
+		if !ok {
+			return fmt.Errorf("unknown package %q (defined: %s)", addScope, strings.Join(packageNames(cfg), ", "))
+		}
+		a.copyPatterns, a.postHooks, a.scopeDir = pkg.CopyPatterns, pkg.PostHooks, pkg.Path
+		log.Explainf("scope: using copy_patterns and post_hooks of packages.%s in %s", addScope, pkg.Path)
+	}
+	if addNoCopy && len(a.copyPatterns) > 0 {
+		a.copyPatterns = nil
+		log.Infof("Skipping copy_patterns (--no-copy)")
+	}
+	if addNoHooks && len(a.postHooks) > 0 {
+		a.postHooks = nil
+		log.Infof("Skipping post_hooks (--no-hooks)")
+	}
+	if addNoHooks && a.direnv {
+		a.direnv = false
+		log.Infof("Skipping direnv allow (--no-hooks)")
+	}
+	sparsePaths := cfg.SparsePaths
+	if addSparse != "" {
+		paths, ok := cfg.SparseProfiles[addSparse]
+		if !ok {
+			return fmt.Errorf("unknown sparse profile %q (defined: %s)", addSparse, strings.Join(sparseProfileNames(cfg), ", "))
+		}
+		sparsePaths = paths
+		log.Explainf("sparse: using sparse_profiles.%s", addSparse)
+	} else if len(sparsePaths) > 0 {
+		log.Explainf("sparse: using sparse_paths (--sparse <profile> overrides it)")
+	}
+	if len(sparsePaths) > 0 && !addEmpty {
+		log.Infof("Sparse checkout: %s", strings.Join(sparsePaths, ", "))
+		a.sparsePaths = sparsePaths
 	}
 
-	worktreeDir, err := git.GetWorktreeDir(cfg.WorktreeDir)
+	registerMaintenance(cfg, repoRoot)
+
+	if len(inputs) == 1 {
+		wt, err := a.add(inputs[0])
+		if errors.Is(err, errCancelled) {
+			return quietExit(cmd, errCancelled)
+		}
+		if err != nil {
+			return err
+		}
+		return finishAdd(cfg, wt)
+	}
+
+	return a.addBatch(inputs)
+}
+
+// addBatch creates a worktree per input, carrying on past failures, and
+// prints a summary once all inputs have been processed.
+func (a *adder) addBatch(inputs []string) error {
+	type failure struct {
+		input string
+		err   error
+	}
+	var done []created
+	var failed []failure
+
+	for i, input := range inputs {
+		log.Infof("\n[%d/%d] %s", i+1, len(inputs), input)
+		wt, err := a.add(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			failed = append(failed, failure{input, err})
+			continue
+		}
+		done = append(done, wt)
+		if addOpen.requested() {
+			if err := openWorktree(&addOpen, a.cfg, term.Target{Path: wt.path, Branch: wt.branch}); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+		} else if porcelainMode() {
+			writeRecord(wt.path, wt.branch)
+		} else if addPrintPath {
+			fmt.Println(wt.path)
+		}
+	}
+
+	log.Infof("\nCreated %d of %d worktrees", len(done), len(inputs))
+	for _, wt := range done {
+		log.Infof("  ✓ %s %s", styles.BranchStyle.Render(wt.label), styles.DimStyle.Render(wt.path))
+	}
+	for _, f := range failed {
+		fmt.Fprintf(os.Stderr, "  ✗ %s: %v\n", f.input, f.err)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d worktrees failed", len(failed), len(inputs))
+	}
+	return nil
+}
+
+// add creates and sets up the worktree for a single input.
+func (a *adder) add(input string) (created, error) {
+	worktreeDir, err := git.GetWorktreeDir(a.cfg.WorktreeDir)
 	if err != nil {
-		return err
+		return created{}, err
 	}
 	if err := os.MkdirAll(worktreeDir, 0755); err != nil {
-		return fmt.Errorf("failed to create worktree directory: %w", err)
+		return created{}, fmt.Errorf("failed to create worktree directory: %w", err)
 	}
 
-	dirName := git.SanitizeBranchName(branch)
-	worktreePath := filepath.Join(worktreeDir, dirName)
-
-	local, remote := git.BranchExists(branch)
-	if local || remote {
-		fmt.Fprintf(os.Stderr, "Using existing branch: %s\n", branch)
+	var branch, baseBranch, dirName, worktreePath string
+	var create func() error
+	newBranch := false
+	label := input
+	if addDetach {
+		if !git.RefExists(input) {
+			return created{}, fmt.Errorf("ref %q not found", input)
+		}
+		if dirName, err = a.sanitize(input); err != nil {
+			return created{}, err
+		}
+		worktreePath = filepath.Join(worktreeDir, dirName)
+		log.Infof("Creating detached worktree at %s", input)
+		create = func() error { return git.CreateDetachedWorktree(worktreePath, input, a.createOptions()) }
 	} else {
-		fmt.Fprintf(os.Stderr, "Creating new branch from %s: %s\n", baseBranch, branch)
+		var prep preprocess.Result
+		switch {
+		case len(a.prepCommands) > 0:
+			prep, err = preprocess.RunCommands(a.prepCommands, input, a.repoRoot)
+		case len(a.prepScripts) > 0:
+			prep, err = preprocess.RunScripts(a.prepScripts, input, a.repoRoot)
+		case a.prepBuiltin != nil:
+			prep.Branch, err = a.prepBuiltin.Apply(input)
+		default:
+			log.Explainf("preprocess: none configured (or --no-preprocess); %q is the branch name", input)
+			prep.Branch = input
+		}
+		if err != nil {
+			return created{}, err
+		}
+		branch = prep.Branch
+		label = branch
+
+		log.Infof("Branch name: %s", branch)
+
+		baseBranch = a.baseBranch
+		if prep.Base != "" && addBase == "" {
+			baseBranch = prep.Base
+			log.Explainf("base branch: %s (from preprocessing; --base overrides it)", baseBranch)
+		}
+		if prep.Dir != "" {
+			dirName = prep.Dir
+			log.Explainf("directory: %s (from preprocessing)", dirName)
+		} else if a.cfg.DirTemplate != "" {
+			vars := tmpl.NewDirVars(branch, baseBranch, input, time.Now())
+			if dirName, err = tmpl.DirName(a.cfg.DirTemplate, vars, a.sanitize); err != nil {
+				return created{}, err
+			}
+			log.Explainf("directory: %s (rendered from dir_template %q)", dirName, a.cfg.DirTemplate)
+		} else {
+			if dirName, err = a.sanitize(branch); err != nil {
+				return created{}, err
+			}
+			log.Explainf("directory: %s (branch name with characters invalid in paths replaced)", dirName)
+		}
+		worktreePath = filepath.Join(worktreeDir, dirName)
+
+		local, remote := git.BranchExists(branch)
+		switch {
+		case local:
+			log.Explainf("branch: refs/heads/%s exists, so it is checked out as is", branch)
+		case remote:
+			log.Explainf("branch: no refs/heads/%s, but refs/remotes/origin/%s exists, so git creates a local branch tracking it", branch, branch)
+		case !addEmpty:
+			log.Explainf("branch: neither refs/heads/%s nor refs/remotes/origin/%s exists, so a new branch is created", branch, branch)
+		}
+		if !local && !remote && !addNoVerify {
+			if err := checkBranchRules(a.cfg.BranchRules, branch); err != nil {
+				return created{}, err
+			}
+		}
+		switch {
+		case addEmpty:
+			if local || remote {
+				return created{}, fmt.Errorf("branch %q already exists; --empty needs a new branch name", branch)
+			}
+			baseBranch, newBranch = "", true
+			log.Infof("Creating orphan branch: %s", branch)
+			create = func() error { return git.CreateOrphanWorktree(branch, worktreePath, a.createOptions()) }
+		default:
+			if local || remote {
+				log.Infof("Using existing branch: %s", branch)
+			} else {
+				if !git.RefExists(baseBranch) {
+					if baseBranch != a.baseBranch {
+						return created{}, fmt.Errorf("base branch %q from preprocessing not found locally or on origin", baseBranch)
+					}
+					baseBranch, err = resolveMissingBaseBranch(baseBranch, a.repoRoot, addBase == "")
+					if err != nil {
+						return created{}, err
+					}
+					// Remember the choice so a batch only asks once.
+					a.baseBranch = baseBranch
+				}
+				log.Infof("Creating new branch from %s: %s", baseBranch, branch)
+			}
+			newBranch = !local
+			create = func() error { return git.CreateWorktree(branch, worktreePath, baseBranch, a.createOptions()) }
+		}
 	}
 
-	if err := git.CreateWorktree(branch, worktreePath, baseBranch); err != nil {
-		return err
+	if len(a.cfg.PreAddHooks) > 0 && !addNoVerify {
+		if err := a.preAdd(worktreePath, branch, baseBranch); err != nil {
+			return created{}, err
+		}
+	}
+
+	op := beginOperation(state.Operation{
+		Kind:       state.OpAdd,
+		Path:       worktreePath,
+		Branch:     branch,
+		NewBranch:  newBranch,
+		Input:      input,
+		BaseBranch: baseBranch,
+		Scope:      addScope,
+		Profile:    addProfile,
+	})
+	defer endOperation(op)
+	if err := create(); err != nil {
+		return created{}, err
+	}
+	if newBranch {
+		// An orphan branch has no commit yet; recover then keeps it if it
+		// gets one.
+		op.StartCommit, _ = git.ResolveCommit("refs/heads/" + branch)
+	}
+	stepOperation(op, stepCreate)
+
+	rec := state.Record{
+		CreatedAt:     time.Now(),
+		Input:         input,
+		InitialBranch: branch,
+		BaseBranch:    baseBranch,
+		Scope:         addScope,
+		Profile:       addProfile,
+		CI:            a.ci,
+	}
+	// Saved even if setup fails below, so wt inspect can show how far it got.
+	defer func() { saveRecord(worktreePath, rec) }()
+
+	if err := a.setup(worktreePath, dirName, &rec); err != nil {
+		return created{}, err
+	}
+	return created{path: worktreePath, branch: branch, label: label}, nil
+}
+
+// preAdd runs the pre_add_hooks in the repository root before the worktree
+// at path is created. A failing hook vetoes it.
+func (a *adder) preAdd(path, branch, baseBranch string) error {
+	log.Infof("Running pre-add hooks...")
+	env := infoEnv(wtenv.Info{WorktreePath: path, Branch: branch, BaseBranch: baseBranch, RepoRoot: a.repoRoot})
+	if _, err := hooks.RunEnv(a.cfg.PreAddHooks, a.repoRoot, "", a.repoRoot, branch, env); err != nil {
+		return fmt.Errorf("not creating %s: %w (--no-verify skips pre_add_hooks)", path, err)
+	}
+	return nil
+}
+
+// setup prepares a freshly created worktree: expiry, LFS, submodules,
+// templates, copied files, direnv and post-creation hooks, noting what it
+// copied and ran in rec.
+func (a *adder) setup(worktreePath, dirName string, rec *state.Record) error {
+	branch, baseBranch, input := rec.InitialBranch, rec.BaseBranch, rec.Input
+
+	if a.ttl > 0 {
+		rec.ExpiresAt = time.Now().UTC().Add(a.ttl)
+		log.Infof("Expires: %s", rec.ExpiresAt.Local().Format("2006-01-02 15:04"))
+	}
+
+	if a.cfg.VerifyIdentity {
+		verifyIdentity(worktreePath)
+	}
+
+	if a.lfs != git.LFSAuto {
+		switch {
+		case !git.UsesLFS(worktreePath):
+			log.Explainf("lfs: .gitattributes has no filter=lfs entries, nothing to fetch")
+		case a.lfs == git.LFSSkip:
+			log.Infof("Skipped Git LFS downloads (run git lfs pull in the worktree to fetch them)")
+		case !git.LFSInstalled():
+			fmt.Fprintf(os.Stderr, "Warning: repository uses Git LFS but git-lfs is not installed; LFS files are pointers\n")
+		default:
+			log.Infof("Pulling Git LFS files...")
+			if err := git.PullLFS(worktreePath); err != nil {
+				return err
+			}
+		}
 	}
 
-	if len(cfg.CopyPatterns) > 0 {
-		fmt.Fprintln(os.Stderr, "Copying files...")
-		if err := copy.CopyFiles(cfg.CopyPatterns, repoRoot, worktreePath); err != nil {
+	if mode, _ := git.ParseSubmoduleMode(a.cfg.Submodules); mode != git.SubmodulesNone && git.HasSubmodules(worktreePath) {
+		log.Infof("Initializing submodules...")
+		if err := git.UpdateSubmodules(worktreePath, mode); err != nil {
+			return err
+		}
+	}
+
+	if a.cfg.TemplateDir != "" {
+		templateDir := a.cfg.TemplateDir
+		if !filepath.IsAbs(templateDir) {
+			templateDir = filepath.Join(a.repoRoot, templateDir)
+		}
+		log.Infof("Rendering template files...")
+		vars := tmpl.Vars{
+			Branch:       branch,
+			BaseBranch:   baseBranch,
+			Input:        input,
+			RepoRoot:     a.repoRoot,
+			WorktreePath: worktreePath,
+			DirName:      dirName,
+		}
+		if err := tmpl.Render(templateDir, worktreePath, vars); err != nil {
+			return fmt.Errorf("failed to render template: %w", err)
+		}
+	}
+
+	if len(a.copyPatterns) > 0 {
+		log.Infof("Copying files...")
+		srcDir, destDir := filepath.Join(a.repoRoot, a.scopeDir), filepath.Join(worktreePath, a.scopeDir)
+		copied, err := copy.CopyFiles(a.copyPatterns, srcDir, destDir)
+		rec.Copied = copied
+		if err != nil {
 			return fmt.Errorf("failed to copy files: %w", err)
 		}
 	}
 
-	if len(cfg.PostHooks) > 0 {
-		fmt.Fprintln(os.Stderr, "Running post-creation hooks...")
-		if err := hooks.Run(cfg.PostHooks, worktreePath); err != nil {
+	if a.direnv {
+		if err := a.allowDirenv(worktreePath, rec); err != nil {
 			return err
 		}
 	}
 
-	if addTmux {
-		return openTmuxPane(worktreePath)
+	if len(a.postHooks) > 0 {
+		log.Infof("Running post-creation hooks...")
+		results, err := hooks.Run(a.postHooks, filepath.Join(worktreePath, a.scopeDir), filepath.Join(a.repoRoot, a.scopeDir), a.repoRoot, branch)
+		for _, r := range results {
+			rec.Hooks = append(rec.Hooks, state.HookRun{Name: r.Name, Status: string(r.Status)})
+		}
+		printHookSummary(results)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createOptions are how git checks out the worktrees a creates: with the
+// sparse paths, and with LFS pointers unless lfs = "auto".
+func (a *adder) createOptions() git.CreateOptions {
+	return git.CreateOptions{SparsePaths: a.sparsePaths, SkipLFSSmudge: a.lfs != git.LFSAuto}
+}
+
+// sanitize turns a branch name or ref into a directory name following the
+// [sanitize] config.
+func (a *adder) sanitize(name string) (string, error) {
+	return sanitizeName(a.cfg, name)
+}
+
+// sanitizeName turns name into a directory name following the [sanitize]
+// config of cfg.
+func sanitizeName(cfg *config.Config, name string) (string, error) {
+	return git.Sanitize(name, git.SanitizeOptions{
+		Replacement: cfg.Sanitize.Replacement,
+		MaxLength:   cfg.Sanitize.MaxLength,
+		Lowercase:   cfg.Sanitize.Lowercase,
+		Strip:       cfg.Sanitize.Strip,
+	})
+}
+
+// checkBranchRules enforces the [branch_rules] naming policy on a branch
+// about to be created.
+func checkBranchRules(rules config.BranchRules, branch string) error {
+	if err := rules.Check(branch); err != nil {
+		return fmt.Errorf("%w (--no-verify skips branch_rules)", err)
+	}
+	log.Explainf("branch_rules: %s passes", branch)
+	return nil
+}
+
+// verifyIdentity reports the commit identity git resolves in a new worktree
+// and warns about anything that would produce bad or unsigned commits.
+func verifyIdentity(worktreePath string) {
+	id := git.ResolveIdentity(worktreePath)
+	problems := id.Problems()
+	if len(problems) == 0 {
+		log.Infof("Commit identity: %s", id)
+		return
 	}
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "Warning: %s in %s\n", problem, worktreePath)
+	}
+}
 
-	fmt.Fprintf(os.Stderr, "Worktree created at: %s\n", worktreePath)
-	if addPrintPath {
-		fmt.Println(worktreePath)
+// finishAdd opens or prints a single newly created worktree.
+func finishAdd(cfg *config.Config, wt created) error {
+	if addOpen.requested() {
+		return openWorktree(&addOpen, cfg, term.Target{Path: wt.path, Branch: wt.branch})
+	}
+
+	log.Infof("Worktree created at: %s", wt.path)
+	if porcelainMode() {
+		writeRecord(wt.path, wt.branch)
+	} else if addPrintPath {
+		fmt.Println(wt.path)
 	} else {
-		fmt.Printf("cd %s\n", worktreePath)
+		fmt.Printf("cd %s\n", wt.path)
 	}
 
 	return nil
 }
 
+// readInputs reads one input per line, ignoring blank lines.
+func readInputs(r io.Reader) ([]string, error) {
+	var inputs []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		inputs = append(inputs, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read inputs from stdin: %w", err)
+	}
+	return inputs, nil
+}
+
+func packageNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Packages))
+	for name := range cfg.Packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sparseProfileNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.SparseProfiles))
+	for name := range cfg.SparseProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func profileNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func hookProfileNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.HookProfiles))
+	for name := range cfg.HookProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveMissingBaseBranch lets the user pick an existing base branch when the
+// configured one doesn't exist, and optionally saves the choice to the config.
+func resolveMissingBaseBranch(baseBranch, repoRoot string, offerSave bool) (string, error) {
+	candidates := git.BaseBranchCandidates()
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("base branch %q not found locally or on origin; use --base or set base_branch in %s", baseBranch, config.ConfigFileName)
+	}
+
+	fmt.Fprintf(os.Stderr, "Base branch %q not found. Select a base branch:\n", baseBranch)
+	items := make([]tui.Item, len(candidates))
+	for i, c := range candidates {
+		items[i] = tui.Item{Label: c, Value: c}
+	}
+	selected, err := tui.Select(items)
+	if err != nil {
+		return "", fmt.Errorf("base branch %q not found (candidates: %s): %w", baseBranch, strings.Join(candidates, ", "), err)
+	}
+	if selected == "" {
+		return "", errCancelled
+	}
+
+	if offerSave {
+		save, err := tui.Confirm(fmt.Sprintf("Save %q as base_branch in %s?", selected, config.ConfigFileName))
+		if err != nil {
+			return "", err
+		}
+		if save {
+			configPath := filepath.Join(repoRoot, config.ConfigFileName)
+			if err := config.SetString(configPath, "base_branch", selected); err != nil {
+				return "", fmt.Errorf("failed to update config: %w", err)
+			}
+			log.Infof("Updated base_branch in %s", configPath)
+		}
+	}
+
+	return selected, nil
+}
+
 var cdCmd = &cobra.Command{
-	Use:   "cd",
+	Use:   "cd [query]",
 	Short: "Go to a worktree",
-	Long:  `Interactive fuzzy finder to go to a worktree.`,
-	RunE:  runCd,
+	Long: `Interactive fuzzy finder to go to a worktree.
+
+With a query, worktrees are fuzzy-matched by branch: a single match (or a
+branch named exactly like the query) is entered right away, several matches
+open the finder pre-filtered with the query. --query always opens the
+finder, with its filter pre-filled.
+
+Scripts, Makefiles and CI jobs can pick without the finder: --select enters
+the worktree of a branch (or path), --filter the one whose branch matches a
+glob (or /regex/, as in if_branch), narrowed further by a query, and --first
+takes the first match in the finder's order instead of failing when several
+match (alone, the worktree entered most recently and often).
+
+The finder lists the worktrees you enter most often and most recently first
+(like zoxide); --no-frecency keeps git's order.
+
+The main worktree is left out unless --include-main (or cd_include_main in
+the config) lists it too, marked "(main worktree)".`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runCd,
 }
 
 var (
-	cdTmux      bool
-	cdPrintPath bool
+	cdOpen        openFlags
+	cdPrintPath   bool
+	cdRemote      string
+	cdQuery       string
+	cdNoFrecency  bool
+	cdIncludeMain bool
+	cdPick        pickFlags
 )
 
 func init() {
-	cdCmd.Flags().BoolVarP(&cdTmux, "tmux", "t", false, "Open in new tmux pane")
+	cdOpen.register(cdCmd)
 	cdCmd.Flags().BoolVar(&cdPrintPath, "print-path", false, "Print worktree path (for shell integration)")
+	cdCmd.Flags().StringVar(&cdRemote, "remote", "", "Pick a worktree on a [remotes.<name>] machine and open an ssh session into it")
+	cdCmd.Flags().StringVar(&cdQuery, "query", "", "Open the finder with this filter pre-filled")
+	cdCmd.Flags().BoolVar(&cdNoFrecency, "no-frecency", false, "List worktrees in git's order instead of by how often and recently you entered them")
+	cdCmd.Flags().BoolVar(&cdIncludeMain, "include-main", false, "List the main worktree too (default: cd_include_main from config)")
+	cdPick.register(cdCmd)
 }
 
 func runCd(cmd *cobra.Command, args []string) error {
+	if cdQuery != "" && len(args) > 0 {
+		return fmt.Errorf("--query cannot be combined with a query argument")
+	}
+	if cdPick.branch != "" && len(args) > 0 {
+		return fmt.Errorf("--select cannot be combined with a query argument")
+	}
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+
+	if cdRemote != "" {
+		return runRemoteCd(cmd, cfg, cdRemote)
+	}
+
 	worktrees, err := git.ListWorktrees()
 	if err != nil {
 		return err
 	}
 
-	// Filter out main worktree
+	locations := term.TmuxLocations(worktreePaths(worktrees))
+	notes := worktreeNotes(cfg, worktrees)
+	records := loadRecords()
+
+	includeMain := cfg.CdIncludeMain
+	if cmd.Flags().Changed("include-main") {
+		includeMain = cdIncludeMain
+	}
 	var items []tui.Item
+	hasLocations := false
 	for _, wt := range worktrees {
-		if wt.IsMain {
+		if wt.IsMain && (!includeMain || wt.Bare) {
 			continue
 		}
-		label := wt.Branch
-		if label == "" {
-			label = filepath.Base(wt.Path)
+		label := wt.Label()
+		if wt.Detached {
+			label += " (detached)"
+		}
+		if wt.IsMain {
+			label += " (main worktree)"
+		}
+		if len(locations[wt.Path]) > 0 {
+			hasLocations = true
 		}
 		items = append(items, tui.Item{
-			Label: label,
-			Value: wt.Path,
+			Label:   label,
+			Value:   wt.Path,
+			Detail:  strings.TrimSpace(notes[wt.Path] + " " + tmuxDetail(locations[wt.Path])),
+			Path:    wt.Path,
+			Created: worktreeCreated(records, wt.Path),
 		})
 	}
 
 	if len(items) == 0 {
-		fmt.Println("No worktrees to switch to.")
-		return nil
+		fmt.Fprintln(os.Stderr, "No worktrees to switch to.")
+		return quietExit(cmd, errNoWorktrees)
 	}
 
-	selected, err := tui.Select(items)
-	if err != nil {
-		return err
+	usage, usagePath := loadUsage()
+	if !cdNoFrecency {
+		sortByFrecency(items, usage, time.Now())
+	}
+
+	opts := tui.Options{Query: cdQuery, Details: selectorDetails(cfg, worktrees)}
+	if hasLocations {
+		opts.Keys = []tui.KeyBinding{{Key: "ctrl+t", Help: "jump to tmux"}}
 	}
 
+	var result tui.Result
+	if cdPick.requested() {
+		query := cdQuery
+		if len(args) == 1 {
+			query = args[0]
+		}
+		picked, err := cdPick.pick(items, worktrees, query)
+		if err != nil {
+			return err
+		}
+		// As without the flags, an exact branch name wins.
+		if exact, ok := exactMatch(worktrees, query, includeMain); ok && len(args) == 1 && cdPick.filter == "" {
+			picked = []tui.Item{{Label: exact.Label(), Value: exact.Path}}
+		}
+		switch len(picked) {
+		case 0:
+			fmt.Fprintln(os.Stderr, cdPick.noMatch(query))
+			return quietExit(cmd, errNoWorktrees)
+		case 1:
+			result = tui.Result{Value: picked[0].Value, Key: "enter"}
+		default:
+			return ambiguousPick(picked)
+		}
+	} else if len(args) == 1 {
+		opts.Query = args[0]
+		matches := tui.Filter(items, opts.Query)
+		if exact, ok := exactMatch(worktrees, opts.Query, includeMain); ok {
+			matches = []tui.Item{{Value: exact.Path}}
+		}
+		switch len(matches) {
+		case 0:
+			fmt.Fprintf(os.Stderr, "No worktree matches %q.\n", opts.Query)
+			return quietExit(cmd, errNoWorktrees)
+		case 1:
+			result = tui.Result{Value: matches[0].Value, Key: "enter"}
+		}
+	}
+	if result.Value == "" {
+		result, err = tui.SelectWith(items, opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	selected := result.Value
 	if selected == "" {
-		return nil // User cancelled
+		return quietExit(cmd, errCancelled)
 	}
 
-	if cdTmux {
-		return openTmuxPane(selected)
+	recordVisit(usagePath, selected, worktrees)
+
+	if result.Key == "ctrl+t" {
+		locs := locations[selected]
+		if len(locs) == 0 {
+			return fmt.Errorf("%s is not open in tmux", selected)
+		}
+		return term.JumpTmux(locs[0])
+	}
+
+	branch := ""
+	if wt, ok := git.FindWorktree(worktrees, selected); ok {
+		branch = wt.Branch
+	}
+
+	if len(cfg.PostSwitchHooks) > 0 {
+		repoRoot, err := git.GetRepoRoot()
+		if err != nil {
+			return err
+		}
+		if err := ensureTrusted(repoRoot); err != nil {
+			return err
+		}
+		log.Infof("Running post-switch hooks...")
+		if _, err := hooks.Run(cfg.PostSwitchHooks, selected, "", mainWorktreeRoot(worktrees), branch); err != nil {
+			return err
+		}
+	}
+
+	if cdOpen.requested() {
+		return openWorktree(&cdOpen, cfg, term.Target{Path: selected, Branch: branch})
 	}
 
 	if cdPrintPath {
@@ -207,22 +1029,114 @@ func runCd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// exactMatch returns the worktree whose branch is exactly query, leaving out
+// the main worktree unless includeMain.
+func exactMatch(worktrees []git.Worktree, query string, includeMain bool) (*git.Worktree, bool) {
+	for i, wt := range worktrees {
+		if (!wt.IsMain || includeMain) && wt.Branch == query {
+			return &worktrees[i], true
+		}
+	}
+	return nil, false
+}
+
 var removeCmd = &cobra.Command{
 	Use:     "rm [path]",
 	Aliases: []string{"remove"},
 	Short:   "Remove worktree(s)",
-	Long:    `Remove one or more worktrees. If no path is given, shows interactive selection.`,
-	RunE:    runRemove,
+	Long: `Remove one or more worktrees. If no path is given, shows interactive selection.
+
+Worktrees with modified or untracked files, or with commits that are on no
+remote, are only removed after a confirmation or with --force, so work that
+exists nowhere else isn't forgotten. Repositories without remotes only check
+for files.
+
+--merged limits the selection to worktrees whose branch is merged into the
+given base branch (default: base_branch from the config). Add --yes to remove
+all of them without prompting, e.g. from a cleanup script; dirty worktrees and
+those with unpushed commits are skipped unless --force is given.
+
+--query pre-fills the filter of the interactive selection.
+
+--select removes the worktree of a branch (or path), and --filter every one
+whose branch matches a glob (or /regex/, as in if_branch), narrowed further
+by --query, without the selection; --first removes only the first match.
+Like --yes, they skip dirty worktrees and those with unpushed commits unless
+--force is given.
+
+--all removes every worktree but the main one (or every merged one, with
+--merged) after a single confirmation (--yes skips it). Worktrees with
+uncommitted changes or unpushed commits are listed and kept unless --force
+is given.
+
+--keep-dir only unregisters the worktrees: git forgets them and their
+branches can be checked out elsewhere, but their files stay where they are,
+without the .git link, e.g. to archive build artifacts. Submodules get their
+git directories back, and wt clean leaves the directories alone. Uncommitted
+changes don't need --force then, since nothing is deleted.`,
+	RunE: runRemove,
 }
 
-var removeForce bool
+// mergedIntoConfigBase is the --merged value used when no base is given.
+const mergedIntoConfigBase = "base_branch"
+
+var (
+	removeForce   bool
+	removeMerged  string
+	removeYes     bool
+	removeQuery   string
+	removeKeepDir bool
+	removeAll     bool
+	removePick    pickFlags
+)
 
 func init() {
-	removeCmd.Flags().BoolVarP(&removeForce, "force", "f", false, "Force removal even if worktree is dirty")
+	removeCmd.Flags().BoolVarP(&removeForce, "force", "f", false, "Remove even with uncommitted changes or commits on no remote, discarding them")
+	removeCmd.Flags().StringVar(&removeMerged, "merged", "", "Only offer worktrees whose branch is merged into this base (default: base_branch from config)")
+	removeCmd.Flags().Lookup("merged").NoOptDefVal = mergedIntoConfigBase
+	removeCmd.Flags().BoolVarP(&removeYes, "yes", "y", false, "With --merged or --all, remove all matching worktrees without prompting")
+	removeCmd.Flags().StringVar(&removeQuery, "query", "", "Open the selection with this filter pre-filled")
+	removeCmd.Flags().BoolVar(&removeKeepDir, "keep-dir", false, "Unregister the worktree but keep its files on disk")
+	removeCmd.MarkFlagsMutuallyExclusive("keep-dir", "force")
+	removeCmd.Flags().BoolVar(&removeAll, "all", false, "Remove every worktree except the main one after one confirmation")
+	removeCmd.MarkFlagsMutuallyExclusive("all", "query")
+	removePick.register(removeCmd)
+	removeCmd.MarkFlagsMutuallyExclusive("all", "select")
+	removeCmd.MarkFlagsMutuallyExclusive("all", "filter")
+	removeCmd.MarkFlagsMutuallyExclusive("all", "first")
 }
 
 func runRemove(cmd *cobra.Command, args []string) error {
+	filterMerged := cmd.Flags().Changed("merged")
+	if removeYes && !filterMerged && !removeAll {
+		return fmt.Errorf("--yes requires --merged or --all")
+	}
+	if removeYes && removeQuery != "" {
+		return fmt.Errorf("--query cannot be combined with --yes")
+	}
+	if removePick.first && removePick.filter == "" && removeQuery == "" {
+		return fmt.Errorf("--first needs --filter or --query")
+	}
+	if filterMerged && removeMerged == mergedIntoConfigBase && len(args) == 1 {
+		// `wt rm --merged develop`: the optional flag value arrives as an argument.
+		removeMerged, args = args[0], nil
+	}
 	if len(args) > 0 {
+		if filterMerged {
+			return fmt.Errorf("--merged cannot be combined with a path")
+		}
+		if removeAll {
+			return fmt.Errorf("--all cannot be combined with a path")
+		}
+		if removeQuery != "" {
+			return fmt.Errorf("--query cannot be combined with a path")
+		}
+		if removePick.requested() {
+			return fmt.Errorf("--select, --filter and --first cannot be combined with a path")
+		}
+		if removeKeepDir {
+			return keepWorktreeDir(args[0])
+		}
 		return removeWorktreeWithConfirm(args[0], removeForce)
 	}
 
@@ -231,60 +1145,357 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+
+	var merged map[string]bool
+	if filterMerged {
+		base := removeMerged
+		if base == mergedIntoConfigBase {
+			base = cfg.BaseBranch
+		}
+		if merged, err = git.MergedBranches(base); err != nil {
+			return err
+		}
+		delete(merged, base)
+	}
 
+	locations := term.TmuxLocations(worktreePaths(worktrees))
+
+	records := loadRecords()
 	var items []tui.Item
 	for _, wt := range worktrees {
 		if wt.IsMain {
 			continue
 		}
-		label := fmt.Sprintf("%s (%s)", wt.Branch, wt.Path)
-		if wt.Branch == "" {
-			label = wt.Path
+		if filterMerged && !merged[wt.Branch] {
+			continue
 		}
 		items = append(items, tui.Item{
-			Label: label,
-			Value: wt.Path,
+			Label:   wt.Label(),
+			Value:   wt.Path,
+			Detail:  tmuxDetail(locations[wt.Path]),
+			Path:    wt.Path,
+			Created: worktreeCreated(records, wt.Path),
 		})
 	}
 
 	if len(items) == 0 {
-		fmt.Println("No worktrees to remove.")
-		return nil
+		if filterMerged {
+			log.Infof("No merged worktrees to remove.")
+		} else {
+			log.Infof("No worktrees to remove.")
+		}
+		if removeYes {
+			return nil
+		}
+		return quietExit(cmd, errNoWorktrees)
+	}
+
+	if removeAll {
+		paths := make([]string, len(items))
+		for i, item := range items {
+			paths[i] = item.Value
+		}
+		err := removeAllWorktrees(cfg, paths)
+		if errors.Is(err, errCancelled) {
+			return quietExit(cmd, errCancelled)
+		}
+		return err
+	}
+
+	if removePick.requested() {
+		picked, err := removePick.pick(items, worktrees, removeQuery)
+		if err != nil {
+			return err
+		}
+		if len(picked) == 0 {
+			fmt.Fprintln(os.Stderr, removePick.noMatch(removeQuery))
+			return quietExit(cmd, errNoWorktrees)
+		}
+		items = picked
+	}
+	if removeYes || removePick.requested() {
+		paths := make([]string, len(items))
+		for i, item := range items {
+			paths[i] = item.Value
+		}
+		return removeUnattended(cfg, paths)
+	}
+
+	paths := make([]string, len(items))
+	for i, item := range items {
+		paths[i] = item.Value
 	}
+	updates, statuses := collectStatuses(paths, dirtyCheck(cfg))
 
-	selected, err := tui.MultiSelect(items)
+	selected, err := tui.MultiSelectWith(items, tui.Options{
+		Updates: updates,
+		Query:   removeQuery,
+		Details: selectorDetails(cfg, worktrees),
+	})
 	if err != nil {
 		return err
 	}
 
 	if len(selected) == 0 {
-		fmt.Println("No worktrees selected.")
+		return quietExit(cmd, errCancelled)
+	}
+
+	if removeKeepDir {
+		for _, path := range selected {
+			if err := keepWorktreeDir(path); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
 
+	// Worktrees with work that exists nowhere else need an explicit yes.
+	collected := statuses()
+	var risky []string
+	for _, path := range selected {
+		if collected[path].Risky() {
+			risky = append(risky, path)
+		}
+	}
+	forceRisky := false
+	if len(risky) > 0 {
+		fmt.Fprintln(os.Stderr, "These worktrees have uncommitted changes or unpushed commits:")
+		for _, path := range risky {
+			fmt.Fprintf(os.Stderr, "  %s %s\n", path, describeStatus(collected[path]))
+		}
+		forceRisky, err = tui.Confirm(fmt.Sprintf("Remove %d worktree(s) anyway?", len(risky)))
+		if err != nil {
+			return err
+		}
+	}
+
+	removed := 0
 	for _, path := range selected {
+		if collected[path].Risky() {
+			if !forceRisky {
+				fmt.Printf("Skipped: %s\n", path)
+				continue
+			}
+			fmt.Printf("Removing worktree: %s\n", path)
+			if err := removeWorktree(path, true); err != nil {
+				return err
+			}
+			removed++
+			continue
+		}
 		fmt.Printf("Removing worktree: %s\n", path)
 		if err := removeWorktreeWithConfirm(path, removeForce); err != nil {
 			return err
 		}
+		removed++
 	}
+	collectGarbage(cfg, removed)
 
 	return nil
 }
 
+// removeUnattended removes the worktrees at paths without asking, skipping
+// those with work that exists nowhere else unless --force is given.
+func removeUnattended(cfg *config.Config, paths []string) error {
+	removed := 0
+	for _, path := range paths {
+		if removeKeepDir {
+			if err := keepWorktreeDir(path); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Printf("Removing worktree: %s\n", path)
+		err := removeWorktree(path, removeForce)
+		var unpushed *git.UnpushedError
+		if errors.Is(err, git.ErrDirtyWorktree) {
+			fmt.Fprintf(os.Stderr, "Skipped %s: contains modified or untracked files (use --force)\n", path)
+			continue
+		}
+		if errors.As(err, &unpushed) {
+			fmt.Fprintf(os.Stderr, "Skipped %s: has %d commit(s) that are on no remote (use --force)\n", path, unpushed.Commits)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		removed++
+	}
+	collectGarbage(cfg, removed)
+	return nil
+}
+
+// removeAllWorktrees removes the worktrees at paths after one confirmation
+// summing them up. Worktrees with work that exists nowhere else are kept
+// unless --force is given.
+func removeAllWorktrees(cfg *config.Config, paths []string) error {
+	var statuses map[string]git.Status
+	if !removeKeepDir {
+		// No timeout: what is kept depends on every status.
+		statuses = git.StatusAll(context.Background(), paths, git.DirtyFull, nil)
+	}
+
+	var remove, kept []string
+	for _, path := range paths {
+		if statuses[path].Risky() && !removeForce {
+			kept = append(kept, path)
+		} else {
+			remove = append(remove, path)
+		}
+	}
+	verb := "Remove"
+	if removeKeepDir {
+		verb = "Unregister"
+	}
+	fmt.Fprintf(os.Stderr, "%s %d worktree(s):\n", verb, len(remove))
+	for _, path := range remove {
+		if statuses[path].Risky() {
+			fmt.Fprintf(os.Stderr, "  %s %s\n", path, describeStatus(statuses[path]))
+		} else {
+			fmt.Fprintf(os.Stderr, "  %s\n", path)
+		}
+	}
+	if len(kept) > 0 {
+		fmt.Fprintf(os.Stderr, "Keeping %d with uncommitted changes or unpushed commits (use --force to remove them too):\n", len(kept))
+		for _, path := range kept {
+			fmt.Fprintf(os.Stderr, "  %s %s\n", path, describeStatus(statuses[path]))
+		}
+	}
+	if len(remove) == 0 {
+		return nil
+	}
+	if !removeYes {
+		confirmed, err := tui.Confirm(fmt.Sprintf("%s %d worktree(s)?", verb, len(remove)))
+		if err != nil {
+			return fmt.Errorf("%w (use --yes to remove without asking)", err)
+		}
+		if !confirmed {
+			return errCancelled
+		}
+	}
+
+	removed := 0
+	for _, path := range remove {
+		if removeKeepDir {
+			if err := keepWorktreeDir(path); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Printf("Removing worktree: %s\n", path)
+		// Work can appear after the statuses were taken, while the prompt
+		// waited, so git's own check still decides.
+		err := removeWorktree(path, removeForce)
+		var unpushed *git.UnpushedError
+		if errors.Is(err, git.ErrDirtyWorktree) {
+			fmt.Fprintf(os.Stderr, "Skipped %s: contains modified or untracked files (use --force)\n", path)
+			continue
+		}
+		if errors.As(err, &unpushed) {
+			fmt.Fprintf(os.Stderr, "Skipped %s: has %d commit(s) that are on no remote (use --force)\n", path, unpushed.Commits)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		removed++
+	}
+	collectGarbage(cfg, removed)
+	return nil
+}
+
+// statusTimeout bounds how long selectors wait for status badges. Worktrees
+// that take longer, e.g. on a slow network mount, go without.
+const statusTimeout = 10 * time.Second
+
+// collectStatuses inspects worktrees in the background, streaming status
+// badges for the selector, and returns a function that waits for the
+// statuses, which lack the worktrees that failed or ran past statusTimeout.
+func collectStatuses(paths []string, check git.DirtyCheck) (<-chan tui.ItemUpdate, func() map[string]git.Status) {
+	updates := make(chan tui.ItemUpdate, len(paths))
+	var results map[string]git.Status
+	done := make(chan struct{})
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), statusTimeout)
+		defer cancel()
+		results = git.StatusAll(ctx, paths, check, func(path string, status git.Status) {
+			if badge := statusBadge(status); badge != "" {
+				updates <- tui.ItemUpdate{Value: path, Badge: badge}
+			}
+		})
+		if missing := len(paths) - len(results); missing > 0 {
+			log.Debugf("no status for %d worktree(s) within %s", missing, statusTimeout)
+		}
+		close(updates)
+		close(done)
+	}()
+
+	return updates, func() map[string]git.Status {
+		<-done
+		return results
+	}
+}
+
+// dirtyCheck returns the dirty_check selectors use for their status badges.
+func dirtyCheck(cfg *config.Config) git.DirtyCheck {
+	check, err := git.ParseDirtyCheck(cfg.DirtyCheck)
+	if err != nil {
+		return git.DirtyFull
+	}
+	return check
+}
+
+// statusBadge renders dirty and unpushed indicators, e.g. "● ↑3".
+func statusBadge(s git.Status) string {
+	var parts []string
+	if s.Dirty {
+		parts = append(parts, styles.DirtyStyle.Render("●"))
+	}
+	if s.Unpushed > 0 {
+		parts = append(parts, styles.AheadStyle.Render(fmt.Sprintf("↑%d", s.Unpushed)))
+	}
+	return strings.Join(parts, " ")
+}
+
+func describeStatus(s git.Status) string {
+	var parts []string
+	if s.Dirty {
+		parts = append(parts, "uncommitted changes")
+	}
+	if s.Unpushed == 1 {
+		parts = append(parts, "1 unpushed commit")
+	} else if s.Unpushed > 1 {
+		parts = append(parts, fmt.Sprintf("%d unpushed commits", s.Unpushed))
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
 // removeWorktreeWithConfirm attempts to remove a worktree and prompts for
-// confirmation if it contains modified or untracked files.
+// confirmation if it contains modified or untracked files, or commits that
+// are on no remote.
 func removeWorktreeWithConfirm(path string, force bool) error {
-	err := git.RemoveWorktree(path, force)
+	err := removeWorktree(path, force)
 	if err == nil {
 		return nil
 	}
 
-	if !errors.Is(err, git.ErrDirtyWorktree) {
+	var unpushed *git.UnpushedError
+	switch {
+	case errors.Is(err, git.ErrDirtyWorktree):
+		fmt.Printf("Worktree '%s' contains modified or untracked files.\n", path)
+	case errors.As(err, &unpushed):
+		fmt.Printf("Worktree '%s' has %d commit(s) that are on no remote.\n", path, unpushed.Commits)
+		if status, statusErr := git.GetStatus(path); statusErr == nil && status.Dirty {
+			fmt.Println("It also contains modified or untracked files.")
+		}
+	default:
 		return err
 	}
 
-	fmt.Printf("Worktree '%s' contains modified or untracked files.\n", path)
 	confirmed, confirmErr := tui.Confirm("Force remove anyway?")
 	if confirmErr != nil {
 		return confirmErr
@@ -295,7 +1506,31 @@ func removeWorktreeWithConfirm(path string, force bool) error {
 		return nil
 	}
 
-	return git.RemoveWorktree(path, true)
+	return removeWorktree(path, true)
+}
+
+// keepWorktreeDir unregisters the worktree at path (or with that branch) and
+// says where its files remain.
+func keepWorktreeDir(path string) error {
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	wt, ok := git.FindWorktree(worktrees, path)
+	if !ok {
+		return fmt.Errorf("no worktree found for %q", path)
+	}
+	if wt.IsMain {
+		return fmt.Errorf("%s is the main worktree and cannot be unregistered", wt.Path)
+	}
+	path = wt.Path
+	fmt.Printf("Unregistering worktree: %s\n", path)
+	if err := git.UnregisterWorktree(path); err != nil {
+		return err
+	}
+	keepRecord(path)
+	fmt.Printf("Files kept in %s (no longer a git worktree; wt clean leaves it alone)\n", path)
+	return nil
 }
 
 var lsCmd = &cobra.Command{
@@ -304,13 +1539,81 @@ var lsCmd = &cobra.Command{
 	RunE:  runLs,
 }
 
+var (
+	lsJSON bool
+	lsSize bool
+)
+
+func init() {
+	lsCmd.Flags().BoolVar(&lsJSON, "json", false, "Print worktrees as a JSON array")
+	lsCmd.Flags().BoolVar(&lsSize, "size", false, "Show how much disk space each worktree takes up, and the total")
+}
+
 func runLs(cmd *cobra.Command, args []string) error {
 	worktrees, err := git.ListWorktrees()
 	if err != nil {
 		return err
 	}
 
+	var sizes map[string]int64
+	if lsSize {
+		sizes = collectSizes(worktrees)
+	}
+
+	if lsJSON {
+		records := loadRecords()
+		entries := make([]lsEntry, len(worktrees))
+		for i, wt := range worktrees {
+			entries[i].Worktree = wt
+			if rec, ok := records.Worktrees[wt.Path]; ok {
+				entries[i].Record = &rec
+			}
+			if size, ok := sizes[wt.Path]; ok {
+				entries[i].Size = &size
+			}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+	expired := expiredWorktrees(loadRecords(), worktrees)
+
+	if porcelainMode() {
+		for _, wt := range worktrees {
+			var flags []string
+			if wt.IsMain {
+				flags = append(flags, "main")
+			}
+			if wt.Bare {
+				flags = append(flags, "bare")
+			}
+			if wt.Detached {
+				flags = append(flags, "detached")
+			}
+			if expired[wt.Path] {
+				flags = append(flags, "expired")
+			}
+			fields := []string{wt.Path, wt.Branch, wt.Commit, strings.Join(flags, ",")}
+			if lsSize {
+				size := ""
+				if n, ok := sizes[wt.Path]; ok {
+					size = strconv.FormatInt(n, 10)
+				}
+				fields = append(fields, size)
+			}
+			writeRecord(fields...)
+		}
+		return nil
+	}
+
 	homeDir, _ := os.UserHomeDir()
+	locations := term.TmuxLocations(worktreePaths(worktrees))
+	notes := worktreeNotes(cfg, worktrees)
 
 	// Group worktrees by parent directory
 	groups := make(map[string][]git.Worktree)
@@ -329,9 +1632,13 @@ func runLs(cmd *cobra.Command, args []string) error {
 	// Print main worktree first
 	if mainWorktree != nil {
 		path := shortenHome(mainWorktree.Path, homeDir)
-		branch := styles.BranchStyle.Render(mainWorktree.Branch)
-		badge := styles.CursorStyle.Render("(main)")
-		fmt.Printf("%s %s %s\n", path, branch, badge)
+		if mainWorktree.Bare {
+			fmt.Printf("%s %s\n", path, styles.CursorStyle.Render("(bare)"))
+		} else {
+			branch := styles.BranchStyle.Render(mainWorktree.Label())
+			badge := styles.CursorStyle.Render("(main)")
+			fmt.Printf("%s %s %s%s%s%s\n", path, branch, badge, styledNote(notes[mainWorktree.Path]), styledTmuxDetail(locations[mainWorktree.Path]), styledSize(sizes, mainWorktree.Path))
+		}
 	}
 
 	// Print grouped worktrees
@@ -340,18 +1647,99 @@ func runLs(cmd *cobra.Command, args []string) error {
 		fmt.Println(styles.DimStyle.Render(shortenHome(parentDir, homeDir) + "/"))
 		for _, wt := range wts {
 			dirName := filepath.Base(wt.Path)
+			detail := styledNote(notes[wt.Path]) + styledTmuxDetail(locations[wt.Path]) + styledSize(sizes, wt.Path)
+			if wt.Detached {
+				detail = " " + styles.DimStyle.Render("(detached)") + detail
+			}
+			if expired[wt.Path] {
+				detail = " " + styles.DirtyStyle.Render("(expired)") + detail
+			}
 			if dirName == wt.Branch {
-				fmt.Printf("  %s\n", styles.BranchStyle.Render(dirName))
+				fmt.Printf("  %s%s\n", styles.BranchStyle.Render(dirName), detail)
 			} else {
-				branch := styles.BranchStyle.Render(wt.Branch)
-				fmt.Printf("  %s %s\n", dirName, branch)
+				branch := styles.BranchStyle.Render(wt.Label())
+				fmt.Printf("  %s %s%s\n", dirName, branch, detail)
 			}
 		}
 	}
 
+	if lsSize {
+		var total int64
+		for _, size := range sizes {
+			total += size
+		}
+		fmt.Println()
+		fmt.Printf("Total: %s in %d worktree(s)\n", du.Format(total), len(sizes))
+	}
 	return nil
 }
 
+// styledSize renders the disk usage of the worktree at path, if measured.
+func styledSize(sizes map[string]int64, path string) string {
+	size, ok := sizes[path]
+	if !ok {
+		return ""
+	}
+	return " " + styles.DimStyle.Render("["+du.Format(size)+"]")
+}
+
+// targetWorktree returns the worktree named by the optional path|branch
+// argument, defaulting to the one containing the current directory.
+func targetWorktree(worktrees []git.Worktree, args []string) (*git.Worktree, error) {
+	if len(args) == 0 {
+		return git.CurrentWorktree(worktrees)
+	}
+	wt, ok := git.FindWorktree(worktrees, args[0])
+	if !ok {
+		return nil, fmt.Errorf("no worktree found for %q", args[0])
+	}
+	return wt, nil
+}
+
+// mainWorktreeRoot returns the path of the main worktree, or "" when the
+// repository is bare.
+func mainWorktreeRoot(worktrees []git.Worktree) string {
+	if main, ok := git.MainWorktree(worktrees); ok && !main.Bare {
+		return main.Path
+	}
+	return ""
+}
+
+func worktreePaths(worktrees []git.Worktree) []string {
+	paths := make([]string, len(worktrees))
+	for i, wt := range worktrees {
+		paths[i] = wt.Path
+	}
+	return paths
+}
+
+// tmuxDetail describes where a worktree is open in tmux, e.g. "[tmux work:2 +1]".
+func tmuxDetail(locs []term.TmuxLocation) string {
+	switch len(locs) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("[tmux %s]", locs[0])
+	default:
+		return fmt.Sprintf("[tmux %s +%d]", locs[0], len(locs)-1)
+	}
+}
+
+func styledTmuxDetail(locs []term.TmuxLocation) string {
+	detail := tmuxDetail(locs)
+	if detail == "" {
+		return ""
+	}
+	return " " + styles.DimStyle.Render(detail)
+}
+
+func styledNote(note string) string {
+	if note == "" {
+		return ""
+	}
+	return " " + styles.DimStyle.Render(note)
+}
+
 func shortenHome(path, homeDir string) string {
 	if homeDir != "" && strings.HasPrefix(path, homeDir) {
 		return "~" + path[len(homeDir):]
@@ -362,27 +1750,161 @@ func shortenHome(path, homeDir string) string {
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Create a sample .wt.toml config file",
-	RunE:  runInit,
+	Long: `Create a sample .wt.toml config file.
+
+With --detect, the repository is inspected for package.json, lockfiles,
+Gemfile, go.mod, compose overrides, and .env files, and matching
+copy_patterns and post_hooks (npm ci, bundle install, go mod download) are
+added below the sample.
+
+With --force, an existing .wt.toml is regenerated: the changes are shown as a
+diff and, once confirmed, the old file is kept as .wt.toml.bak.
+
+Worktrees created before wt was set up are followed: worktree_dir is set to
+the directory most of them are in, and dotfiles that git doesn't track but
+every worktree has are added to copy_patterns. wt init then offers to adopt
+them (see wt adopt).`,
+	RunE: runInit,
 }
 
+var (
+	initDetect bool
+	initForce  bool
+	initYes    bool
+)
+
 func runInit(cmd *cobra.Command, args []string) error {
 	configPath := config.ConfigFileName
 
-	if _, err := os.Stat(configPath); err == nil {
-		return fmt.Errorf("%s already exists", configPath)
+	existing, err := os.ReadFile(configPath)
+	if err == nil && !initForce {
+		return fmt.Errorf("%s already exists (use --force to regenerate it)", configPath)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	exists := err == nil
+
+	content := config.SampleConfig()
+	var detected detect.Result
+	heading := "# Detected from existing worktrees"
+	if initDetect {
+		detected = detect.Detect(".")
+		for _, finding := range detected.Findings {
+			fmt.Fprintf(os.Stderr, "Detected %s\n", finding)
+		}
+		if detected.Empty() {
+			fmt.Fprintln(os.Stderr, "No known project files found; writing the plain sample.")
+		}
+		heading = "# Detected by wt init --detect"
+	}
+
+	// Worktrees made before wt was set up show where they are kept and what
+	// gets copied into them.
+	worktreeDir := config.DefaultConfig().WorktreeDir
+	// wt init also works outside a repository, with no worktrees to follow.
+	worktrees, _ := git.ListWorktrees()
+	layout := existingLayout(worktrees)
+	if layout.WorktreeDir != "" {
+		content = strings.Replace(content, fmt.Sprintf("worktree_dir = %q", worktreeDir), fmt.Sprintf("worktree_dir = %q", layout.WorktreeDir), 1)
+		worktreeDir = layout.WorktreeDir
+	}
+	for _, name := range layout.CopyPatterns {
+		if !coveredBy(detected.CopyPatterns, name) {
+			detected.CopyPatterns = append(detected.CopyPatterns, name)
+		}
 	}
 
-	if err := os.WriteFile(configPath, []byte(config.SampleConfig()), 0644); err != nil {
+	if !detected.Empty() {
+		settings, err := detected.TOML()
+		if err != nil {
+			return err
+		}
+		content += "\n" + heading + "\n" + settings
+	}
+
+	if exists {
+		if string(existing) == content {
+			fmt.Fprintf(os.Stderr, "%s is already up to date\n", configPath)
+			return nil
+		}
+		replaced, err := replaceConfig(configPath, content)
+		if err != nil || !replaced {
+			return err
+		}
+	} else if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to create config file: %w", err)
 	}
 
-	cfg := config.DefaultConfig()
-	if err := ensureGitignoreHasWorktreeDir(cfg.WorktreeDir); err != nil {
-		return err
+	// A bare repository has no working tree for a .gitignore to apply to,
+	// and worktrees outside the repository need no entry.
+	if !git.IsBareRepository() && !strings.HasPrefix(worktreeDir, "..") && !filepath.IsAbs(worktreeDir) {
+		if err := ensureGitignoreHasWorktreeDir(worktreeDir); err != nil {
+			return err
+		}
 	}
 
-	fmt.Printf("Created %s\n", configPath)
-	return nil
+	if exists {
+		fmt.Printf("Regenerated %s\n", configPath)
+	} else {
+		fmt.Printf("Created %s\n", configPath)
+	}
+	return offerAdoption(worktrees)
+}
+
+// offerAdoption asks whether wt init should adopt the worktrees wt has no
+// record of, or says how to do it later.
+func offerAdoption(worktrees []git.Worktree) error {
+	records := loadRecords()
+	found := unadoptedWorktrees(worktrees, records)
+	if len(found) == 0 {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Found %d worktrees not created by wt:\n", len(found))
+	for _, wt := range found {
+		fmt.Fprintf(os.Stderr, "  %s (%s)\n", wt.Path, wt.Label())
+	}
+	if !initYes {
+		confirmed, err := tui.Confirm("Adopt them?")
+		if err != nil || !confirmed {
+			log.Infof("Run wt adopt to adopt them later.")
+			return nil
+		}
+	}
+	return adoptWorktrees(found, records)
+}
+
+// replaceConfig shows how content differs from the config file at path and,
+// once confirmed, moves the file to path.bak and writes content in its place.
+func replaceConfig(path, content string) (bool, error) {
+	newPath := path + ".new"
+	if err := os.WriteFile(newPath, []byte(content), 0644); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", newPath, err)
+	}
+	defer os.Remove(newPath)
+
+	if err := git.DiffFiles(path, newPath, os.Stderr); err != nil {
+		return false, err
+	}
+	if !initYes {
+		confirmed, err := tui.Confirm(fmt.Sprintf("Replace %s? The current file is kept as %s.bak", path, path))
+		if err != nil {
+			return false, fmt.Errorf("%w (use --yes to replace without asking)", err)
+		}
+		if !confirmed {
+			fmt.Fprintln(os.Stderr, "Kept the current config.")
+			return false, nil
+		}
+	}
+
+	if err := os.Rename(path, path+".bak"); err != nil {
+		return false, fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	if err := os.Rename(newPath, path); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	log.Infof("Backed up the previous config to %s.bak", path)
+	return true, nil
 }
 
 func ensureGitignoreHasWorktreeDir(worktreeDir string) error {
@@ -450,33 +1972,33 @@ func runShellInit(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func openTmuxPane(path string) error {
-	if os.Getenv("TMUX") == "" {
-		return fmt.Errorf("not inside a tmux session")
-	}
-
-	cmd := exec.Command("tmux", "new-window", "-c", path)
-	return cmd.Run()
-}
-
 const bashZshIntegration = `# wt shell integration
 # Add this to your .bashrc or .zshrc:
 #   eval "$(wt shell-init bash)"  # for bash
 #   eval "$(wt shell-init zsh)"   # for zsh
+#
+# wt exits with 2 when there are no worktrees and 130 when a selection is
+# cancelled; the wrapper treats cancellation as a no-op.
 
 wt() {
-  if [[ "$1" == "cd" ]] && [[ ! " $* " =~ " --tmux " ]] && [[ ! " $* " =~ " -t " ]]; then
-    local result
+  if [[ "$1" == "cd" ]] && [[ ! " $* " =~ " --tmux " ]] && [[ ! " $* " =~ " -t " ]] && [[ ! " $* " =~ " --remote" ]]; then
+    local result rc
     result=$(command wt cd --print-path "${@:2}")
-    if [[ -n "$result" && -d "$result" ]]; then
+    rc=$?
+    [[ $rc -eq 130 ]] && return 0
+    if [[ $rc -eq 0 && -n "$result" && -d "$result" ]]; then
       cd "$result"
     fi
+    return $rc
   elif [[ "$1" == "add" ]] && [[ ! " $* " =~ " --tmux " ]] && [[ ! " $* " =~ " -t " ]]; then
-    local result
+    local result rc
     result=$(command wt add "${@:2}" --print-path)
-    if [[ -n "$result" && -d "$result" ]]; then
+    rc=$?
+    [[ $rc -eq 130 ]] && return 0
+    if [[ $rc -eq 0 && -n "$result" && -d "$result" ]]; then
       cd "$result"
     fi
+    return $rc
   else
     command wt "$@"
   fi
@@ -486,18 +2008,27 @@ wt() {
 const fishIntegration = `# wt shell integration
 # Add this to your config.fish:
 #   wt shell-init fish | source
+#
+# wt exits with 2 when there are no worktrees and 130 when a selection is
+# cancelled; the wrapper treats cancellation as a no-op.
 
 function wt
-  if test "$argv[1]" = "cd"; and not contains -- --tmux $argv; and not contains -- -t $argv
+  if test "$argv[1]" = "cd"; and not contains -- --tmux $argv; and not contains -- -t $argv; and not string match -q -- '--remote*' $argv
     set -l result (command wt cd --print-path $argv[2..])
-    if test -n "$result"; and test -d "$result"
+    set -l rc $status
+    test $rc -eq 130; and return 0
+    if test $rc -eq 0; and test -n "$result"; and test -d "$result"
       cd $result
     end
+    return $rc
   else if test "$argv[1]" = "add"; and not contains -- --tmux $argv; and not contains -- -t $argv
     set -l result (command wt add $argv[2..] --print-path)
-    if test -n "$result"; and test -d "$result"
+    set -l rc $status
+    test $rc -eq 130; and return 0
+    if test $rc -eq 0; and test -n "$result"; and test -d "$result"
       cd $result
     end
+    return $rc
   else
     command wt $argv
   end
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/log"
+)
+
+// registerMaintenance runs git maintenance start for the repository unless
+// maintenance.register is off or git already lists it. A failure only warns:
+// the worktree itself is fine without background maintenance.
+func registerMaintenance(cfg *config.Config, repoRoot string) {
+	if !cfg.Maintenance.Register {
+		return
+	}
+	if git.MaintenanceRegistered(repoRoot) {
+		log.Explainf("maintenance: %s is already registered", repoRoot)
+		return
+	}
+	log.Infof("Registering %s for git maintenance...", repoRoot)
+	if err := git.StartMaintenance(repoRoot); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// collectGarbage runs a lightweight gc once removed reaches
+// maintenance.gc_after_removals.
+func collectGarbage(cfg *config.Config, removed int) {
+	threshold := cfg.Maintenance.GCAfterRemovals
+	if threshold == 0 || removed < threshold {
+		return
+	}
+	log.Infof("Removed %d worktrees, running git gc --auto...", removed)
+	if err := git.GC(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
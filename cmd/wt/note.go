@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/state"
+)
+
+var noteCmd = &cobra.Command{
+	Use:   "note [path|branch] [text]",
+	Short: "Show or set a worktree's note and preview URL",
+	Long: `Show or set the note and preview URL of a worktree (default: the current one).
+The note is set with -m, or as the text after the worktree:
+
+  wt note exp-cache "trying an LRU instead of the TTL map"
+
+Notes are shown dimmed next to the branch in wt ls and the wt cd selector.
+
+Notes are keyed by branch. With state_backend = "git" and state_remote set,
+they are shared with everyone using the same remote; --list shows all of them,
+including notes on branches you have no worktree for.`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: runNote,
+}
+
+var (
+	noteMessage string
+	noteURL     string
+	noteClear   bool
+	noteList    bool
+)
+
+func init() {
+	noteCmd.Flags().StringVarP(&noteMessage, "message", "m", "", "Set the note")
+	noteCmd.Flags().StringVar(&noteURL, "url", "", "Set the preview URL")
+	noteCmd.Flags().BoolVar(&noteClear, "clear", false, "Remove the note and URL")
+	noteCmd.Flags().BoolVar(&noteList, "list", false, "List all notes")
+	noteCmd.MarkFlagsMutuallyExclusive("clear", "message")
+	noteCmd.MarkFlagsMutuallyExclusive("clear", "url")
+	noteCmd.MarkFlagsMutuallyExclusive("list", "message")
+	noteCmd.MarkFlagsMutuallyExclusive("list", "url")
+	noteCmd.MarkFlagsMutuallyExclusive("list", "clear")
+
+	rootCmd.AddCommand(noteCmd)
+}
+
+func runNote(cmd *cobra.Command, args []string) error {
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+	store, err := openStateStore(cfg)
+	if err != nil {
+		return err
+	}
+	setNote, setURL := cmd.Flags().Changed("message"), cmd.Flags().Changed("url")
+	if len(args) == 2 {
+		if setNote || noteClear || noteList {
+			return fmt.Errorf("the note text cannot be combined with --message, --clear or --list")
+		}
+		setNote, noteMessage, args = true, args[1], args[:1]
+	}
+
+	if noteList {
+		st, err := store.Load()
+		if err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(st.Worktrees))
+		for key, e := range st.Worktrees {
+			if !e.Empty() {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			printNote(key, st.Worktrees[key])
+		}
+		return nil
+	}
+
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	target, err := targetWorktree(worktrees, args)
+	if err != nil {
+		return err
+	}
+	if target.Branch == "" {
+		return fmt.Errorf("notes are keyed by branch, but %s has a detached HEAD", target.Path)
+	}
+
+	if !setNote && !setURL && !noteClear {
+		st, err := store.Load()
+		if err != nil {
+			return err
+		}
+		if e := st.Worktrees[target.Branch]; !e.Empty() {
+			printNote(target.Branch, e)
+		}
+		return nil
+	}
+
+	updatedBy := git.ResolveIdentity(target.Path).Email
+	return store.Update(func(st *state.State) error {
+		e := st.Worktrees[target.Branch]
+		switch {
+		case noteClear:
+			e = state.Entry{}
+		default:
+			if setNote {
+				e.Note = noteMessage
+			}
+			if setURL {
+				e.URL = noteURL
+			}
+		}
+		e.UpdatedBy = updatedBy
+		e.UpdatedAt = time.Now().UTC()
+		st.Set(target.Branch, e)
+		return nil
+	})
+}
+
+func printNote(key string, e state.Entry) {
+	fmt.Println(key)
+	if e.Note != "" {
+		fmt.Printf("  note: %s\n", e.Note)
+	}
+	if e.URL != "" {
+		fmt.Printf("  url:  %s\n", e.URL)
+	}
+	if e.UpdatedBy != "" {
+		fmt.Printf("  by %s on %s\n", e.UpdatedBy, e.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	}
+}
+
+// worktreeNotes returns the first line of each worktree's note by path, for
+// showing next to the branch. It only reads the local copy of the state, so
+// listing never touches the network; a store that can't be read shows no
+// notes.
+func worktreeNotes(cfg *config.Config, worktrees []git.Worktree) map[string]string {
+	st, err := loadLocalState(cfg)
+	if err != nil {
+		return nil
+	}
+	notes := make(map[string]string)
+	for _, wt := range worktrees {
+		if wt.Branch == "" {
+			continue
+		}
+		if note, _, _ := strings.Cut(st.Worktrees[wt.Branch].Note, "\n"); note != "" {
+			notes[wt.Path] = note
+		}
+	}
+	return notes
+}
+
+// openStateStore returns the metadata store configured for the repository.
+func openStateStore(cfg *config.Config) (state.Store, error) {
+	gitDir, err := git.CommonDir()
+	if err != nil {
+		return nil, err
+	}
+	backend, err := state.ParseBackend(cfg.StateBackend)
+	if err != nil {
+		return nil, err
+	}
+	return state.Open(backend, gitDir, cfg.StateRemote), nil
+}
+
+// loadLocalState reads the local copy of the metadata store, without
+// fetching state_remote.
+func loadLocalState(cfg *config.Config) (state.State, error) {
+	gitDir, err := git.CommonDir()
+	if err != nil {
+		return state.State{}, err
+	}
+	backend, err := state.ParseBackend(cfg.StateBackend)
+	if err != nil {
+		return state.State{}, err
+	}
+	return state.Open(backend, gitDir, "").Load()
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/term"
+	"github.com/default-anton/wt/internal/tmpl"
+)
+
+// openFlags are the flags shared by commands that can open a worktree in a
+// terminal multiplexer or a new terminal tab instead of printing its path.
+type openFlags struct {
+	tmux        bool
+	tmuxSplit   string
+	tmuxSession bool
+	zellij      bool
+	windowName  string
+}
+
+func (f *openFlags) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&f.tmux, "tmux", "t", false, "Open in tmux (mode from tmux_mode config, default: new window), or a new zellij, WezTerm, kitty, iTerm2 or Windows Terminal tab or split when run there (see the terminal config)")
+	cmd.Flags().StringVar(&f.tmuxSplit, "tmux-split", "", "Open in a tmux split: horizontal or vertical")
+	cmd.Flags().Lookup("tmux-split").NoOptDefVal = "horizontal"
+	cmd.Flags().BoolVar(&f.tmuxSession, "tmux-session", false, "Open in a tmux session named after the branch")
+	cmd.Flags().BoolVar(&f.zellij, "zellij", false, "Open in zellij (mode from zellij_mode config, default: new tab)")
+	cmd.Flags().StringVar(&f.windowName, "window-name", "", "Name the tmux window or session (overrides tmux_name)")
+}
+
+// requested reports whether any open flag was given.
+func (f *openFlags) requested() bool {
+	return f.tmux || f.tmuxSplit != "" || f.tmuxSession || f.zellij
+}
+
+// opener returns the opener selected by flags, falling back to the config.
+// Plain -t/--tmux opens in the terminal config names, or else the one wt is
+// running in.
+func (f *openFlags) opener(cfg *config.Config) (term.Opener, error) {
+	terminal := term.TerminalTmux
+	switch {
+	case f.zellij:
+		terminal = term.TerminalZellij
+	case f.tmux && f.tmuxSplit == "" && !f.tmuxSession:
+		var err error
+		if terminal, err = term.ParseTerminal(cfg.Terminal); err != nil {
+			return nil, err
+		}
+		if terminal == term.TerminalAuto {
+			terminal = term.DetectTerminal()
+		}
+	}
+
+	switch terminal {
+	case term.TerminalZellij:
+		mode, err := term.ParseZellijMode(cfg.ZellijMode)
+		if err != nil {
+			return nil, err
+		}
+		return term.Zellij{Mode: mode}, nil
+	case term.TerminalWezTerm, term.TerminalKitty, term.TerminalWindowsTerminal, term.TerminalITerm2:
+		return terminalOpener(terminal, cfg)
+	}
+
+	var (
+		mode term.TmuxMode
+		err  error
+	)
+	switch {
+	case f.tmuxSession:
+		mode = term.TmuxSession
+	case f.tmuxSplit != "":
+		mode, err = term.ParseTmuxMode("split-" + f.tmuxSplit)
+	default:
+		mode, err = term.ParseTmuxMode(cfg.TmuxMode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return term.Tmux{Mode: mode}, nil
+}
+
+// terminalOpener returns the opener of a terminal emulator, with
+// terminal_mode.
+func terminalOpener(terminal term.Terminal, cfg *config.Config) (term.Opener, error) {
+	mode, err := term.ParseTerminalMode(cfg.TerminalMode)
+	if err != nil {
+		return nil, err
+	}
+	switch terminal {
+	case term.TerminalWezTerm:
+		return term.WezTerm{Mode: mode}, nil
+	case term.TerminalKitty:
+		return term.Kitty{Mode: mode}, nil
+	case term.TerminalWindowsTerminal:
+		return term.WindowsTerminal{Mode: mode}, nil
+	default:
+		return term.ITerm2{Mode: mode}, nil
+	}
+}
+
+// openWorktree opens target with the opener selected by flags or config.
+// Local worktrees get their WT_* variables in the new pane's environment.
+func openWorktree(f *openFlags, cfg *config.Config, target term.Target) error {
+	opener, err := f.opener(cfg)
+	if err != nil {
+		return err
+	}
+	if target.Path != "" && len(target.Command) == 0 {
+		target.Env = worktreeEnv(cfg, target.Path, target.Branch)
+	}
+	switch {
+	case f.windowName != "":
+		target.Name = f.windowName
+	case cfg.TmuxName != "":
+		vars := tmpl.NameVars{Repo: repoName(), Branch: target.Branch}
+		if target.Path != "" {
+			vars.Dir = filepath.Base(target.Path)
+		}
+		if vars.Branch == "" {
+			vars.Branch = vars.Dir
+		}
+		if target.Name, err = tmpl.TmuxName(cfg.TmuxName, vars, func(name string) (string, error) { return sanitizeName(cfg, name) }); err != nil {
+			return err
+		}
+	}
+	return opener.Open(target)
+}
+
+// repoName is the repository's directory name: that of the main worktree,
+// or of a bare repository without its .git suffix.
+func repoName() string {
+	dir, err := git.CommonDir()
+	if err != nil {
+		return ""
+	}
+	if filepath.Base(dir) == ".git" {
+		dir = filepath.Dir(dir)
+	}
+	return strings.TrimSuffix(filepath.Base(dir), ".git")
+}
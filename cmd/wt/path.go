@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/tui"
+)
+
+var pathCmd = &cobra.Command{
+	Use:   "path <branch|query>",
+	Short: "Print the path of a worktree, without prompts",
+	Long: `Print the path of the worktree for a branch, for scripts, editor tasks and
+CI helpers:
+
+  cd "$(wt path feature-login)"
+
+A worktree whose branch (or path) is exactly the argument wins; otherwise the
+argument is fuzzy-matched against the branches as in wt cd, and must match
+exactly one worktree. The main worktree is included. Nothing is interactive:
+when no worktree or several match, wt path fails with exit code 1.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPath,
+}
+
+func init() {
+	rootCmd.AddCommand(pathCmd)
+}
+
+func runPath(cmd *cobra.Command, args []string) error {
+	query := args[0]
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	if wt, ok := git.FindWorktree(worktrees, query); ok {
+		fmt.Println(wt.Path)
+		return nil
+	}
+
+	items := make([]tui.Item, len(worktrees))
+	for i, wt := range worktrees {
+		items[i] = tui.Item{Label: wt.Label(), Value: wt.Path}
+	}
+	matches := tui.Filter(items, query)
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("no worktree matches %q", query)
+	case 1:
+		fmt.Println(matches[0].Value)
+		return nil
+	}
+	labels := make([]string, len(matches))
+	for i, m := range matches {
+		labels[i] = m.Label
+	}
+	return fmt.Errorf("%q matches %d worktrees: %s", query, len(matches), strings.Join(labels, ", "))
+}
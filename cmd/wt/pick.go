@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/hooks"
+	"github.com/default-anton/wt/internal/tui"
+)
+
+// pickFlags pick worktrees without the selector, for scripts, Makefiles and
+// CI jobs.
+type pickFlags struct {
+	branch string
+	filter string
+	first  bool
+}
+
+// register adds the flags to cmd, which must have a --query flag already.
+func (f *pickFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.branch, "select", "", "Pick the worktree of this branch (or path) without the selector")
+	cmd.Flags().StringVar(&f.filter, "filter", "", "Pick the worktrees whose branch matches this glob (or /regex/, as in if_branch) without the selector")
+	cmd.Flags().BoolVar(&f.first, "first", false, "Pick the first match, in the selector's order, without the selector")
+	cmd.MarkFlagsMutuallyExclusive("select", "filter")
+	cmd.MarkFlagsMutuallyExclusive("select", "first")
+	cmd.MarkFlagsMutuallyExclusive("select", "query")
+}
+
+// requested reports whether any pick flag was given.
+func (f *pickFlags) requested() bool {
+	return f.branch != "" || f.filter != "" || f.first
+}
+
+// pick returns the items the flags pick, in the selector's order: the one
+// of the --select worktree, or those whose branch matches --filter and
+// fuzzy-matches query, only the first of them with --first.
+func (f *pickFlags) pick(items []tui.Item, worktrees []git.Worktree, query string) ([]tui.Item, error) {
+	if f.branch != "" {
+		wt, ok := git.FindWorktree(worktrees, f.branch)
+		if !ok {
+			return nil, nil
+		}
+		for _, item := range items {
+			if item.Value == wt.Path {
+				return []tui.Item{item}, nil
+			}
+		}
+		return nil, nil
+	}
+
+	picked := items
+	if f.filter != "" {
+		branches := make(map[string]string, len(worktrees))
+		for _, wt := range worktrees {
+			branches[wt.Path] = wt.Branch
+		}
+		picked = nil
+		for _, item := range items {
+			branch := branches[item.Value]
+			if branch == "" {
+				continue
+			}
+			ok, err := hooks.MatchBranch(f.filter, branch)
+			if err != nil {
+				return nil, fmt.Errorf("--filter: %w", err)
+			}
+			if ok {
+				picked = append(picked, item)
+			}
+		}
+	}
+	if query != "" {
+		picked = tui.Filter(picked, query)
+	}
+	if f.first && len(picked) > 1 {
+		picked = picked[:1]
+	}
+	return picked, nil
+}
+
+// noMatch says that nothing matched the flags and query.
+func (f *pickFlags) noMatch(query string) string {
+	switch {
+	case f.branch != "":
+		return fmt.Sprintf("No worktree for %q.", f.branch)
+	case f.filter != "" && query != "":
+		return fmt.Sprintf("No worktree matches %q and %q.", f.filter, query)
+	case f.filter != "":
+		return fmt.Sprintf("No worktree matches %q.", f.filter)
+	}
+	return fmt.Sprintf("No worktree matches %q.", query)
+}
+
+// ambiguousPick is the error for several picked worktrees where one is
+// needed.
+func ambiguousPick(picked []tui.Item) error {
+	labels := make([]string, len(picked))
+	for i, item := range picked {
+		labels[i] = item.Label
+	}
+	return fmt.Errorf("%d worktrees match: %s (add --first to take the first)", len(picked), strings.Join(labels, ", "))
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Porcelain output is a compatibility guarantee for scripts and editor
+// plugins; see docs/porcelain.md before changing anything here.
+var (
+	porcelain    bool
+	porcelainNUL bool
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&porcelain, "porcelain", false, "Stable tab-separated output for scripts (ls, add)")
+	rootCmd.PersistentFlags().BoolVarP(&porcelainNUL, "null", "z", false, "Porcelain output with records terminated by NUL instead of newline")
+}
+
+// porcelainMode reports whether porcelain output was requested.
+func porcelainMode() bool {
+	return porcelain || porcelainNUL
+}
+
+// writeRecord prints one porcelain record: tab-separated fields terminated
+// by a newline, or by NUL with -z.
+func writeRecord(fields ...string) {
+	terminator := "\n"
+	if porcelainNUL {
+		terminator = "\x00"
+	}
+	fmt.Fprint(os.Stdout, strings.Join(fields, "\t")+terminator)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/runner"
+)
+
+var prCmd = &cobra.Command{
+	Use:   "pr",
+	Short: "Open pull requests from worktrees",
+}
+
+var prCreateCmd = &cobra.Command{
+	Use:   "create [path|branch] [-- gh flags]",
+	Short: "Push a worktree's branch and open a pull request with gh",
+	Long: `Push the branch of a worktree (default: the current one) and open a pull
+request for it with gh pr create.
+
+The pull request targets the base branch the worktree was created from
+(base_branch when wt has no record of it). Its title is what was passed to
+wt add when preprocessing turned that into a different branch name, e.g.
+"Fix login timeout" for fix-login-timeout; otherwise gh fills the title and
+body from the commits. --title sets the title, and anything after -- is
+passed on to gh pr create:
+
+  wt pr create -- --reviewer octocat --label bug`,
+	RunE: runPRCreate,
+}
+
+var (
+	prTitle string
+	prDraft bool
+	prWeb   bool
+)
+
+func init() {
+	prCreateCmd.Flags().StringVar(&prTitle, "title", "", "Title of the pull request")
+	prCreateCmd.Flags().BoolVar(&prDraft, "draft", false, "Open the pull request as a draft")
+	prCreateCmd.Flags().BoolVar(&prWeb, "web", false, "Finish the pull request in the browser")
+
+	prCmd.AddCommand(prCreateCmd)
+	rootCmd.AddCommand(prCmd)
+}
+
+func runPRCreate(cmd *cobra.Command, args []string) error {
+	ghArgs := []string{}
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		args, ghArgs = args[:dash], args[dash:]
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("accepts at most one worktree, got %d (pass gh flags after --)", len(args))
+	}
+
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	target, err := targetWorktree(worktrees, args)
+	if err != nil {
+		return err
+	}
+	if target.Branch == "" {
+		return fmt.Errorf("%s is not on a branch", target.Path)
+	}
+	if _, err := exec.LookPath("gh"); err != nil {
+		return fmt.Errorf("wt pr create needs the GitHub CLI (gh): %w", err)
+	}
+
+	base, title := cfg.BaseBranch, prTitle
+	if rec, ok := loadRecords().Worktrees[target.Path]; ok {
+		if rec.BaseBranch != "" {
+			base = rec.BaseBranch
+		}
+		if title == "" && rec.Input != "" && rec.Input != rec.InitialBranch && rec.InitialBranch == target.Branch {
+			title = rec.Input
+		}
+	}
+
+	// gh wants the base as a branch of the repository, not origin/main.
+	base = git.RemoteBranch(base)
+	if target.Branch == base {
+		return fmt.Errorf("%s is on the base branch %s; nothing to open a pull request for", target.Path, base)
+	}
+
+	log.Infof("Pushing %s...", target.Branch)
+	if err := git.Push(target.Path, target.Branch); err != nil {
+		return err
+	}
+
+	create := []string{"pr", "create", "--head", target.Branch, "--base", base}
+	if title != "" {
+		create = append(create, "--title", title)
+	}
+	if prDraft {
+		create = append(create, "--draft")
+	}
+	if prWeb {
+		create = append(create, "--web")
+	} else {
+		create = append(create, "--fill")
+	}
+	gh := exec.Command("gh", append(create, ghArgs...)...)
+	gh.Dir = target.Path
+	gh.Stdin = os.Stdin
+	gh.Stdout = os.Stdout
+	gh.Stderr = os.Stderr
+	if err := runner.Run(gh); err != nil {
+		return fmt.Errorf("gh pr create failed: %w", err)
+	}
+	return nil
+}
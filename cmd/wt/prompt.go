@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/git"
+)
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Print a prompt segment when inside a linked worktree",
+	Long: `Print a short segment for a shell prompt, e.g. "⎇ feature/auth [wt]", when
+the current directory is inside a linked worktree, and nothing (with exit
+code 0) in the main worktree or outside a repository.
+
+It reads the .git file and HEAD directly instead of running git or loading
+the config, so it stays well under the time a prompt can spare:
+
+  PS1='$(wt prompt --color bash) \w \$ '        (bash)
+  PROMPT='$(wt prompt --color zsh) %~ %# '      (zsh, with PROMPT_SUBST)
+
+The output is plain unless --color asks for ANSI colors: "ansi" for raw
+escapes (starship, fish), "bash" and "zsh" to also mark them as zero-width
+for the shell's line editor.`,
+	Args: cobra.NoArgs,
+	// Skip the config and git lookups every other command starts with.
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	RunE:             runPrompt,
+}
+
+var promptColor string
+
+func init() {
+	promptCmd.Flags().StringVar(&promptColor, "color", "", "Color the segment for a prompt: ansi, bash or zsh")
+	rootCmd.AddCommand(promptCmd)
+}
+
+// promptEscapes maps the --color modes to how they wrap an ANSI escape.
+var promptEscapes = map[string]string{
+	"ansi": "%s",
+	"bash": "\x01%s\x02",
+	"zsh":  "%%{%s%%}",
+}
+
+func runPrompt(cmd *cobra.Command, args []string) error {
+	wrap, ok := promptEscapes[promptColor]
+	if promptColor != "" && !ok {
+		return fmt.Errorf("unknown color mode %q (supported: ansi, bash, zsh)", promptColor)
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	head, ok := git.LinkedWorktreeHead(dir)
+	if !ok {
+		return nil
+	}
+	if wrap == "" {
+		fmt.Printf("⎇ %s [wt]\n", head)
+		return nil
+	}
+	if promptColor == "zsh" {
+		// zsh expands % sequences in the prompt after substituting it, so a
+		// branch named fix-100% would otherwise eat the rest of it.
+		head = strings.ReplaceAll(head, "%", "%%")
+	}
+	color := func(code string) string { return fmt.Sprintf(wrap, "\x1b["+code+"m") }
+	fmt.Printf("%s⎇ %s%s %s[wt]%s\n", color("35"), head, color("0"), color("2"), color("0"))
+	return nil
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/state"
+	"github.com/default-anton/wt/internal/tui"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune --gone | --stale",
+	Short: "Remove worktrees whose upstream branch was deleted or that went stale",
+	Long: `With --gone, find local branches whose upstream is gone, usually because
+the remote branch was deleted after its pull request was merged, then remove
+their worktrees and delete the branches after one confirmation.
+
+All remotes are fetched with --prune first so git notices deleted branches;
+--no-fetch skips that. A branch is only pruned when all of its commits were
+on its upstream right before that fetch deleted it: branches with commits
+made since, or whose upstream was already gone, are kept, and so are
+worktrees with uncommitted changes, unless --force is given. Branches are
+deleted with git branch -d, so one not merged into HEAD (e.g. squash-merged)
+stays until --force deletes it anyway. The branch checked out in the main
+worktree is never deleted.
+
+With --stale, find worktrees whose branch has had no commits, and that
+haven't been opened with wt cd (or created, if never opened), for longer
+than max_age from the config. Their worktrees are removed, but the branches
+stay. Worktrees with uncommitted changes or unpushed commits are kept unless
+--force is given.
+
+Both can be combined.`,
+	Args: cobra.NoArgs,
+	RunE: runPrune,
+}
+
+var (
+	pruneGone    bool
+	pruneStale   bool
+	pruneYes     bool
+	pruneDryRun  bool
+	pruneForce   bool
+	pruneNoFetch bool
+)
+
+func init() {
+	pruneCmd.Flags().BoolVar(&pruneGone, "gone", false, "Prune branches whose upstream branch no longer exists")
+	pruneCmd.Flags().BoolVar(&pruneStale, "stale", false, "Prune worktrees unused for longer than max_age")
+	pruneCmd.Flags().BoolVarP(&pruneYes, "yes", "y", false, "Prune without asking")
+	pruneCmd.Flags().BoolVarP(&pruneDryRun, "dry-run", "n", false, "Only list what would be pruned")
+	pruneCmd.Flags().BoolVarP(&pruneForce, "force", "f", false, "Also prune worktrees with uncommitted changes, and branches or worktrees with unpushed commits (deleting branches with git branch -D)")
+	pruneCmd.Flags().BoolVar(&pruneNoFetch, "no-fetch", false, "Don't fetch before looking for gone branches")
+	rootCmd.AddCommand(pruneCmd)
+}
+
+// pruneCandidate is a worktree, a branch, or both, to prune.
+type pruneCandidate struct {
+	branch       string
+	path         string // empty for branches without a worktree
+	deleteBranch bool
+	detail       string
+	label        string // the worktree's label, for messages
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	if !pruneGone && !pruneStale {
+		return fmt.Errorf("say what to prune: --gone, --stale, or both")
+	}
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+	var maxAge time.Duration
+	if pruneStale {
+		if cfg.MaxAge == "" {
+			return fmt.Errorf("--stale needs max_age in the config, e.g. max_age = \"30d\"")
+		}
+		if maxAge, err = state.ParseTTL(cfg.MaxAge); err != nil {
+			return fmt.Errorf("max_age: %w", err)
+		}
+	}
+
+	var upstreams map[string]string
+	if pruneGone {
+		// Where the upstreams were before the fetch deletes them.
+		if upstreams, err = git.UpstreamCommits(); err != nil {
+			return err
+		}
+		if !pruneNoFetch {
+			log.Infof("Fetching...")
+			if err := git.FetchPrune(); err != nil {
+				return err
+			}
+		}
+	}
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+
+	var gone, stale []pruneCandidate
+	var kept []string
+	seen := make(map[string]bool)
+	if pruneGone {
+		if gone, kept, err = goneCandidates(worktrees, upstreams); err != nil {
+			return err
+		}
+		for _, c := range gone {
+			seen[c.path] = true
+		}
+	}
+	if pruneStale {
+		candidates, staleKept := staleCandidates(worktrees, maxAge, time.Now())
+		for _, c := range candidates {
+			if !seen[c.path] {
+				stale = append(stale, c)
+			}
+		}
+		kept = append(kept, staleKept...)
+	}
+
+	if len(gone)+len(stale)+len(kept) == 0 {
+		log.Infof("Nothing to prune.")
+		return nil
+	}
+	if pruneGone {
+		printPruneCandidates(fmt.Sprintf("Branches whose upstream is gone (%d):", len(gone)), gone)
+	}
+	if pruneStale {
+		printPruneCandidates(fmt.Sprintf("Worktrees unused for over %s (%d):", cfg.MaxAge, len(stale)), stale)
+	}
+	if len(kept) > 0 {
+		fmt.Fprintf(os.Stderr, "Keeping %d (use --force to prune them too):\n", len(kept))
+		for _, k := range kept {
+			fmt.Fprintf(os.Stderr, "  %s\n", k)
+		}
+	}
+	prune := append(gone, stale...)
+	if len(prune) == 0 || pruneDryRun {
+		return nil
+	}
+	if !pruneYes {
+		confirmed, err := tui.Confirm(fmt.Sprintf("Prune %d worktree(s) or branch(es)?", len(prune)))
+		if err != nil {
+			return fmt.Errorf("%w (use --yes to prune without asking)", err)
+		}
+		if !confirmed {
+			return quietExit(cmd, errCancelled)
+		}
+	}
+
+	removed := 0
+	for _, c := range prune {
+		if c.path != "" {
+			fmt.Printf("Removing worktree: %s\n", c.path)
+			// Risky worktrees were filtered out above, and commits of
+			// branches whose upstream is gone are on no remote any more,
+			// so force past git's checks.
+			if err := removeWorktree(c.path, true); err != nil {
+				return err
+			}
+			removed++
+		}
+		if !c.deleteBranch {
+			continue
+		}
+		deleteBranch := git.DeleteMergedBranch
+		if pruneForce {
+			deleteBranch = git.DeleteBranch
+		}
+		if err := deleteBranch(c.branch); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: kept branch %s: %v (use --force to delete it)\n", c.branch, err)
+			continue
+		}
+		log.Infof("Deleted branch %s", c.branch)
+	}
+	collectGarbage(cfg, removed)
+	return nil
+}
+
+// goneCandidates returns the local branches whose upstream is gone, with
+// their worktrees, and describes those kept back. upstreams has the commits
+// the upstreams were at before they were fetched away.
+func goneCandidates(worktrees []git.Worktree, upstreams map[string]string) ([]pruneCandidate, []string, error) {
+	gone, err := git.GoneBranches()
+	if err != nil {
+		return nil, nil, err
+	}
+	byBranch := make(map[string]git.Worktree, len(worktrees))
+	for _, wt := range worktrees {
+		if wt.Branch != "" {
+			byBranch[wt.Branch] = wt
+		}
+	}
+
+	var paths []string
+	for _, branch := range gone {
+		if wt, ok := byBranch[branch]; ok && !wt.IsMain {
+			paths = append(paths, wt.Path)
+		}
+	}
+	statuses := git.StatusAll(context.Background(), paths, git.DirtyFull, nil)
+
+	var prune []pruneCandidate
+	var kept []string
+	for _, branch := range gone {
+		wt, ok := byBranch[branch]
+		if ok && wt.IsMain {
+			kept = append(kept, fmt.Sprintf("%s (checked out in the main worktree)", branch))
+			continue
+		}
+		name := branch
+		if ok {
+			name += " " + wt.Path
+		}
+		if !pruneForce {
+			if reason := unpushedReason(branch, upstreams); reason != "" {
+				kept = append(kept, fmt.Sprintf("%s (%s)", name, reason))
+				continue
+			}
+		}
+		if !ok {
+			prune = append(prune, pruneCandidate{branch: branch, deleteBranch: true})
+			continue
+		}
+		if !pruneForce {
+			status, known := statuses[wt.Path]
+			if !known {
+				kept = append(kept, fmt.Sprintf("%s (status unknown)", name))
+				continue
+			}
+			if status.Dirty {
+				kept = append(kept, fmt.Sprintf("%s (uncommitted changes)", name))
+				continue
+			}
+		}
+		prune = append(prune, pruneCandidate{branch: branch, path: wt.Path, deleteBranch: true})
+	}
+	return prune, kept, nil
+}
+
+// unpushedReason says why the commits of branch may not have been on its
+// upstream before it was deleted, or returns "" when they all were.
+func unpushedReason(branch string, upstreams map[string]string) string {
+	sha, ok := upstreams[branch]
+	if !ok {
+		return "upstream deleted before this run, can't tell whether all commits were pushed"
+	}
+	if !git.IsAncestor(branch, sha) {
+		return "commits that were never on its upstream"
+	}
+	return ""
+}
+
+// staleCandidates returns the linked worktrees with neither a commit nor a
+// visit within maxAge of now, and describes those kept back.
+func staleCandidates(worktrees []git.Worktree, maxAge time.Duration, now time.Time) ([]pruneCandidate, []string) {
+	records := loadRecords()
+	usage, _ := loadUsage()
+
+	var stale []pruneCandidate
+	for _, wt := range worktrees {
+		if wt.IsMain || wt.Bare {
+			continue
+		}
+		committed, err := git.LastCommitTime(wt.Path)
+		if err != nil || now.Sub(committed) <= maxAge {
+			continue
+		}
+		used := worktreeCreated(records, wt.Path)
+		if visits, ok := usage.Worktrees[wt.Path]; ok && visits.Last.After(used) {
+			used = visits.Last
+		}
+		if now.Sub(used) <= maxAge {
+			continue
+		}
+		detail := fmt.Sprintf("(last commit %s ago, last used %s ago)", tui.FormatAge(now.Sub(committed)), tui.FormatAge(now.Sub(used)))
+		stale = append(stale, pruneCandidate{branch: wt.Branch, path: wt.Path, detail: detail, label: wt.Label()})
+	}
+
+	paths := make([]string, len(stale))
+	for i, c := range stale {
+		paths[i] = c.path
+	}
+	statuses := git.StatusAll(context.Background(), paths, git.DirtyFull, nil)
+
+	var prune []pruneCandidate
+	var kept []string
+	for _, c := range stale {
+		if pruneForce {
+			prune = append(prune, c)
+			continue
+		}
+		status, ok := statuses[c.path]
+		switch {
+		case !ok:
+			kept = append(kept, fmt.Sprintf("%s %s (status unknown)", c.label, c.path))
+		case status.Risky():
+			kept = append(kept, fmt.Sprintf("%s %s %s", c.label, c.path, describeStatus(status)))
+		default:
+			prune = append(prune, c)
+		}
+	}
+	return prune, kept
+}
+
+func printPruneCandidates(title string, candidates []pruneCandidate) {
+	fmt.Fprintln(os.Stderr, title)
+	for _, c := range candidates {
+		line := "  " + c.branch
+		if c.branch == "" {
+			line = "  " + c.path
+		} else if c.path != "" {
+			line += " " + c.path
+		}
+		if c.detail != "" {
+			line += " " + c.detail
+		}
+		fmt.Fprintln(os.Stderr, line)
+	}
+}
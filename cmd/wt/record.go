@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/record"
+	"github.com/default-anton/wt/internal/tui"
+)
+
+var recordCmd = &cobra.Command{
+	Use:   "record [path|branch]",
+	Short: "Record setup commands and turn them into post hooks",
+	Long: `Start a recorded subshell in a worktree (default: the current one).
+
+Run your setup commands as usual, then exit the shell. wt lists the commands
+you ran and appends the ones you pick to .wt.toml as [[post_hooks]].`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRecord,
+}
+
+var recordAll bool
+
+func init() {
+	recordCmd.Flags().BoolVar(&recordAll, "all", false, "Save every recorded command without prompting")
+
+	rootCmd.AddCommand(recordCmd)
+}
+
+func runRecord(cmd *cobra.Command, args []string) error {
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+
+	target, err := targetWorktree(worktrees, args)
+	if err != nil {
+		return err
+	}
+
+	// Hooks are saved to the main worktree's config, where `wt add` is usually run.
+	configPath, err := configFilePath(false)
+	if err != nil {
+		return err
+	}
+	if mainWt, ok := git.MainWorktree(worktrees); ok {
+		configPath = filepath.Join(mainWt.Path, config.ConfigFileName)
+	}
+
+	fmt.Fprintf(os.Stderr, "Recording commands in %s. Exit the shell when done.\n", target.Path)
+	commands, err := record.Shell(target.Path)
+	if err != nil {
+		return err
+	}
+
+	if len(commands) == 0 {
+		fmt.Fprintln(os.Stderr, "No commands recorded.")
+		return nil
+	}
+
+	selected := commands
+	if !recordAll {
+		items := make([]tui.Item, len(commands))
+		for i, c := range commands {
+			items[i] = tui.Item{Label: c, Value: c}
+		}
+		selected, err = tui.MultiSelect(items)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(selected) == 0 {
+		fmt.Fprintln(os.Stderr, "No commands selected.")
+		return quietExit(cmd, errCancelled)
+	}
+
+	newHooks := make([]config.Hook, len(selected))
+	for i, c := range selected {
+		newHooks[i] = config.Hook{Name: hookName(c), Run: c}
+	}
+	if err := config.AppendPostHooks(configPath, newHooks); err != nil {
+		return fmt.Errorf("failed to update %s: %w", configPath, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Added %d post hook(s) to %s\n", len(newHooks), configPath)
+	return nil
+}
+
+// hookName derives a short hook name from a command line.
+func hookName(command string) string {
+	const maxLen = 40
+	if len(command) <= maxLen {
+		return command
+	}
+	return command[:maxLen-3] + "..."
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/state"
+)
+
+// lsEntry is a worktree in wt ls --json, with how wt created it when it
+// did.
+type lsEntry struct {
+	git.Worktree
+	*state.Record
+	Size *int64 `json:"size,omitempty"` // bytes on disk, with --size
+}
+
+// loadRecords returns how wt created the worktrees of the current
+// repository. Records only add detail, so a broken file only warns.
+func loadRecords() state.Records {
+	gitDir, err := git.CommonDir()
+	if err != nil {
+		return state.Records{}
+	}
+	records, err := state.LoadRecords(state.RecordsPath(gitDir))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return records
+}
+
+// saveRecord remembers how the worktree at path was created, forgetting
+// worktrees that no longer exist.
+func saveRecord(path string, rec state.Record) {
+	gitDir, err := git.CommonDir()
+	if err != nil {
+		return
+	}
+	worktrees, listErr := git.ListWorktrees()
+	err = state.UpdateRecords(state.RecordsPath(gitDir), func(records *state.Records) error {
+		if listErr == nil {
+			records.Keep(worktreePaths(worktrees))
+		}
+		records.Set(path, rec)
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record worktree details: %v\n", err)
+	}
+}
+
+// keepRecord remembers that the files of the unregistered worktree at path
+// were kept on purpose, so wt clean doesn't take them for a leftover.
+func keepRecord(path string) {
+	gitDir, err := git.CommonDir()
+	if err != nil {
+		return
+	}
+	err = state.UpdateRecords(state.RecordsPath(gitDir), func(records *state.Records) error {
+		records.SetKept(path, time.Now())
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record kept directory: %v\n", err)
+	}
+}
+
+// forgetRecord drops the record of the worktree at path after it was
+// removed, so a worktree created there later, even outside wt, doesn't
+// inherit its expiry.
+func forgetRecord(path string) {
+	gitDir, err := git.CommonDir()
+	if err != nil {
+		return
+	}
+	err = state.UpdateRecords(state.RecordsPath(gitDir), func(records *state.Records) error {
+		delete(records.Worktrees, path)
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to forget worktree details: %v\n", err)
+	}
+}
+
+// worktreeBase returns the base branch the worktree at path was created
+// from, or base_branch when wt has no record of it.
+func worktreeBase(cfg *config.Config, records state.Records, path string) string {
+	if rec, ok := records.Worktrees[path]; ok && rec.BaseBranch != "" {
+		return rec.BaseBranch
+	}
+	return cfg.BaseBranch
+}
+
+// baseRef returns origin's copy of base when there is one, as it is at
+// least as recent as the local branch after a fetch, or else base itself.
+func baseRef(base string) string {
+	if git.RefExists("refs/remotes/origin/" + base) {
+		return "origin/" + base
+	}
+	return base
+}
+
+// worktreeCreated returns when the worktree at path was created: from its
+// record, or else from when its .git file was written.
+func worktreeCreated(records state.Records, path string) time.Time {
+	if rec, ok := records.Worktrees[path]; ok {
+		return rec.CreatedAt
+	}
+	if info, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/state"
+)
+
+var recoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Finish or roll back wt add and wt rm runs that were interrupted",
+	Long: `wt add and wt rm write what they are about to do to a journal in
+.git/wt/journal and clear it when they are done, so a crash, kill or power
+loss part way through leaves a record instead of an unknown state.
+
+wt recover goes through the operations left in the journal:
+
+  wt add   that created the worktree: runs the setup again (LFS, submodules,
+           templates, copy_patterns, post_hooks) with the current config
+  wt add   that did not get that far: removes what was left behind
+  wt rm    removes the rest of the worktree
+
+With --rollback, interrupted adds are undone instead: the worktree is
+removed, and so is its branch if wt add created it. Work done since is
+kept: a worktree with uncommitted changes is left in place with an error,
+and a branch with new commits is kept. A removal can't be undone, so it is
+always finished. Operations whose process is still running
+are left alone.`,
+	Args: cobra.NoArgs,
+	RunE: runRecover,
+}
+
+var (
+	recoverRollback bool
+	recoverList     bool
+)
+
+// Steps of a journaled operation.
+const stepCreate = "create"
+
+func init() {
+	recoverCmd.Flags().BoolVar(&recoverRollback, "rollback", false, "Undo interrupted adds instead of finishing them")
+	recoverCmd.Flags().BoolVar(&recoverList, "list", false, "Only list the interrupted operations")
+	recoverCmd.MarkFlagsMutuallyExclusive("rollback", "list")
+	rootCmd.AddCommand(recoverCmd)
+}
+
+func openJournal() (state.Journal, error) {
+	gitDir, err := git.CommonDir()
+	if err != nil {
+		return state.Journal{}, err
+	}
+	return state.Journal{Dir: state.JournalDir(gitDir)}, nil
+}
+
+// beginOperation journals op before its first step. The journal is a safety
+// net, so when it can't be written the operation goes ahead unrecorded.
+func beginOperation(op state.Operation) *state.Operation {
+	journal, err := openJournal()
+	if err == nil {
+		var begun *state.Operation
+		if begun, err = journal.Begin(op); err == nil {
+			return begun
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Warning: failed to journal %s: %v\n", op.Kind, err)
+	return &op
+}
+
+func stepOperation(op *state.Operation, step string) {
+	if err := op.Step(step); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to journal %s: %v\n", op.Kind, err)
+	}
+}
+
+// endOperation clears op from the journal once its outcome, success or a
+// reported error, is known.
+func endOperation(op *state.Operation) {
+	if err := op.Done(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to journal %s: %v\n", op.Kind, err)
+	}
+}
+
+// removeWorktree is git.RemoveWorktree, journaled.
+func removeWorktree(path string, force bool) error {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	op := beginOperation(state.Operation{Kind: state.OpRemove, Path: path})
+	defer endOperation(op)
+	if err := git.RemoveWorktree(path, force); err != nil {
+		return err
+	}
+	forgetRecord(path)
+	return nil
+}
+
+func runRecover(cmd *cobra.Command, args []string) error {
+	journal, err := openJournal()
+	if err != nil {
+		return err
+	}
+	pending, err := journal.Pending()
+	if err != nil {
+		return err
+	}
+	var ops []*state.Operation
+	for _, op := range pending {
+		if op.Running() {
+			log.Infof("Skipping %s: still running (pid %d)", op, op.PID)
+			continue
+		}
+		ops = append(ops, op)
+	}
+	if len(ops) == 0 {
+		log.Infof("Nothing to recover.")
+		return nil
+	}
+
+	if recoverList {
+		for _, op := range ops {
+			steps := "none"
+			if len(op.Steps) > 0 {
+				steps = strings.Join(op.Steps, ", ")
+			}
+			fmt.Printf("%s\n  started: %s\n  steps done: %s\n", op, op.StartedAt.Local().Format("2006-01-02 15:04"), steps)
+		}
+		return nil
+	}
+
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadFromDir(repoRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	for _, op := range ops {
+		worktrees, err := git.ListWorktrees()
+		if err != nil {
+			return err
+		}
+		_, registered := git.FindWorktree(worktrees, op.Path)
+
+		switch {
+		case op.Kind == state.OpRemove:
+			err = finishRemove(op, registered)
+		case op.Kind == state.OpAdd && op.Did(stepCreate) && registered && !recoverRollback:
+			err = resumeAdd(cfg, repoRoot, op)
+		case op.Kind == state.OpAdd:
+			err = rollbackAdd(op, registered, worktrees)
+		default:
+			err = fmt.Errorf("unknown operation %q", op.Kind)
+		}
+		if err != nil {
+			return fmt.Errorf("recovering %s: %w", op, err)
+		}
+		endOperation(op)
+	}
+	return nil
+}
+
+// resumeAdd runs the setup of a worktree whose wt add stopped after creating
+// it.
+func resumeAdd(cfg *config.Config, repoRoot string, op *state.Operation) error {
+	log.Infof("Finishing %s", op)
+	if err := ensureTrusted(repoRoot); err != nil {
+		return err
+	}
+	if op.Profile != "" {
+		profile, ok := cfg.Profiles[op.Profile]
+		if !ok {
+			return fmt.Errorf("unknown profile %q", op.Profile)
+		}
+		cfg = cfg.WithProfile(profile)
+	}
+	a := &adder{
+		cfg:          cfg,
+		repoRoot:     repoRoot,
+		copyPatterns: cfg.CopyPatterns,
+		postHooks:    cfg.PostHooks,
+		direnv:       cfg.Direnv,
+	}
+	a.lfs, _ = git.ParseLFSMode(cfg.LFS)
+	if op.Scope != "" {
+		pkg, ok := cfg.Packages[op.Scope]
+		if !ok {
+			return fmt.Errorf("unknown package %q", op.Scope)
+		}
+		a.copyPatterns, a.postHooks, a.scopeDir = pkg.CopyPatterns, pkg.PostHooks, pkg.Path
+	}
+
+	records := loadRecords()
+	rec, ok := records.Worktrees[op.Path]
+	if !ok {
+		rec = state.Record{
+			CreatedAt:     op.StartedAt,
+			Input:         op.Input,
+			InitialBranch: op.Branch,
+			BaseBranch:    op.BaseBranch,
+			Scope:         op.Scope,
+			Profile:       op.Profile,
+		}
+	}
+	rec.Copied, rec.Hooks = nil, nil
+	defer func() { saveRecord(op.Path, rec) }()
+
+	return a.setup(op.Path, filepath.Base(op.Path), &rec)
+}
+
+// rollbackAdd removes what an interrupted wt add left behind: the worktree,
+// or the directory git was still filling, and the branch if wt add created
+// it. Work done since is kept: it refuses to remove a worktree with
+// uncommitted changes, and keeps a branch with commits made after wt add.
+func rollbackAdd(op *state.Operation, registered bool, worktrees []git.Worktree) error {
+	if registered {
+		if _, err := os.Stat(op.Path); err == nil {
+			status, err := git.GetStatus(op.Path)
+			if err != nil {
+				return fmt.Errorf("can't tell whether %s has changes: %w", op.Path, err)
+			}
+			if status.Dirty {
+				return fmt.Errorf("%s has uncommitted changes; commit or discard them, or remove it with wt rm --force", op.Path)
+			}
+		}
+	}
+	log.Infof("Rolling back %s", op)
+	if registered {
+		if err := git.RemoveWorktree(op.Path, true); err != nil {
+			return err
+		}
+	} else if err := os.RemoveAll(op.Path); err != nil {
+		return err
+	}
+	if _, err := git.PruneWorktrees(); err != nil {
+		return err
+	}
+
+	if !op.NewBranch || op.Branch == "" {
+		return nil
+	}
+	if local, _ := git.BranchExists(op.Branch); !local {
+		return nil
+	}
+	for _, wt := range worktrees {
+		if wt.Branch == op.Branch && wt.Path != op.Path {
+			log.Infof("Keeping branch %s: checked out in %s", op.Branch, wt.Path)
+			return nil
+		}
+	}
+	start := op.StartCommit
+	if start == "" {
+		start = op.BaseBranch
+	}
+	if start == "" || !git.IsAncestor("refs/heads/"+op.Branch, start) {
+		log.Infof("Keeping branch %s: it has commits made after wt add", op.Branch)
+		return nil
+	}
+	log.Infof("Deleting branch %s", op.Branch)
+	return git.DeleteBranch(op.Branch)
+}
+
+// finishRemove removes what is left of a worktree whose wt rm was
+// interrupted.
+func finishRemove(op *state.Operation, registered bool) error {
+	if recoverRollback {
+		fmt.Fprintf(os.Stderr, "Warning: a removal can't be undone; finishing %s\n", op)
+	}
+	log.Infof("Finishing %s", op)
+	if registered {
+		if _, err := os.Stat(op.Path); err == nil {
+			return git.RemoveWorktree(op.Path, true)
+		}
+	}
+	_, err := git.PruneWorktrees()
+	return err
+}
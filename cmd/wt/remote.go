@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/remote"
+	"github.com/default-anton/wt/internal/runner"
+	"github.com/default-anton/wt/internal/term"
+	"github.com/default-anton/wt/internal/tui"
+)
+
+// runRemoteCd lets the user pick a worktree on a configured remote machine
+// and opens an ssh session into it, in a new tmux/zellij window with -t.
+func runRemoteCd(cmd *cobra.Command, cfg *config.Config, name string) error {
+	r, ok := cfg.Remotes[name]
+	if !ok {
+		return fmt.Errorf("unknown remote %q (defined: %s)", name, strings.Join(remoteNames(cfg), ", "))
+	}
+
+	log.Infof("Listing worktrees on %s...", r.Host)
+	worktrees, err := remote.List(r)
+	if err != nil {
+		return err
+	}
+
+	var items []tui.Item
+	labels := make(map[string]string)
+	for _, wt := range worktrees {
+		if wt.IsMain {
+			continue
+		}
+		items = append(items, tui.Item{Label: wt.Label(), Value: wt.Path, Path: wt.Path})
+		labels[wt.Path] = wt.Label()
+	}
+	if len(items) == 0 {
+		fmt.Fprintf(os.Stderr, "No worktrees on %s.\n", name)
+		return quietExit(cmd, errNoWorktrees)
+	}
+
+	selected, err := tui.Select(items)
+	if err != nil {
+		return err
+	}
+	if selected == "" {
+		return quietExit(cmd, errCancelled)
+	}
+
+	session := remote.SessionCommand(r, selected)
+	if cdOpen.requested() {
+		return openWorktree(&cdOpen, cfg, term.Target{Branch: labels[selected], Command: session})
+	}
+
+	ssh := exec.Command(session[0], session[1:]...)
+	ssh.Stdin, ssh.Stdout, ssh.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return runner.Run(ssh)
+}
+
+func remoteNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Remotes))
+	for name := range cfg.Remotes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
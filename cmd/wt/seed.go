@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/copy"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/tui"
+)
+
+var seedCmd = &cobra.Command{
+	Use:   "seed [pattern...]",
+	Short: "Copy files picked from another worktree into the current one",
+	Long: `Pick a source worktree, then the paths to copy from it into the current
+worktree, e.g. another worktree's .env or a warm build cache.
+
+The paths offered are the ones copy_patterns matches in the source, or the
+ones the given gitignore-like patterns match. --from names the source
+instead of picking it, and --all copies every match without picking.
+
+Files already in the current worktree are left alone; --update replaces the
+ones whose source is newer, --force replaces them all.`,
+	RunE: runSeed,
+}
+
+var (
+	seedFrom   string
+	seedAll    bool
+	seedUpdate bool
+	seedForce  bool
+)
+
+func init() {
+	seedCmd.Flags().StringVar(&seedFrom, "from", "", "Copy from this worktree (path or branch) instead of picking one")
+	seedCmd.Flags().BoolVar(&seedAll, "all", false, "Copy every matched path without picking")
+	seedCmd.Flags().BoolVar(&seedUpdate, "update", false, "Overwrite files whose source is newer")
+	seedCmd.Flags().BoolVarP(&seedForce, "force", "f", false, "Overwrite existing files")
+	seedCmd.MarkFlagsMutuallyExclusive("update", "force")
+	rootCmd.AddCommand(seedCmd)
+}
+
+func runSeed(cmd *cobra.Command, args []string) error {
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	dest, err := git.CurrentWorktree(worktrees)
+	if err != nil {
+		return err
+	}
+
+	patterns := args
+	if len(patterns) == 0 {
+		patterns = cfg.CopyPatterns
+	}
+	if len(patterns) == 0 {
+		return fmt.Errorf("no patterns given and no copy_patterns configured")
+	}
+
+	var src *git.Worktree
+	if seedFrom != "" {
+		wt, ok := git.FindWorktree(worktrees, seedFrom)
+		if !ok {
+			return fmt.Errorf("no worktree found for %q", seedFrom)
+		}
+		src = wt
+	} else {
+		var items []tui.Item
+		for _, wt := range worktrees {
+			if wt.Path == dest.Path || wt.Bare {
+				continue
+			}
+			items = append(items, tui.Item{Label: wt.Label(), Value: wt.Path, Path: wt.Path})
+		}
+		if len(items) == 0 {
+			fmt.Fprintln(os.Stderr, "No other worktrees to copy from.")
+			return quietExit(cmd, errNoWorktrees)
+		}
+		result, err := tui.SelectWith(items, tui.Options{Details: selectorDetails(cfg, worktrees)})
+		if err != nil {
+			return err
+		}
+		if result.Value == "" {
+			return quietExit(cmd, errCancelled)
+		}
+		src, _ = git.FindWorktree(worktrees, result.Value)
+	}
+	if src.Path == dest.Path {
+		return fmt.Errorf("%s is the current worktree; pick another to copy from", src.Path)
+	}
+	if src.Bare {
+		return fmt.Errorf("%s is a bare repository and has no working tree", src.Path)
+	}
+
+	paths, err := copy.Matches(patterns, src.Path)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		log.Infof("Nothing in %s matches.", src.Path)
+		return nil
+	}
+	if !seedAll {
+		items := make([]tui.Item, len(paths))
+		for i, path := range paths {
+			items[i] = tui.Item{Label: path, Value: path}
+			if _, err := os.Lstat(filepath.Join(dest.Path, path)); err == nil {
+				items[i].Detail = "(exists)"
+			}
+		}
+		if paths, err = tui.MultiSelectWith(items, tui.Options{}); err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			return quietExit(cmd, errCancelled)
+		}
+	}
+
+	log.Infof("Copying files from %s...", src.Path)
+	copied, err := copy.CopyPaths(paths, src.Path, dest.Path, copy.Options{Update: seedUpdate, Overwrite: seedForce})
+	if err != nil {
+		return fmt.Errorf("failed to copy files: %w", err)
+	}
+	if len(copied) == 0 {
+		log.Infof("Nothing copied: every path already exists (--update or --force replaces them).")
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/du"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/styles"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Report how much disk space each worktree takes up",
+	Long: `Measure every worktree directory, largest first, with the files that
+copy_patterns copied into it (node_modules, vendor, ...) broken out, and
+totals at the end. Worktrees nested inside the main one don't count towards
+it. Reflinked copies are counted in full, though they may share blocks.
+
+Nothing is deleted: wt rm, wt prune --stale and wt prune --gone free the
+space.`,
+	Args: cobra.NoArgs,
+	RunE: runGC,
+}
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+}
+
+// sizeWorkers bounds how many directory trees are walked at once.
+const sizeWorkers = 4
+
+// collectSizes measures the worktrees concurrently, returning their disk
+// usage by path. Each leaves out the worktrees nested inside it; ones that
+// can't be measured are missing from the result.
+func collectSizes(worktrees []git.Worktree) map[string]int64 {
+	paths := make(map[string]bool, len(worktrees))
+	for _, wt := range worktrees {
+		paths[wt.Path] = true
+	}
+	sizes := make(map[string]int64, len(worktrees))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, sizeWorkers)
+	for _, wt := range worktrees {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			size, err := du.Size(wt.Path, paths)
+			if err != nil {
+				log.Debugf("size of %s: %v", wt.Path, err)
+				return
+			}
+			mu.Lock()
+			sizes[wt.Path] = size
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return sizes
+}
+
+func runGC(cmd *cobra.Command, args []string) error {
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	records := loadRecords()
+	sizes := collectSizes(worktrees)
+	sort.SliceStable(worktrees, func(i, j int) bool {
+		return sizes[worktrees[i].Path] > sizes[worktrees[j].Path]
+	})
+
+	homeDir, _ := os.UserHomeDir()
+	width := 0
+	for _, wt := range worktrees {
+		width = max(width, len(wt.Label()))
+	}
+	var total, copied int64
+	for _, wt := range worktrees {
+		size, ok := sizes[wt.Path]
+		if !ok {
+			continue
+		}
+		total += size
+		fmt.Printf("%7s  %-*s  %s\n", du.Format(size), width, wt.Label(), styles.DimStyle.Render(shortenHome(wt.Path, homeDir)))
+
+		type entry struct {
+			path string
+			size int64
+		}
+		var entries []entry
+		for _, path := range records.Worktrees[wt.Path].Copied {
+			if n, err := du.Size(filepath.Join(wt.Path, path), nil); err == nil {
+				entries = append(entries, entry{path, n})
+				copied += n
+			}
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].size > entries[j].size })
+		for _, e := range entries {
+			fmt.Printf("%7s  %s\n", "", styles.DimStyle.Render(fmt.Sprintf("%s copied: %s", du.Format(e.size), e.path)))
+		}
+	}
+	fmt.Printf("%7s  total in %d worktree(s)", du.Format(total), len(sizes))
+	if copied > 0 {
+		fmt.Printf(", %s of it copied by copy_patterns", du.Format(copied))
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/tui"
+)
+
+var switchBranchCmd = &cobra.Command{
+	Use:   "switch-branch <branch> [path|branch]",
+	Short: "Check out another branch in an existing worktree",
+	Long: `Check out another branch in an existing worktree (default: the current
+one), reusing its build caches and installed dependencies instead of creating
+a fresh worktree. The worktree keeps its directory name.
+
+A branch that exists neither locally nor on a remote is created from the base
+branch, once its name passes [branch_rules] (--no-verify skips them).
+Switching is refused if the branch is checked out in another worktree.
+Uncommitted changes are stashed after confirmation (or right away with
+--stash). A TTL set with wt add --ttl moves to the new branch.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runSwitchBranch,
+}
+
+var (
+	switchStash    bool
+	switchBase     string
+	switchNoVerify bool
+)
+
+func init() {
+	switchBranchCmd.Flags().BoolVar(&switchStash, "stash", false, "Stash uncommitted changes without asking")
+	switchBranchCmd.Flags().StringVarP(&switchBase, "base", "b", "", "Base branch for a new branch (overrides config)")
+	switchBranchCmd.Flags().BoolVar(&switchNoVerify, "no-verify", false, "Skip the [branch_rules] naming checks for a new branch")
+
+	rootCmd.AddCommand(switchBranchCmd)
+}
+
+func runSwitchBranch(cmd *cobra.Command, args []string) error {
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	target, err := targetWorktree(worktrees, args[1:])
+	if err != nil {
+		return err
+	}
+	if target.Bare {
+		return fmt.Errorf("%s is a bare repository and has no working tree", target.Path)
+	}
+
+	branch := args[0]
+	if target.Branch == branch {
+		log.Infof("%s is already on %s", target.Path, branch)
+		return nil
+	}
+	for _, wt := range worktrees {
+		if wt.Branch == branch {
+			return fmt.Errorf("branch %q is already checked out at %s", branch, wt.Path)
+		}
+	}
+
+	if local, remote := git.BranchExists(branch); !local && !remote && !switchNoVerify {
+		if err := checkBranchRules(cfg.BranchRules, branch); err != nil {
+			return err
+		}
+	}
+
+	status, err := git.GetStatus(target.Path)
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		if !switchStash {
+			fmt.Fprintf(os.Stderr, "Worktree '%s' contains modified or untracked files.\n", target.Path)
+			confirmed, err := tui.Confirm("Stash them and switch?")
+			if err != nil {
+				return fmt.Errorf("%s has uncommitted changes (use --stash to stash them): %w", target.Path, err)
+			}
+			if !confirmed {
+				return quietExit(cmd, errCancelled)
+			}
+		}
+		from := target.Branch
+		if from == "" {
+			from = "detached HEAD"
+		}
+		if err := git.Stash(target.Path, "wt switch-branch: changes on "+from); err != nil {
+			return err
+		}
+		log.Infof("Stashed changes from %s (see git stash list)", from)
+	}
+
+	baseBranch := cfg.BaseBranch
+	if switchBase != "" {
+		baseBranch = switchBase
+	}
+	log.Infof("Switching %s to %s", target.Path, branch)
+	// A --ttl expiry is recorded for the worktree's path, so it stays with
+	// the worktree on its new branch.
+	return git.SwitchBranch(target.Path, branch, baseBranch)
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/styles"
+	"github.com/default-anton/wt/internal/tui"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync [path|branch]...",
+	Short: "Update worktrees with the latest commits of their base branch",
+	Long: `Fetch the base branch of each selected worktree from origin, then bring the
+worktree's branch up to date with it: rebase (default), merge, or ff-only,
+from sync_strategy or --strategy.
+
+Worktrees are picked in a selector unless given as arguments or with --all.
+Each one syncs with the base branch it was created from (base_branch when
+wt has no record of it); --base overrides both. A worktree on the base
+branch itself is only fast-forwarded.
+
+Worktrees with uncommitted changes are skipped with a warning. A rebase or
+merge that runs into conflicts is aborted, leaving that worktree as it was,
+and the conflicting files are listed in the summary at the end.
+
+git never waits for an editor, so wt sync is safe to run from scripts. With
+--interactive it rebases with git rebase -i, or lets you edit the merge
+message, in your editor (GIT_SEQUENCE_EDITOR, GIT_EDITOR, core.editor, ...)
+on the terminal, even when the shell integration captures wt's output.`,
+	RunE: runSync,
+}
+
+var (
+	syncAll         bool
+	syncStrategy    string
+	syncBase        string
+	syncInteractive bool
+)
+
+func init() {
+	syncCmd.Flags().BoolVarP(&syncAll, "all", "a", false, "Sync every worktree that is on a branch")
+	syncCmd.Flags().StringVar(&syncStrategy, "strategy", "", "How to update branches: rebase, merge, or ff-only (overrides sync_strategy)")
+	syncCmd.Flags().StringVar(&syncBase, "base", "", "Sync with this branch instead of each worktree's base branch")
+	syncCmd.Flags().BoolVarP(&syncInteractive, "interactive", "i", false, "Rebase interactively, or edit merge messages, in your editor")
+	rootCmd.AddCommand(syncCmd)
+}
+
+// syncOutcome is how syncing one worktree ended.
+type syncOutcome int
+
+const (
+	syncUpdated syncOutcome = iota
+	syncUpToDate
+	syncSkipped
+	syncFailed
+)
+
+type syncResult struct {
+	name    string
+	outcome syncOutcome
+	detail  string
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+	name := syncStrategy
+	if name == "" {
+		name = cfg.SyncStrategy
+	}
+	strategy, err := git.ParseSyncStrategy(name)
+	if err != nil {
+		return err
+	}
+	if syncAll && len(args) > 0 {
+		return fmt.Errorf("--all cannot be combined with worktree arguments")
+	}
+
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	selected, err := selectSyncWorktrees(cmd, cfg, worktrees, args)
+	if err != nil {
+		return err
+	}
+
+	records := loadRecords()
+	bases := make(map[string]string, len(selected))
+	for _, wt := range selected {
+		base := syncBase
+		if base == "" {
+			base = worktreeBase(cfg, records, wt.Path)
+		}
+		bases[wt.Path] = base
+	}
+
+	// Fetch each base branch once, then resolve what to sync with. A base
+	// that can't be fetched or found fails only the worktrees on it.
+	hasOrigin := git.HasRemote("origin")
+	refs := make(map[string]string)
+	failures := make(map[string]string)
+	for _, wt := range selected {
+		base := bases[wt.Path]
+		if _, done := refs[base]; done {
+			continue
+		}
+		if _, failed := failures[base]; failed {
+			continue
+		}
+		ref := base
+		if hasOrigin {
+			branch := git.RemoteBranch(base)
+			log.Infof("Fetching %s...", branch)
+			if err := git.Fetch("origin", branch); err != nil {
+				failures[base] = err.Error()
+				continue
+			}
+			ref = baseRef(branch)
+		}
+		if !git.RefExists(ref) {
+			failures[base] = fmt.Sprintf("base branch %s not found", base)
+			continue
+		}
+		refs[base] = ref
+	}
+
+	statuses := git.StatusAll(context.Background(), worktreePaths(selected), git.DirtyFull, nil)
+	var results []syncResult
+	for _, wt := range selected {
+		if failure, failed := failures[bases[wt.Path]]; failed {
+			results = append(results, syncResult{name: wt.Label(), outcome: syncFailed, detail: failure})
+			continue
+		}
+		status, ok := statuses[wt.Path]
+		if !ok {
+			results = append(results, syncResult{name: wt.Label(), outcome: syncFailed, detail: "failed to get its status"})
+			continue
+		}
+		results = append(results, syncWorktree(wt, status, bases[wt.Path], refs[bases[wt.Path]], strategy))
+	}
+	printSyncSummary(results)
+
+	failed := 0
+	for _, r := range results {
+		if r.outcome == syncFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d worktree(s) could not be synced", failed)
+	}
+	return nil
+}
+
+// selectSyncWorktrees returns the worktrees named by args, every worktree on
+// a branch with --all, or else those picked in a selector.
+func selectSyncWorktrees(cmd *cobra.Command, cfg *config.Config, worktrees []git.Worktree, args []string) ([]git.Worktree, error) {
+	if len(args) > 0 {
+		var selected []git.Worktree
+		for _, arg := range args {
+			wt, ok := git.FindWorktree(worktrees, arg)
+			if !ok {
+				return nil, fmt.Errorf("no worktree found for %q", arg)
+			}
+			if wt.Branch == "" {
+				return nil, fmt.Errorf("%s is not on a branch", wt.Path)
+			}
+			selected = append(selected, *wt)
+		}
+		return selected, nil
+	}
+
+	var candidates []git.Worktree
+	for _, wt := range worktrees {
+		if !wt.Bare && wt.Branch != "" {
+			candidates = append(candidates, wt)
+		}
+	}
+	if len(candidates) == 0 {
+		fmt.Fprintln(os.Stderr, "No worktrees on a branch to sync.")
+		return nil, quietExit(cmd, errNoWorktrees)
+	}
+	if syncAll {
+		return candidates, nil
+	}
+
+	items := make([]tui.Item, len(candidates))
+	for i, wt := range candidates {
+		items[i] = tui.Item{Label: wt.Label(), Value: wt.Path, Path: wt.Path}
+	}
+	updates, _ := collectStatuses(worktreePaths(candidates), dirtyCheck(cfg))
+	paths, err := tui.MultiSelectWith(items, tui.Options{Updates: updates})
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, quietExit(cmd, errCancelled)
+	}
+	var selected []git.Worktree
+	for _, path := range paths {
+		wt, _ := git.FindWorktree(candidates, path)
+		selected = append(selected, *wt)
+	}
+	return selected, nil
+}
+
+// syncWorktree brings wt, whose status is given, up to date with ref, the
+// fetched base branch.
+func syncWorktree(wt git.Worktree, status git.Status, base, ref string, strategy git.SyncStrategy) syncResult {
+	result := syncResult{name: wt.Label()}
+	if wt.Branch == base {
+		if ref == base {
+			result.outcome, result.detail = syncSkipped, "is the base branch"
+			return result
+		}
+		strategy = git.SyncFastForward
+	}
+
+	if status.Dirty {
+		fmt.Fprintf(os.Stderr, "Warning: skipping %s: it has uncommitted changes\n", wt.Path)
+		result.outcome, result.detail = syncSkipped, "uncommitted changes"
+		return result
+	}
+
+	_, behind, err := git.AheadBehind(wt.Path, ref)
+	if err != nil {
+		result.outcome, result.detail = syncFailed, err.Error()
+		return result
+	}
+	if behind == 0 {
+		result.outcome, result.detail = syncUpToDate, "up to date with "+ref
+		return result
+	}
+
+	log.Infof("Syncing %s with %s (%s)...", wt.Label(), ref, strategy)
+	err = git.Integrate(wt.Path, ref, strategy, syncInteractive)
+	var conflict *git.ConflictError
+	switch {
+	case err == nil:
+		verb := map[git.SyncStrategy]string{
+			git.SyncRebase:      "rebased onto",
+			git.SyncMerge:       "merged",
+			git.SyncFastForward: "fast-forwarded to",
+		}[strategy]
+		result.outcome, result.detail = syncUpdated, verb+" "+ref
+	case errors.As(err, &conflict):
+		result.outcome, result.detail = syncFailed, conflict.Error()+"; left as it was"
+	case errors.Is(err, git.ErrNotFastForward):
+		result.outcome, result.detail = syncFailed, "has commits of its own; not a fast-forward"
+	default:
+		result.outcome, result.detail = syncFailed, err.Error()
+	}
+	return result
+}
+
+// printSyncSummary lists how syncing each worktree ended, in the style of
+// printHookSummary.
+func printSyncSummary(results []syncResult) {
+	if len(results) == 0 {
+		return
+	}
+	width := 0
+	for _, r := range results {
+		width = max(width, len(r.name))
+	}
+	log.Infof("Sync:")
+	for _, r := range results {
+		var mark string
+		switch r.outcome {
+		case syncUpdated:
+			mark = styles.MatchStyle.Render("✓")
+		case syncUpToDate:
+			mark = styles.DimStyle.Render("=")
+		case syncFailed:
+			mark = styles.DirtyStyle.Render("✗")
+		default:
+			mark = styles.DimStyle.Render("-")
+		}
+		log.Infof("  %s %-*s  %s", mark, width, r.name, styles.DimStyle.Render(r.detail))
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/trust"
+	"github.com/default-anton/wt/internal/tui"
+)
+
+var trustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Allow the commands in this repository's .wt.toml to run",
+	Long: `Review and allow the commands (preprocess_script, preprocess_command,
+pre_add_hooks, post_hooks, post_switch_hooks, hook_profiles, profiles, and
+direnv allow when direnv = true) in this repository's .wt.toml and the files
+it includes.
+
+wt asks before running them for the first time, and again after every
+change to them. Trust is kept in $XDG_STATE_HOME/wt/trust.json
+(default ~/.local/state/wt/trust.json). wt trust allows the current version
+without asking, e.g. in scripts; --revoke forgets it. WT_TRUST_ALL=1 skips
+the check entirely, for CI and containers.`,
+	Args: cobra.NoArgs,
+	RunE: runTrust,
+}
+
+var trustRevoke bool
+
+func init() {
+	trustCmd.Flags().BoolVar(&trustRevoke, "revoke", false, "Forget the trust given to this repository's .wt.toml")
+	rootCmd.AddCommand(trustCmd)
+}
+
+// repoCommands reads the repository's own .wt.toml and lists the commands
+// it would run. The global config is the user's own and needs no trust, but
+// the files .wt.toml includes and its preprocess_script files are part of
+// it: their contents are appended to data, so changing one asks for trust
+// again.
+func repoCommands(repoRoot string) (path string, data []byte, commands []string, err error) {
+	path = filepath.Join(repoRoot, config.ConfigFileName)
+	data, err = os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return path, nil, nil, nil
+	}
+	if err != nil {
+		return path, nil, nil, err
+	}
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		return path, nil, nil, err
+	}
+	layers, err := config.Layers(path)
+	if err != nil {
+		return path, nil, nil, err
+	}
+	for _, layer := range layers[:len(layers)-1] {
+		included, err := os.ReadFile(layer)
+		if err != nil {
+			return path, nil, nil, err
+		}
+		data = append(data, "\x00"+layer+"\x00"...)
+		data = append(data, included...)
+	}
+	for _, script := range cfg.PreprocessScript {
+		if !filepath.IsAbs(script) {
+			script = filepath.Join(repoRoot, script)
+		}
+		contents, err := os.ReadFile(script)
+		if errors.Is(err, os.ErrNotExist) {
+			// preprocess fails on it before running anything.
+			continue
+		}
+		if err != nil {
+			return path, nil, nil, err
+		}
+		data = append(data, "\x00"+script+"\x00"...)
+		data = append(data, contents...)
+	}
+	return path, data, trust.Commands(cfg), nil
+}
+
+func trustStore() (*trust.Store, error) {
+	path, err := trust.Path()
+	if err != nil {
+		return nil, err
+	}
+	return &trust.Store{Path: path}, nil
+}
+
+// ensureTrusted asks before the commands in the repository's .wt.toml run
+// for the first time, and again whenever the file changes.
+func ensureTrusted(repoRoot string) error {
+	if all, _ := strconv.ParseBool(os.Getenv("WT_TRUST_ALL")); all {
+		return nil
+	}
+	path, data, commands, err := repoCommands(repoRoot)
+	if err != nil || len(commands) == 0 {
+		return err
+	}
+	store, err := trustStore()
+	if err != nil {
+		return err
+	}
+	hash := trust.Hash(data)
+	if ok, err := store.Trusted(repoRoot, hash); err != nil || ok {
+		return err
+	}
+
+	printCommands(path, commands)
+	allowed, err := tui.Confirm("Trust these commands?")
+	if err != nil {
+		return fmt.Errorf("%s is not trusted yet (review it and run wt trust): %w", path, err)
+	}
+	if !allowed {
+		return fmt.Errorf("%s is not trusted (review it and run wt trust to allow it)", path)
+	}
+	return store.Allow(repoRoot, hash)
+}
+
+func printCommands(path string, commands []string) {
+	fmt.Fprintf(os.Stderr, "%s runs these commands:\n", path)
+	for _, command := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n", command)
+	}
+}
+
+func runTrust(cmd *cobra.Command, args []string) error {
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		return err
+	}
+	store, err := trustStore()
+	if err != nil {
+		return err
+	}
+	if trustRevoke {
+		revoked, err := store.Revoke(repoRoot)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			log.Infof("Revoked trust for %s", repoRoot)
+		} else {
+			log.Infof("%s was not trusted", repoRoot)
+		}
+		return nil
+	}
+
+	path, data, commands, err := repoCommands(repoRoot)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return fmt.Errorf("%s does not exist", path)
+	}
+	if len(commands) > 0 {
+		printCommands(path, commands)
+	}
+	if err := store.Allow(repoRoot, trust.Hash(data)); err != nil {
+		return err
+	}
+	log.Infof("Trusted %s", path)
+	return nil
+}
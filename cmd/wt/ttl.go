@@ -0,0 +1,22 @@
+package main
+
+import (
+	"time"
+
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/state"
+)
+
+// expiredWorktrees returns the paths of worktrees whose TTL has passed. The
+// expiry is in the local records, keyed by path: it belongs to this
+// worktree, not to every clone's worktree of the same branch.
+func expiredWorktrees(records state.Records, worktrees []git.Worktree) map[string]bool {
+	now := time.Now()
+	expired := make(map[string]bool)
+	for _, wt := range worktrees {
+		if !wt.IsMain && records.Worktrees[wt.Path].Expired(now) {
+			expired[wt.Path] = true
+		}
+	}
+	return expired
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+
+	"github.com/default-anton/wt/internal/config"
+)
+
+var whichHookCmd = &cobra.Command{
+	Use:   "which-hook [name|key|pattern]",
+	Short: "Show which config file defined a hook, setting, or copy pattern",
+	Long: `Show the effective configuration with the file and line each setting and
+hook comes from, after layering the global config and the repository's
+.wt.toml ("default" marks built-in values).
+
+With an argument, only the matching hooks (by name), setting (e.g.
+base_branch, packages.api), or copy pattern (e.g. ".env*") are shown.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runWhichHook,
+}
+
+func init() {
+	rootCmd.AddCommand(whichHookCmd)
+}
+
+// sourcedHook is an effective hook with its position in the config.
+type sourcedHook struct {
+	key  string // e.g. "post_hooks[1]"
+	hook config.Hook
+}
+
+func runWhichHook(cmd *cobra.Command, args []string) error {
+	cfg, err := loadRepoConfig()
+	if err != nil {
+		return err
+	}
+	origins, err := config.LoadOrigins(cfg.Sources)
+	if err != nil {
+		return err
+	}
+	values, err := cfg.Values()
+	if err != nil {
+		return err
+	}
+
+	var hooks []sourcedHook
+	addHooks := func(section string, list []config.Hook) {
+		for i, h := range list {
+			hooks = append(hooks, sourcedHook{key: fmt.Sprintf("%s[%d]", section, i), hook: h})
+		}
+	}
+	addHooks("pre_add_hooks", cfg.PreAddHooks)
+	addHooks("post_hooks", cfg.PostHooks)
+	addHooks("post_switch_hooks", cfg.PostSwitchHooks)
+	for _, name := range hookProfileNames(cfg) {
+		addHooks("hook_profiles."+name, cfg.HookProfiles[name])
+	}
+	for _, name := range packageNames(cfg) {
+		addHooks("packages."+name+".post_hooks", cfg.Packages[name].PostHooks)
+	}
+	for _, name := range profileNames(cfg) {
+		addHooks("profiles."+name+".post_hooks", cfg.Profiles[name].PostHooks)
+	}
+
+	if len(args) == 0 {
+		for _, source := range cfg.Sources {
+			fmt.Printf("# Loaded: %s\n", source)
+		}
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			if key != "pre_add_hooks" && key != "post_hooks" && key != "post_switch_hooks" && key != "hook_profiles" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			printSetting(key, values[key], origins)
+		}
+		for _, h := range hooks {
+			printHook(h, origins)
+		}
+		return nil
+	}
+
+	query := args[0]
+	found := false
+	for _, h := range hooks {
+		if strings.EqualFold(h.hook.Name, query) {
+			printHook(h, origins)
+			found = true
+		}
+	}
+	if value, ok := lookupValue(values, query); ok {
+		printSetting(query, value, origins)
+		found = true
+	}
+	patterns := map[string][]string{"copy_patterns": cfg.CopyPatterns}
+	for name, pkg := range cfg.Packages {
+		patterns["packages."+name+".copy_patterns"] = pkg.CopyPatterns
+	}
+	for name, profile := range cfg.Profiles {
+		patterns["profiles."+name+".copy_patterns"] = profile.CopyPatterns
+	}
+	for key, list := range patterns {
+		for _, p := range list {
+			if p == query {
+				fmt.Printf("%s contains %q\t(%s)\n", key, p, origins.Lookup(key))
+				found = true
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("no hook, setting, or copy pattern named %q", query)
+	}
+	return nil
+}
+
+// lookupValue finds a dotted key such as "packages.api.path" in values.
+func lookupValue(values map[string]any, key string) (any, bool) {
+	var current any = values
+	for _, part := range strings.Split(key, ".") {
+		table, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = table[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// printSetting prints key and its origin; tables are listed entry by entry.
+// Hooks inside tables are left to printHook.
+func printSetting(key string, value any, origins config.Origins) {
+	if strings.HasSuffix(key, ".post_hooks") {
+		return
+	}
+	table, ok := value.(map[string]any)
+	if !ok {
+		fmt.Printf("%s = %s\t(%s)\n", key, formatValue(value), origins.Lookup(key))
+		return
+	}
+	names := make([]string, 0, len(table))
+	for name := range table {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		printSetting(key+"."+name, table[name], origins)
+	}
+}
+
+func printHook(h sourcedHook, origins config.Origins) {
+	fmt.Printf("%s %q: %s\t(%s)\n", h.key, h.hook.Name, h.hook.Run, origins.Lookup(h.key))
+	for _, guard := range []struct{ name, value string }{
+		{"if_exists", h.hook.IfExists},
+		{"if_branch", h.hook.IfBranch},
+		{"if_changed", h.hook.IfChanged},
+	} {
+		if guard.value != "" {
+			fmt.Printf("  %s = %q\n", guard.name, guard.value)
+		}
+	}
+}
+
+// formatValue renders a value as a one-line TOML literal.
+func formatValue(value any) string {
+	var b strings.Builder
+	if err := toml.NewEncoder(&b).Encode(map[string]any{"v": value}); err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(b.String(), "v = "))
+}
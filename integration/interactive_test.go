@@ -154,6 +154,8 @@ func TestCdTmuxUsesNewWindow(t *testing.T) {
 	argsFile := filepath.Join(tmpDir, "tmux-args")
 	fakeTmuxPath := filepath.Join(fakeBin, "tmux")
 	fakeTmux := "#!/bin/sh\n" +
+		"[ \"$1\" = list-panes ] && exit 0\n" +
+		"[ \"$1\" = select-pane ] && exit 0\n" +
 		"echo \"$@\" > \"$TMUX_ARGS_FILE\"\n"
 	if err := os.WriteFile(fakeTmuxPath, []byte(fakeTmux), 0755); err != nil {
 		t.Fatalf("write fake tmux: %v", err)
@@ -182,10 +184,12 @@ func TestCdTmuxUsesNewWindow(t *testing.T) {
 		t.Fatalf("read tmux args: %v", err)
 	}
 
-	want := fmt.Sprintf("new-window -c %s", worktreePath)
 	got := strings.TrimSpace(string(args))
-	if got != want {
-		t.Fatalf("expected tmux args %q, got %q", want, got)
+	if !strings.HasPrefix(got, "new-window ") || !strings.HasSuffix(got, "-c "+worktreePath) {
+		t.Fatalf("expected tmux new-window in %q, got %q", worktreePath, got)
+	}
+	if want := fmt.Sprintf("-e WT_WORKTREE_PATH=%s", worktreePath); !strings.Contains(got, want) {
+		t.Fatalf("expected tmux args to contain %q, got %q", want, got)
 	}
 	if worktreePath == "" {
 		t.Fatalf("expected worktree path to be set")
@@ -353,6 +357,7 @@ func buildEnv(extra map[string]string) []string {
 	}
 	env["GIT_CONFIG_NOSYSTEM"] = "1"
 	env["GIT_CONFIG_GLOBAL"] = os.DevNull
+	env["WT_TRUST_ALL"] = "1"
 	for key, value := range extra {
 		env[key] = value
 	}
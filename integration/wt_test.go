@@ -51,6 +51,8 @@ func TestScripts(t *testing.T) {
 			env.Setenv("HOME", home)
 			env.Setenv("GIT_CONFIG_NOSYSTEM", "1")
 			env.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+			// Scripts trust their own .wt.toml; trust.txtar turns the check back on.
+			env.Setenv("WT_TRUST_ALL", "1")
 			return nil
 		},
 	})
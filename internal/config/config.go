@@ -1,64 +1,809 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/BurntSushi/toml"
+	"github.com/bmatcuk/doublestar/v4"
+
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/preprocess"
+	"github.com/default-anton/wt/internal/state"
+	"github.com/default-anton/wt/internal/styles"
+	"github.com/default-anton/wt/internal/term"
+	"github.com/default-anton/wt/internal/tmpl"
+	"github.com/default-anton/wt/internal/tui"
 )
 
 const ConfigFileName = ".wt.toml"
 
 type Hook struct {
-	Name     string `toml:"name"`
-	Run      string `toml:"run"`
-	IfExists string `toml:"if_exists,omitempty"`
+	Use       string `toml:"use,omitempty"` // a HookPresets name filling in the fields left empty
+	Name      string `toml:"name"`
+	Run       string `toml:"run"`
+	Dir       string `toml:"dir,omitempty"` // relative to the worktree, or RepoRootDir[/sub]
+	IfExists  string `toml:"if_exists,omitempty"`
+	IfBranch  string `toml:"if_branch,omitempty"`
+	IfChanged string `toml:"if_changed,omitempty"`
+}
+
+// Profile replaces parts of the setup for worktrees created with
+// `wt add --profile <name>`. Keys it leaves out keep the top-level settings.
+type Profile struct {
+	CopyPatterns []string `toml:"copy_patterns"`
+	PostHooks    []Hook   `toml:"post_hooks"`
+	SparsePaths  []string `toml:"sparse_paths"`
+}
+
+// WithProfile returns a copy of the config with the keys profile sets in
+// place of the top-level ones.
+func (c *Config) WithProfile(profile Profile) *Config {
+	out := *c
+	if profile.CopyPatterns != nil {
+		out.CopyPatterns = profile.CopyPatterns
+	}
+	if profile.PostHooks != nil {
+		out.PostHooks = profile.PostHooks
+	}
+	if profile.SparsePaths != nil {
+		out.SparsePaths = profile.SparsePaths
+	}
+	return &out
+}
+
+// Package scopes setup to one part of a monorepo. Copy patterns are relative to
+// Path, and hooks run in Path inside the new worktree.
+type Package struct {
+	Path         string   `toml:"path"`
+	CopyPatterns []string `toml:"copy_patterns"`
+	PostHooks    []Hook   `toml:"post_hooks"`
+}
+
+// Remote is another machine, reachable over SSH, with a clone of the
+// repository (used by `wt cd --remote`).
+type Remote struct {
+	Host string `toml:"host"` // anything ssh accepts: host, user@host, or a ~/.ssh/config alias
+	Path string `toml:"path"` // repository path on the remote machine
+	Wt   string `toml:"wt"`   // wt executable on the remote (default: wt)
+}
+
+// Sanitize tunes how branch names become worktree directory names.
+type Sanitize struct {
+	MaxLength   int    `toml:"max_length"`
+	Replacement string `toml:"replacement"`
+	Lowercase   bool   `toml:"lowercase"`
+	Strip       string `toml:"strip"`
+}
+
+// Maintenance keeps the object store shared by all worktrees healthy as
+// branches come and go.
+type Maintenance struct {
+	Register        bool `toml:"register"`          // run git maintenance start once for the repository
+	GCAfterRemovals int  `toml:"gc_after_removals"` // run git gc --auto after removing this many worktrees at once (0: never)
+}
+
+// BranchRules is a naming policy for the branches wt add and
+// wt switch-branch create. Patterns are Go regular expressions, matched
+// anywhere in the name unless anchored.
+type BranchRules struct {
+	Allow     []string `toml:"allow"`      // the name must match at least one (none: anything goes)
+	Deny      []string `toml:"deny"`       // the name must match none
+	MaxLength int      `toml:"max_length"` // 0: no limit
+	Ticket    string   `toml:"ticket"`     // the name must contain a match, e.g. "[A-Z]+-[0-9]+"
+}
+
+// Check returns an error describing the first rule branch breaks. The
+// patterns must have passed Validate.
+func (r BranchRules) Check(branch string) error {
+	if r.MaxLength > 0 && len(branch) > r.MaxLength {
+		return fmt.Errorf("branch %q is %d characters long; branch_rules.max_length is %d", branch, len(branch), r.MaxLength)
+	}
+	if r.Ticket != "" && !regexp.MustCompile(r.Ticket).MatchString(branch) {
+		return fmt.Errorf("branch %q has no ticket matching branch_rules.ticket %q", branch, r.Ticket)
+	}
+	for _, pattern := range r.Deny {
+		if regexp.MustCompile(pattern).MatchString(branch) {
+			return fmt.Errorf("branch %q matches branch_rules.deny pattern %q", branch, pattern)
+		}
+	}
+	for _, pattern := range r.Allow {
+		if regexp.MustCompile(pattern).MatchString(branch) {
+			return nil
+		}
+	}
+	if len(r.Allow) > 0 {
+		return fmt.Errorf("branch %q matches none of the branch_rules.allow patterns (%s)", branch, strings.Join(r.Allow, ", "))
+	}
+	return nil
+}
+
+func (r BranchRules) validate() error {
+	if r.MaxLength < 0 {
+		return fmt.Errorf("branch_rules.max_length must not be negative")
+	}
+	patterns := map[string][]string{"allow": r.Allow, "deny": r.Deny, "ticket": {r.Ticket}}
+	for _, key := range []string{"allow", "deny", "ticket"} {
+		for _, pattern := range patterns[key] {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("branch_rules.%s: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Steps is a pipeline of preprocessing steps. It can be written as a single
+// string or as an array of strings.
+type Steps []string
+
+func (s *Steps) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		*s = Steps{v}
+	case []any:
+		steps := make(Steps, len(v))
+		for i, step := range v {
+			str, ok := step.(string)
+			if !ok {
+				return fmt.Errorf("expected a string or an array of strings, got %v", v)
+			}
+			steps[i] = str
+		}
+		*s = steps
+	default:
+		return fmt.Errorf("expected a string or an array of strings, got %T", v)
+	}
+	return nil
 }
 
 type Config struct {
-	BaseBranch       string   `toml:"base_branch"`
-	WorktreeDir      string   `toml:"worktree_dir"`
-	PreprocessScript string   `toml:"preprocess_script"`
-	CopyPatterns     []string `toml:"copy_patterns"`
-	PostHooks        []Hook   `toml:"post_hooks"`
+	// Include lists config files applied underneath this one; see Layers.
+	Include []string `toml:"include"`
+
+	BaseBranch        string   `toml:"base_branch"`
+	WorktreeDir       string   `toml:"worktree_dir"`
+	DirTemplate       string   `toml:"dir_template"`
+	Sanitize          Sanitize `toml:"sanitize,omitempty"`
+	Preprocess        string   `toml:"preprocess"`
+	PreprocessMatch   string   `toml:"preprocess_match"`
+	PreprocessReplace string   `toml:"preprocess_replace"`
+	PreprocessScript  Steps    `toml:"preprocess_script"`
+	PreprocessCommand Steps    `toml:"preprocess_command"`
+	TemplateDir       string   `toml:"template_dir"`
+	Submodules        string   `toml:"submodules"`
+	LFS               string   `toml:"lfs"`
+	SparsePaths       []string `toml:"sparse_paths"`
+	CopyPatterns      []string `toml:"copy_patterns"`
+	EnvFiles          []string `toml:"env_files"`
+	PreAddHooks       []Hook   `toml:"pre_add_hooks"`
+	PostHooks         []Hook   `toml:"post_hooks"`
+	PostSwitchHooks   []Hook   `toml:"post_switch_hooks"`
+	TmuxMode          string   `toml:"tmux_mode"`
+	TmuxName          string   `toml:"tmux_name"`
+	ZellijMode        string   `toml:"zellij_mode"`
+	Terminal          string   `toml:"terminal"`
+	TerminalMode      string   `toml:"terminal_mode"`
+	VerifyIdentity    bool     `toml:"verify_identity"`
+	Direnv            bool     `toml:"direnv"`
+	StateBackend      string   `toml:"state_backend"`
+	StateRemote       string   `toml:"state_remote"`
+	Theme             string   `toml:"theme"`
+	ReducedMotion     bool     `toml:"reduced_motion"`
+	SelectorView      string   `toml:"selector_view"`
+	CdIncludeMain     bool     `toml:"cd_include_main"`
+	Finder            string   `toml:"finder"`
+	HookOutput        string   `toml:"hook_output"`
+	SyncStrategy      string   `toml:"sync_strategy"`
+	DirtyCheck        string   `toml:"dirty_check"`
+	MaxAge            string   `toml:"max_age"`
+	GitBackend        string   `toml:"git_backend"`
+
+	Maintenance Maintenance `toml:"maintenance,omitempty"`
+	BranchRules BranchRules `toml:"branch_rules,omitempty"`
+
+	SparseProfiles map[string][]string `toml:"sparse_profiles"`
+	HookProfiles   map[string][]Hook   `toml:"hook_profiles"`
+	Packages       map[string]Package  `toml:"packages"`
+	Profiles       map[string]Profile  `toml:"profiles"`
+	Remotes        map[string]Remote   `toml:"remotes"`
+
+	// Sources lists the config files applied, in order (global first, then repo).
+	Sources []string `toml:"-"`
 }
 
 func DefaultConfig() *Config {
 	return &Config{
-		BaseBranch:   "main",
-		WorktreeDir:  ".worktrees",
-		CopyPatterns: []string{},
-		PostHooks:    []Hook{},
+		BaseBranch:      "main",
+		WorktreeDir:     ".worktrees",
+		CopyPatterns:    []string{},
+		PostHooks:       []Hook{},
+		PostSwitchHooks: []Hook{},
 	}
 }
 
-// Load finds and parses .wt.toml from the current directory or parent directories.
-// Returns default config if no config file is found.
+// GlobalConfigPath returns the path of the user-wide config file:
+// $XDG_CONFIG_HOME/wt/config.toml, defaulting to ~/.config/wt/config.toml.
+func GlobalConfigPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "wt", "config.toml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "wt", "config.toml"), nil
+}
+
+// Load finds and parses .wt.toml from the current directory or parent directories,
+// layered on top of the global config. Returns default config if no config file is found.
 func Load() (*Config, error) {
 	configPath, err := findConfig()
 	if err != nil {
-		return DefaultConfig(), nil
+		return loadLayers()
 	}
-	return loadFromPath(configPath)
+	return loadLayers(configPath)
 }
 
-// LoadFromDir loads config from a specific directory.
+// LoadFromDir loads the global config followed by the config in a specific directory.
+// Keys set in the repo config override the global ones.
 func LoadFromDir(dir string) (*Config, error) {
-	configPath := filepath.Join(dir, ConfigFileName)
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return DefaultConfig(), nil
+	return loadLayers(filepath.Join(dir, ConfigFileName))
+}
+
+// LoadFile parses a single config file on its own, without the defaults or
+// the global config underneath.
+func LoadFile(path string) (*Config, error) {
+	layers, err := Layers(path)
+	if err != nil {
+		return nil, err
 	}
-	return loadFromPath(configPath)
+	cfg := &Config{}
+	for _, layer := range layers {
+		if err := decodeFileStrict(layer, cfg); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.expandPresets(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if err := cfg.expandVars(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
 }
 
-func loadFromPath(path string) (*Config, error) {
+func loadLayers(paths ...string) (*Config, error) {
 	cfg := DefaultConfig()
-	if _, err := toml.DecodeFile(path, cfg); err != nil {
-		return nil, err
+
+	if globalPath, err := GlobalConfigPath(); err == nil {
+		paths = append([]string{globalPath}, paths...)
+	}
+
+	for _, path := range paths {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		layers, err := Layers(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, layer := range layers {
+			if err := decodeFileStrict(layer, cfg); err != nil {
+				return nil, err
+			}
+			cfg.Sources = append(cfg.Sources, layer)
+		}
+	}
+
+	if err := cfg.expandPresets(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if err := cfg.expandVars(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 	return cfg, nil
 }
 
+// decodeFileStrict decodes path into cfg and fails on keys that don't map to a
+// config field, reporting the line where the first unknown key appears.
+func decodeFileStrict(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// Decode into a fresh Config: decoding over cfg would let list entries,
+	// e.g. [[post_hooks]], inherit fields from an earlier layer's entry at
+	// the same index.
+	var layer Config
+	md, err := toml.Decode(string(data), &layer)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		key := undecoded[0]
+		line := findKeyLine(string(data), key)
+		if line > 0 {
+			return fmt.Errorf("%s:%d: unknown config key %q", path, line, key.String())
+		}
+		return fmt.Errorf("%s: unknown config key %q", path, key.String())
+	}
+	mergeDefined(reflect.ValueOf(cfg).Elem(), reflect.ValueOf(layer), md, nil)
+	return nil
+}
+
+// mergeDefined copies the keys a layer defines from src into dst, which are
+// both the struct at key: a key set in the layer replaces the value as a
+// whole, except that tables merge key by key and maps entry by entry.
+func mergeDefined(dst, src reflect.Value, md toml.MetaData, key []string) {
+	for i := 0; i < dst.NumField(); i++ {
+		name, _, _ := strings.Cut(dst.Type().Field(i).Tag.Get("toml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fieldKey := append(key[:len(key):len(key)], name)
+		if !md.IsDefined(fieldKey...) {
+			continue
+		}
+		d, s := dst.Field(i), src.Field(i)
+		switch d.Kind() {
+		case reflect.Struct:
+			mergeDefined(d, s, md, fieldKey)
+		case reflect.Map:
+			if d.IsNil() {
+				d.Set(reflect.MakeMap(d.Type()))
+			}
+			for _, k := range s.MapKeys() {
+				d.SetMapIndex(k, s.MapIndex(k))
+			}
+		default:
+			d.Set(s)
+		}
+	}
+}
+
+// findKeyLine returns the 1-based line where key is first assigned or used as
+// a table header, following the table headers so a key is only found in its
+// own table. When key itself isn't written out, e.g. inside an inline table,
+// it returns the line of the longest enclosing key that is, or 0.
+func findKeyLine(content string, key toml.Key) int {
+	var table []string
+	best, bestLen := 0, 0
+	multiline := ""
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if multiline != "" {
+			if strings.Count(line, multiline)%2 == 1 {
+				multiline = ""
+			}
+			continue
+		}
+		var path []string
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "["):
+			header, _, _ := strings.Cut(line, "]")
+			table = splitKey(strings.Trim(header, "[ \t"))
+			path = table
+		default:
+			lhs, rhs, ok := cutUnquoted(line, '=')
+			if !ok {
+				continue
+			}
+			for _, quote := range []string{`"""`, "'''"} {
+				if strings.Count(rhs, quote)%2 == 1 {
+					multiline = quote
+				}
+			}
+			path = append(slices.Clip(table), splitKey(lhs)...)
+		}
+		n := 0
+		for n < len(path) && n < len(key) && path[n] == key[n] {
+			n++
+		}
+		if n == len(key) {
+			return i + 1
+		}
+		if n == len(path) && n > bestLen {
+			best, bestLen = i+1, n
+		}
+	}
+	return best
+}
+
+// splitKey splits a dotted TOML key such as `packages."web app".path` into
+// its parts.
+func splitKey(s string) []string {
+	var parts []string
+	for {
+		part, rest, more := cutUnquoted(s, '.')
+		parts = append(parts, strings.Trim(strings.TrimSpace(part), `"'`))
+		if !more {
+			return parts
+		}
+		s = rest
+	}
+}
+
+// cutUnquoted cuts s around the first sep outside quotes.
+func cutUnquoted(s string, sep byte) (before, after string, found bool) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			return s[:i], s[i+1:], true
+		}
+	}
+	return s, "", false
+}
+
+// Validate checks values that decode fine but can't work at runtime.
+func (c *Config) Validate() error {
+	if strings.TrimSpace(c.WorktreeDir) == "" {
+		return fmt.Errorf("worktree_dir must not be empty")
+	}
+	if c.Sanitize.MaxLength < 0 {
+		return fmt.Errorf("sanitize.max_length must not be negative")
+	}
+	if strings.ContainsAny(c.Sanitize.Replacement, "/\\:*?\"<>| \t") {
+		return fmt.Errorf("sanitize.replacement %q contains characters that are invalid in directory names", c.Sanitize.Replacement)
+	}
+	if c.DirTemplate != "" {
+		if err := tmpl.ValidateDirTemplate(c.DirTemplate); err != nil {
+			return fmt.Errorf("dir_template: %w", err)
+		}
+	}
+	if c.TmuxName != "" {
+		if err := tmpl.ValidateTmuxName(c.TmuxName); err != nil {
+			return fmt.Errorf("tmux_name: %w", err)
+		}
+	}
+	if c.Maintenance.GCAfterRemovals < 0 {
+		return fmt.Errorf("maintenance.gc_after_removals must not be negative")
+	}
+	if err := c.BranchRules.validate(); err != nil {
+		return err
+	}
+	if len(c.PreprocessScript) > 0 && len(c.PreprocessCommand) > 0 {
+		return fmt.Errorf("set preprocess_script or preprocess_command, not both")
+	}
+	builtin, err := preprocess.ParseBuiltin(c.Preprocess, c.PreprocessMatch, c.PreprocessReplace)
+	if err != nil {
+		return err
+	}
+	if builtin != nil && len(c.PreprocessScript)+len(c.PreprocessCommand) > 0 {
+		return fmt.Errorf("set preprocess/preprocess_match or preprocess_script/preprocess_command, not both")
+	}
+	if _, err := git.ParseSubmoduleMode(c.Submodules); err != nil {
+		return fmt.Errorf("submodules: %w", err)
+	}
+	if _, err := git.ParseLFSMode(c.LFS); err != nil {
+		return fmt.Errorf("lfs: %w", err)
+	}
+	if c.MaxAge != "" {
+		if _, err := state.ParseTTL(c.MaxAge); err != nil {
+			return fmt.Errorf("max_age: %w", err)
+		}
+	}
+	if _, err := git.ParseDirtyCheck(c.DirtyCheck); err != nil {
+		return fmt.Errorf("dirty_check: %w", err)
+	}
+	if _, err := git.ParseBackend(c.GitBackend); err != nil {
+		return fmt.Errorf("git_backend: %w", err)
+	}
+	if _, err := git.ParseSyncStrategy(c.SyncStrategy); err != nil {
+		return fmt.Errorf("sync_strategy: %w", err)
+	}
+	if _, err := term.ParseTmuxMode(c.TmuxMode); err != nil {
+		return fmt.Errorf("tmux_mode: %w", err)
+	}
+	if _, err := term.ParseZellijMode(c.ZellijMode); err != nil {
+		return fmt.Errorf("zellij_mode: %w", err)
+	}
+	if _, err := term.ParseTerminal(c.Terminal); err != nil {
+		return fmt.Errorf("terminal: %w", err)
+	}
+	if _, err := term.ParseTerminalMode(c.TerminalMode); err != nil {
+		return fmt.Errorf("terminal_mode: %w", err)
+	}
+	if _, err := styles.LookupTheme(c.Theme); err != nil {
+		return fmt.Errorf("theme: %w", err)
+	}
+	if _, err := tui.ParseView(c.SelectorView); err != nil {
+		return fmt.Errorf("selector_view: %w", err)
+	}
+	if _, err := tui.ParseFinder(c.Finder); err != nil {
+		return fmt.Errorf("finder: %w", err)
+	}
+	switch c.HookOutput {
+	case "", HookOutputStream, HookOutputQuiet, HookOutputOnFailure:
+	default:
+		return fmt.Errorf("hook_output: unknown mode %q (supported: %s, %s, %s)", c.HookOutput, HookOutputStream, HookOutputQuiet, HookOutputOnFailure)
+	}
+	backend, err := state.ParseBackend(c.StateBackend)
+	if err != nil {
+		return fmt.Errorf("state_backend: %w", err)
+	}
+	if c.StateRemote != "" && backend != state.BackendGit {
+		return fmt.Errorf("state_remote requires state_backend = \"git\"")
+	}
+	if err := validateSparsePaths("sparse_paths", c.SparsePaths); err != nil {
+		return err
+	}
+	for name, paths := range c.SparseProfiles {
+		if len(paths) == 0 {
+			return fmt.Errorf("sparse_profiles.%s: at least one path is required", name)
+		}
+		if err := validateSparsePaths("sparse_profiles."+name, paths); err != nil {
+			return err
+		}
+	}
+	if err := validateHooks("post_hooks", c.PostHooks); err != nil {
+		return err
+	}
+	if err := validateHooks("pre_add_hooks", c.PreAddHooks); err != nil {
+		return err
+	}
+	if err := validateHooks("post_switch_hooks", c.PostSwitchHooks); err != nil {
+		return err
+	}
+	for name, list := range c.HookProfiles {
+		if err := validateHooks("hook_profiles."+name, list); err != nil {
+			return err
+		}
+	}
+	for name, pkg := range c.Packages {
+		if pkg.Path == "" {
+			return fmt.Errorf("packages.%s: path is required", name)
+		}
+		if clean := filepath.Clean(pkg.Path); filepath.IsAbs(pkg.Path) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("packages.%s: path %q must be relative to the repository root", name, pkg.Path)
+		}
+		if err := validateHooks("packages."+name+".post_hooks", pkg.PostHooks); err != nil {
+			return err
+		}
+	}
+	for name, profile := range c.Profiles {
+		if err := validateSparsePaths("profiles."+name+".sparse_paths", profile.SparsePaths); err != nil {
+			return err
+		}
+		if err := validateHooks("profiles."+name+".post_hooks", profile.PostHooks); err != nil {
+			return err
+		}
+	}
+	for name, remote := range c.Remotes {
+		if remote.Host == "" {
+			return fmt.Errorf("remotes.%s: host is required", name)
+		}
+		if remote.Path == "" {
+			return fmt.Errorf("remotes.%s: path is required", name)
+		}
+	}
+	return nil
+}
+
+// validateSparsePaths checks that sparse checkout paths name directories
+// inside the repository.
+func validateSparsePaths(section string, paths []string) error {
+	for _, p := range paths {
+		clean := filepath.Clean(p)
+		if strings.TrimSpace(p) == "" || clean == "." {
+			return fmt.Errorf("%s: paths must not be empty", section)
+		}
+		if filepath.IsAbs(p) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("%s: %q must be relative to the repository root", section, p)
+		}
+	}
+	return nil
+}
+
+func validateHooks(section string, hooks []Hook) error {
+	for i, hook := range hooks {
+		if strings.TrimSpace(hook.Run) == "" {
+			return fmt.Errorf("%s[%d] (%q): run is required", section, i, hook.Name)
+		}
+		if filepath.IsAbs(hook.Dir) {
+			return fmt.Errorf("%s[%d] (%q): dir must be relative to the worktree or start with %s", section, i, hook.Name, RepoRootDir)
+		}
+		if strings.HasPrefix(hook.Dir, "@") && hook.Dir != RepoRootDir && !strings.HasPrefix(hook.Dir, RepoRootDir+"/") {
+			return fmt.Errorf("%s[%d] (%q): unknown dir %q (only %s is special)", section, i, hook.Name, hook.Dir, RepoRootDir)
+		}
+		rest, _ := strings.CutPrefix(hook.Dir, RepoRootDir)
+		if clean := filepath.Clean(strings.TrimPrefix(rest, "/")); clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("%s[%d] (%q): dir %q must not leave the worktree or repository root", section, i, hook.Name, hook.Dir)
+		}
+		if err := validateBranchPattern(hook.IfBranch); err != nil {
+			return fmt.Errorf("%s[%d] (%q): if_branch: %w", section, i, hook.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateBranchPattern checks an if_branch pattern the way
+// hooks.MatchBranch reads it: a /regex/, or a glob.
+func validateBranchPattern(pattern string) error {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		_, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		return err
+	}
+	if !doublestar.ValidatePattern(pattern) {
+		return fmt.Errorf("bad glob pattern %q", pattern)
+	}
+	return nil
+}
+
+// RepoRootDir, alone or followed by /path, as a hook's dir runs the hook in
+// the repository wt runs from instead of the worktree.
+const RepoRootDir = "@repo_root"
+
+// Values of hook_output, which decides when hooks' output is shown.
+const (
+	HookOutputStream    = "stream"     // as the hooks print it (default)
+	HookOutputQuiet     = "quiet"      // never
+	HookOutputOnFailure = "on-failure" // only a failed hook's, once it failed
+)
+
+// Values returns the config as a generic TOML map keyed by TOML key names.
+func (c *Config) Values() (map[string]any, error) {
+	data, err := toml.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]any)
+	if _, err := toml.Decode(string(data), &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// ParseValue validates literal as the TOML value for key and returns it in
+// canonical form. Bare words are accepted for string keys and quoted automatically.
+func ParseValue(key, literal string) (string, error) {
+	for _, candidate := range []string{literal, tomlString(literal)} {
+		var cfg Config
+		md, err := toml.Decode(key+" = "+candidate, &cfg)
+		if err != nil {
+			continue
+		}
+		if len(md.Undecoded()) > 0 {
+			return "", fmt.Errorf("unknown config key: %s", key)
+		}
+		return candidate, nil
+	}
+	return "", fmt.Errorf("invalid value for %s: %s", key, literal)
+}
+
+// SetString sets a top-level string key in the config file at path, preserving
+// the rest of the file. The file is created if it doesn't exist.
+func SetString(path, key, value string) error {
+	return SetRaw(path, key, tomlString(value))
+}
+
+// SetRaw sets a top-level key to the given TOML literal in the config file at
+// path, preserving comments and other keys. Multi-line arrays are replaced whole.
+// The file is created if it doesn't exist.
+func SetRaw(path, key, literal string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	line := key + " = " + literal
+	var lines []string
+	if len(existing) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(existing), "\n"), "\n")
+	}
+
+	keyRe := regexp.MustCompile(`^[ \t]*` + regexp.QuoteMeta(key) + `[ \t]*=`)
+	replaced := false
+	for i := 0; i < len(lines); i++ {
+		if tableHeaderRe.MatchString(lines[i]) {
+			break
+		}
+		if !keyRe.MatchString(lines[i]) {
+			continue
+		}
+		end := i
+		depth := bracketDepth(lines[i])
+		for depth > 0 && end+1 < len(lines) {
+			end++
+			depth += bracketDepth(lines[end])
+		}
+		lines = append(lines[:i], append([]string{line}, lines[end+1:]...)...)
+		replaced = true
+		break
+	}
+	if !replaced {
+		lines = append([]string{line}, lines...)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// AppendPostHooks appends hooks as [[post_hooks]] entries to the config file at
+// path. The file is created if it doesn't exist.
+func AppendPostHooks(path string, hooks []Hook) error {
+	var b strings.Builder
+	enc := toml.NewEncoder(&b)
+	enc.Indent = ""
+	if err := enc.Encode(struct {
+		PostHooks []Hook `toml:"post_hooks"`
+	}{hooks}); err != nil {
+		return err
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	content := string(existing)
+	if content != "" {
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += "\n"
+	}
+	content += b.String()
+
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+var tableHeaderRe = regexp.MustCompile(`^[ \t]*\[`)
+
+// bracketDepth returns the net number of unclosed '[' on a line, ignoring
+// brackets inside quoted strings and comments.
+func bracketDepth(line string) int {
+	depth := 0
+	var quote rune
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return depth
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+		}
+	}
+	return depth
+}
+
+func tomlString(s string) string {
+	var b strings.Builder
+	if err := toml.NewEncoder(&b).Encode(map[string]string{"v": s}); err != nil {
+		return strconv.Quote(s)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(b.String(), "v = "))
+}
+
 func findConfig() (string, error) {
 	dir, err := os.Getwd()
 	if err != nil {
@@ -83,15 +828,85 @@ func findConfig() (string, error) {
 func SampleConfig() string {
 	return `# wt configuration file
 
+# Config files to apply first, so one set of copy_patterns and hooks can be
+# shared across repositories. Relative paths are relative to this file, ~/ is
+# the home directory, and keys set here override the included ones
+# include = ["../shared/wt-common.toml", "~/.config/wt/hooks.toml"]
+
 # Base branch for new worktrees (default: main)
 base_branch = "main"
 
-# Directory for worktrees (default: .worktrees)
+# Directory for worktrees (default: .worktrees). Paths may use environment
+# variables, e.g. "${HOME}/worktrees"; an unset one is an error ($$ is a
+# literal $). Hook run commands are expanded by the shell instead
 worktree_dir = ".worktrees"
 
-# Preprocessing script (receives input, outputs branch name)
-# Script can be any executable - bash, python, etc.
+# Name of each worktree's directory, as a Go template (default: the branch with
+# / replaced). Fields: {{.Branch}}, {{.BaseBranch}}, {{.Input}}, {{.Date}}
+# (YYYY-MM-DD), {{.Hash}} (7 hex digits of the branch's SHA-1). Functions:
+# sanitize, lower, truncate N, ticket (first key like PROJ-123), date "layout"
+# dir_template = "{{.Branch | ticket}}-{{.Hash}}"
+
+# How branch names become directory names (also behind dir_template's
+# sanitize). Characters invalid on any OS (/ \ : * ? " < > |) and whitespace
+# always become the replacement
+# [sanitize]
+# replacement = "-"
+# max_length = 40
+# lowercase = true
+# strip = "()[]"
+
+# Naming policy for new branches, checked after preprocessing by wt add and
+# wt switch-branch (--no-verify skips it). Patterns are Go regular
+# expressions: the name must match one allow pattern (if any), no deny
+# pattern, and contain the ticket pattern
+# [branch_rules]
+# allow = ["^(feat|fix|chore)/"]
+# deny = ["^(main|master|release/.*)$", "wip"]
+# max_length = 60
+# ticket = "[A-Z]+-[0-9]+"
+
+# Built-in preprocessing, no script needed: "slug" lowercases the input and
+# joins its words with dashes ("Fix Login" -> "fix-login"), "jira" keeps a
+# ticket key and slugifies the rest ("Fix login PROJ-12" -> "PROJ-12-fix-login")
+# preprocess = "slug"
+
+# Rewrite the input with a regular expression first (Go syntax, $1 for
+# groups); input that does not match is an error
+# preprocess_match = '^https://github\.com/.+/issues/(\d+)$'
+# preprocess_replace = "issue-$1"
+
+# Preprocessing script (receives input, outputs branch name, or JSON such as
+# {"branch": "feat/x", "base": "develop", "dir": "x"})
+# Script can be any executable - bash, python, etc. Scripts without the
+# executable bit or a shebang line are run by extension (.sh, .py, .rb, .js, ...)
+# A list of scripts forms a pipeline: each gets the previous one's output
 # preprocess_script = ".wt/preprocess.sh"
+# preprocess_script = [".wt/ticket-id.sh", ".wt/fetch-title.py", ".wt/slugify.sh"]
+
+# Or a command line run by sh in the repository root, with the input appended
+# as the last argument (instead of preprocess_script); also accepts a list
+# preprocess_command = "python3 scripts/branch_name.py"
+
+# Initialize submodules in every new worktree: "none" (default),
+# "recursive" (git submodule update --init --recursive), or "shallow"
+# (the same with --depth 1). Runs before templates, copies, and hooks.
+# submodules = "recursive"
+
+# How Git LFS files reach new worktrees: "auto" (default, git downloads them
+# during checkout if git-lfs is installed), "pull" (check out pointers, then
+# one batched git lfs pull), or "skip" (leave pointers; fetch later with
+# git lfs pull). Ignored in repositories without filter=lfs attributes.
+# lfs = "pull"
+
+# Check out only these directories (plus files in the repository root) in
+# every new worktree, using git's cone-mode sparse checkout
+# sparse_paths = ["apps/web", "libs/ui"]
+
+# Template directory whose contents are rendered into every new worktree
+# (Go templates: {{.Branch}}, {{.BaseBranch}}, {{.Input}}, {{.RepoRoot}},
+# {{.WorktreePath}}, {{.DirName}})
+# template_dir = ".wt/template"
 
 # Files/directories to copy (gitignore-like patterns)
 # Supports ** for recursive matching (e.g., **/node_modules for monorepos)
@@ -102,14 +917,180 @@ worktree_dir = ".worktrees"
 #   "!.env.example",
 # ]
 
+# Env files (relative to the worktree) included in ` + "`wt env`" + ` output
+# env_files = [".env"]
+
+# Check the commit identity (user.name, user.email, signing key) git resolves
+# inside each new worktree and warn right away if something is missing
+# verify_identity = true
+
+# Run direnv allow in each new worktree, after copying the repository's .envrc
+# into it if git doesn't check one out, so direnv doesn't block it. Skipped
+# with --no-hooks
+# direnv = true
+
 # Post-creation hooks (run in order after worktree is created)
 # [[post_hooks]]
 # name = "Install dependencies"
-# run = "npm install"
+# run = "npm ci"
+# if_changed = "package-lock.json"  # only if it differs from the copy source
 #
 # [[post_hooks]]
 # name = "Setup database"
 # run = "bin/rails db:prepare"
 # if_exists = "bin/rails"
+#
+# [[post_hooks]]
+# name = "Build frontend"
+# run = "npm run build"
+# dir = "frontend"  # relative to the worktree; "@repo_root" runs in the main repo
+#
+# [[post_hooks]]
+# use = "node-install"  # a preset: node-install, bundler, go-mod-download,
+#                       # rails-db-prepare or docker-compose-up; keys set
+#                       # here override the preset's
+#
+# [[post_hooks]]
+# name = "Seed staging data"
+# run = "bin/seed-staging"
+# if_branch = "hotfix/*"  # glob, or /regex/ (e.g. "/^release-\\d+$/")
+
+# Named sets of directories for ` + "`wt add --sparse <name>`" + ` (instead of sparse_paths)
+# [sparse_profiles]
+# api = ["apps/api", "libs/shared"]
+
+# Named sets of post hooks for ` + "`wt ci --hooks-profile <name>`" + ` (instead of
+# post_hooks), e.g. to skip editor setup in pipelines
+# [[hook_profiles.ci]]
+# name = "Install dependencies"
+# run = "npm ci --prefer-offline"
+
+# Monorepo packages (` + "`wt add --scope api`" + ` uses only this package's
+# copy_patterns and hooks, relative to its path)
+# [packages.api]
+# path = "apps/api"
+# copy_patterns = [".env", "node_modules"]
+#
+# [[packages.api.post_hooks]]
+# name = "Install dependencies"
+# run = "npm install"
+
+# Setup profiles for ` + "`wt add --profile frontend`" + `: copy_patterns, post_hooks
+# and sparse_paths replace the top-level ones; keys left out keep them
+# [profiles.frontend]
+# copy_patterns = ["apps/web/.env.local"]
+# sparse_paths = ["apps/web", "libs/ui"]
+#
+# [[profiles.frontend.post_hooks]]
+# use = "node-install"
+# dir = "apps/web"
+
+# Machines with clones of this repository, for ` + "`wt cd --remote devbox`" + `
+# (lists worktrees there over ssh and opens an ssh session into the selected one)
+# [remotes.devbox]
+# host = "devbox"        # host, user@host, or a ~/.ssh/config alias
+# path = "~/src/app"     # repository path on the remote
+# wt = "~/go/bin/wt"     # wt on the remote, if not on the non-interactive PATH
+
+# Upkeep of the object store all worktrees share: register the repository
+# with git maintenance start when wt add first runs in it, and run a
+# lightweight git gc --auto after wt rm or wt clean removes this many
+# worktrees at once
+# [maintenance]
+# register = true
+# gc_after_removals = 5
+
+# How --tmux opens worktrees: "window" (default), "split-horizontal",
+# "split-vertical", or "session" (one session per branch)
+# tmux_mode = "window"
+
+# Name of the tmux windows and sessions wt opens, and their pane titles, as a
+# Go template (default: sessions and titles use the branch, windows keep
+# tmux's automatic name). Fields: {{.Repo}}, {{.Branch}}, {{.Dir}}; functions
+# as in dir_template. --window-name overrides it for one command
+# tmux_name = "{{.Repo}}:{{.Branch | sanitize}}"
+
+# How --zellij (or -t inside zellij) opens worktrees: "tab" (default) or "pane"
+# zellij_mode = "tab"
+
+# Where -t/--tmux opens worktrees: "auto" (default: tmux or zellij when inside
+# one, else the terminal wt runs in), "tmux", "zellij", "wezterm" (wezterm
+# cli), "kitty" (needs allow_remote_control), "iterm2" (AppleScript) or
+# "windows-terminal" (wt.exe)
+# terminal = "wezterm"
+
+# How those terminals open worktrees: "tab" (default), "split-horizontal"
+# (side by side) or "split-vertical" (top and bottom)
+# terminal_mode = "split-horizontal"
+
+# Where worktree notes (` + "`wt note`" + `) are stored: "file" (default, local to
+# this clone) or "git" (commits on refs/wt/state). With state_remote set, the
+# git backend fetches and pushes the ref so teammates share notes.
+# state_backend = "git"
+# state_remote = "origin"
+
+# Color theme: "default", "high-contrast", "deuteranopia-safe", or
+# "monochrome" (the WT_THEME environment variable takes precedence)
+# theme = "deuteranopia-safe"
+
+# Plain line-by-line output instead of animation: no blinking cursor in
+# selectors, and git and hooks write to a pipe, so progress bars and spinners
+# become plain lines (the WT_REDUCED_MOTION environment variable also turns
+# it on)
+# reduced_motion = true
+
+# How much each selector row shows: "compact" (branch only), "normal"
+# (default: status, note and dim path) or "detailed" (plus age). Ctrl+L
+# switches while a selector is open
+# selector_view = "compact"
+
+# What selectors run: "builtin" (default) or "fzf", which pipes the worktrees
+# to the fzf binary so your own fzf bindings, layout and FZF_DEFAULT_OPTS
+# apply (with wt inspect as the preview)
+# finder = "fzf"
+
+# List the main worktree in the wt cd finder too, marked "(main worktree)"
+# (--include-main for one run)
+# cd_include_main = true
+
+# How selectors find the uncommitted changes behind the ● badge: "full"
+# (default, modified and untracked files; git status honors
+# core.untrackedCache), "fast" (modified tracked files only, for repositories
+# with huge untracked build trees) or "off" (only unpushed commits are shown)
+# dirty_check = "fast"
+
+# How wt lists worktrees and looks up branches: "exec" (default, runs git) or
+# "go-git" (reads the repository files in-process, saving a git process or
+# two per command on repositories with many refs or where starting processes
+# is slow, as on Windows). Everything else runs git either way. WT_GIT_BACKEND
+# overrides it
+# git_backend = "go-git"
+
+# When hook output is shown: "stream" (default, as it is printed), "quiet"
+# (never) or "on-failure" (captured, and replayed only if the hook fails). The
+# summary of what ran is printed either way
+# hook_output = "on-failure"
+
+# How long a worktree may go without commits and without being opened
+# (wt cd) before ` + "`wt prune --stale`" + ` offers to remove it, e.g. "30d" or "6w"
+# max_age = "30d"
+
+# How ` + "`wt sync`" + ` updates a worktree's branch after fetching its base branch:
+# "rebase" (default), "merge", or "ff-only" (only branches without commits
+# of their own). --strategy overrides it for one run
+# sync_strategy = "merge"
+
+# Pre-add hooks run in the repository root before ` + "`wt add`" + ` creates a worktree,
+# with WT_BRANCH, WT_BASE_BRANCH, WT_WORKTREE_PATH, WT_DIR_NAME and WT_REPO_ROOT
+# describing it. One exiting non-zero cancels the worktree; what it printed
+# says why. ` + "`wt add --no-verify`" + ` skips them
+# [[pre_add_hooks]]
+# name = "Limit open worktrees"
+# run = "test $(git worktree list | wc -l) -le 10 || { echo 'Too many worktrees, remove some first'; exit 1; }"
+
+# Post-switch hooks (run in the selected worktree by ` + "`wt cd`" + `)
+# [[post_switch_hooks]]
+# name = "Activate tool versions"
+# run = "mise install"
 `
 }
@@ -0,0 +1,633 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestSetString(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		create   bool
+		want     string
+	}{
+		{
+			name:   "creates file",
+			create: false,
+			want:   "base_branch = \"develop\"\n",
+		},
+		{
+			name:     "replaces existing key and keeps comments",
+			existing: "# Base branch\n\nbase_branch = \"main\"\nworktree_dir = \".worktrees\"\n",
+			create:   true,
+			want:     "# Base branch\n\nbase_branch = \"develop\"\nworktree_dir = \".worktrees\"\n",
+		},
+		{
+			name:     "prepends when key is missing",
+			existing: "worktree_dir = \".worktrees\"\n",
+			create:   true,
+			want:     "base_branch = \"develop\"\nworktree_dir = \".worktrees\"\n",
+		},
+		{
+			name:     "ignores keys inside tables",
+			existing: "[[post_hooks]]\nbase_branch = \"x\"\n",
+			create:   true,
+			want:     "base_branch = \"develop\"\n[[post_hooks]]\nbase_branch = \"x\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ConfigFileName)
+			if tt.create {
+				if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+					t.Fatalf("write config: %v", err)
+				}
+			}
+
+			if err := SetString(path, "base_branch", "develop"); err != nil {
+				t.Fatalf("SetString: %v", err)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read config: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFromDirStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			content: "base_branch = \"main\"\ncopy_patterns = [\".env\"]\n",
+		},
+		{
+			name:    "unknown top-level key",
+			content: "base_branch = \"main\"\n\ncopy_pattern = [\".env\"]\n",
+			wantErr: ".wt.toml:3: unknown config key \"copy_pattern\"",
+		},
+		{
+			name:    "unknown hook key",
+			content: "[[post_hooks]]\nname = \"x\"\nrun = \"true\"\nif_exist = \"bin/rails\"\n",
+			wantErr: ".wt.toml:4: unknown config key \"post_hooks.if_exist\"",
+		},
+		{
+			name:    "unknown key in a table",
+			content: "[sanitize]\nlowercase = true\n\n[packages.web]\npath = \"web\"\nlowercase = true\n",
+			wantErr: ".wt.toml:6: unknown config key \"packages.web.lowercase\"",
+		},
+		{
+			name:    "unknown table",
+			content: "[hooks]\nname = \"x\"\n",
+			wantErr: ".wt.toml:1: unknown config key \"hooks\"",
+		},
+		{
+			name:    "hook without run",
+			content: "[[post_hooks]]\nname = \"x\"\n",
+			wantErr: "post_hooks[0] (\"x\"): run is required",
+		},
+		{
+			name:    "hook dir with unknown prefix",
+			content: "[[post_hooks]]\nname = \"x\"\nrun = \"true\"\ndir = \"@root\"\n",
+			wantErr: "post_hooks[0] (\"x\"): unknown dir \"@root\"",
+		},
+		{
+			name:    "hook dir outside the worktree",
+			content: "[[post_hooks]]\nname = \"x\"\nrun = \"true\"\ndir = \"sub/../..\"\n",
+			wantErr: "post_hooks[0] (\"x\"): dir \"sub/../..\" must not leave the worktree or repository root",
+		},
+		{
+			name:    "hook dir outside the repository root",
+			content: "[[pre_add_hooks]]\nname = \"x\"\nrun = \"true\"\ndir = \"@repo_root/../x\"\n",
+			wantErr: "pre_add_hooks[0] (\"x\"): dir \"@repo_root/../x\" must not leave the worktree or repository root",
+		},
+		{
+			name:    "hook if_branch with a bad regex",
+			content: "[[post_hooks]]\nname = \"x\"\nrun = \"true\"\nif_branch = \"/release-(/\"\n",
+			wantErr: "post_hooks[0] (\"x\"): if_branch: error parsing regexp",
+		},
+		{
+			name:    "hook if_branch with a bad glob",
+			content: "[[post_switch_hooks]]\nname = \"x\"\nrun = \"true\"\nif_branch = \"feature/[\"\n",
+			wantErr: "post_switch_hooks[0] (\"x\"): if_branch: bad glob pattern \"feature/[\"",
+		},
+		{
+			name:    "hook profile hook without run",
+			content: "[[hook_profiles.ci]]\nname = \"x\"\n",
+			wantErr: "hook_profiles.ci[0] (\"x\"): run is required",
+		},
+		{
+			name:    "package without path",
+			content: "[packages.api]\ncopy_patterns = []\n",
+			wantErr: "packages.api: path is required",
+		},
+		{
+			name:    "package outside the repository",
+			content: "[packages.api]\npath = \"services/../../api\"\n",
+			wantErr: "packages.api: path \"services/../../api\" must be relative to the repository root",
+		},
+		{
+			name:    "preprocess script and command",
+			content: "preprocess_script = \".wt/pre.sh\"\npreprocess_command = \"python3 pre.py\"\n",
+			wantErr: "set preprocess_script or preprocess_command, not both",
+		},
+		{
+			name:    "unknown preprocess mode",
+			content: "preprocess = \"kebab\"\n",
+			wantErr: "preprocess: unknown mode \"kebab\"",
+		},
+		{
+			name:    "preprocess with a script",
+			content: "preprocess = \"slug\"\npreprocess_script = \"pre.sh\"\n",
+			wantErr: "set preprocess/preprocess_match or preprocess_script/preprocess_command, not both",
+		},
+		{
+			name:    "preprocess_script of wrong type",
+			content: "preprocess_script = 1\n",
+			wantErr: "expected a string or an array of strings",
+		},
+		{
+			name:    "negative sanitize.max_length",
+			content: "[sanitize]\nmax_length = -1\n",
+			wantErr: "sanitize.max_length must not be negative",
+		},
+		{
+			name:    "negative maintenance.gc_after_removals",
+			content: "[maintenance]\ngc_after_removals = -1\n",
+			wantErr: "maintenance.gc_after_removals must not be negative",
+		},
+		{
+			name:    "invalid branch_rules pattern",
+			content: "[branch_rules]\ndeny = [\"wip(\"]\n",
+			wantErr: "branch_rules.deny: error parsing regexp",
+		},
+		{
+			name:    "unknown selector view",
+			content: "selector_view = \"dense\"\n",
+			wantErr: "selector_view: unknown selector view \"dense\"",
+		},
+		{
+			name:    "tmux_name with an unknown field",
+			content: "tmux_name = \"{{.Session}}\"\n",
+			wantErr: "tmux_name:",
+		},
+		{
+			name:    "unknown hook output mode",
+			content: "hook_output = \"silent\"\n",
+			wantErr: "hook_output: unknown mode \"silent\"",
+		},
+		{
+			name:    "unknown submodule mode",
+			content: "submodules = \"all\"\n",
+			wantErr: "submodules: unknown submodule mode \"all\"",
+		},
+		{
+			name:    "invalid max age",
+			content: "max_age = \"a month\"\n",
+			wantErr: "max_age: invalid ttl \"a month\"",
+		},
+		{
+			name:    "unknown dirty check",
+			content: "dirty_check = \"quick\"\n",
+			wantErr: "dirty_check: unknown dirty check \"quick\"",
+		},
+		{
+			name:    "unknown git backend",
+			content: "git_backend = \"libgit2\"\n",
+			wantErr: "git_backend: unknown git backend \"libgit2\"",
+		},
+		{
+			name:    "unknown terminal",
+			content: "terminal = \"iterm\"\n",
+			wantErr: "terminal: unknown terminal \"iterm\"",
+		},
+		{
+			name:    "unknown terminal mode",
+			content: "terminal_mode = \"window\"\n",
+			wantErr: "terminal_mode: unknown terminal mode \"window\"",
+		},
+		{
+			name:    "unknown hook preset",
+			content: "[[post_hooks]]\nuse = \"npm\"\n",
+			wantErr: "post_hooks[0]: unknown hook preset \"npm\" (available: bundler,",
+		},
+		{
+			name:    "profile",
+			content: "[profiles.web]\ncopy_patterns = [\".env\"]\nsparse_paths = [\"web\"]\n[[profiles.web.post_hooks]]\nuse = \"node-install\"\n",
+		},
+		{
+			name:    "profile hook without run",
+			content: "[[profiles.web.post_hooks]]\nname = \"x\"\n",
+			wantErr: "profiles.web.post_hooks[0] (\"x\"): run is required",
+		},
+		{
+			name:    "unknown sync strategy",
+			content: "sync_strategy = \"squash\"\n",
+			wantErr: "sync_strategy: unknown sync strategy \"squash\"",
+		},
+		{
+			name:    "unknown lfs mode",
+			content: "lfs = \"fetch\"\n",
+			wantErr: "lfs: unknown lfs mode \"fetch\"",
+		},
+		{
+			name:    "sparse path outside the repository",
+			content: "sparse_paths = [\"../shared\"]\n",
+			wantErr: "sparse_paths: \"../shared\" must be relative to the repository root",
+		},
+		{
+			name:    "empty sparse profile",
+			content: "[sparse_profiles]\napi = []\n",
+			wantErr: "sparse_profiles.api: at least one path is required",
+		},
+		{
+			name:    "syntax error",
+			content: "base_branch = \n",
+			wantErr: ".wt.toml: toml: line 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, ConfigFileName), []byte(tt.content), 0644); err != nil {
+				t.Fatalf("write config: %v", err)
+			}
+
+			_, err := LoadFromDir(dir)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestHookPresets(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	content := `[[post_hooks]]
+use = "go-mod-download"
+
+[[post_hooks]]
+use = "bundler"
+name = "Gems"
+if_changed = "Gemfile.lock"
+
+[packages.web]
+path = "web"
+[[packages.web.post_hooks]]
+use = "node-install"
+`
+	if err := os.WriteFile(filepath.Join(dir, ConfigFileName), []byte(content), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := LoadFromDir(dir)
+	if err != nil {
+		t.Fatalf("LoadFromDir: %v", err)
+	}
+
+	want := []Hook{
+		{Use: "go-mod-download", Name: "Download Go modules", Run: "go mod download", IfExists: "go.mod"},
+		{Use: "bundler", Name: "Gems", Run: "bundle install", IfExists: "Gemfile", IfChanged: "Gemfile.lock"},
+	}
+	if !reflect.DeepEqual(cfg.PostHooks, want) {
+		t.Fatalf("post_hooks = %+v, want %+v", cfg.PostHooks, want)
+	}
+	if hook := cfg.Packages["web"].PostHooks[0]; hook.Run != nodeInstall || hook.IfExists != "package.json" {
+		t.Fatalf("packages.web.post_hooks[0] = %+v", hook)
+	}
+}
+
+func TestLayersReplaceWholeKeys(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	dir := t.TempDir()
+	global := `base_branch = "develop"
+copy_patterns = [".env"]
+
+[[post_hooks]]
+name = "global"
+run = "make"
+if_exists = "package.json"
+if_branch = "release/*"
+dir = "web"
+
+[maintenance]
+register = true
+
+[packages.web]
+path = "web"
+`
+	repo := `[[post_hooks]]
+name = "mine"
+run = "make test"
+
+[maintenance]
+gc_after_removals = 5
+
+[packages.api]
+path = "api"
+`
+	if err := os.MkdirAll(filepath.Join(xdg, "wt"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(xdg, "wt", "config.toml"), []byte(global), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ConfigFileName), []byte(repo), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFromDir(dir)
+	if err != nil {
+		t.Fatalf("LoadFromDir: %v", err)
+	}
+	if want := []Hook{{Name: "mine", Run: "make test"}}; !reflect.DeepEqual(cfg.PostHooks, want) {
+		t.Errorf("post_hooks = %+v, want %+v without the global hook's fields", cfg.PostHooks, want)
+	}
+	if cfg.BaseBranch != "develop" || !reflect.DeepEqual(cfg.CopyPatterns, []string{".env"}) {
+		t.Errorf("base_branch = %q, copy_patterns = %v; want the global ones", cfg.BaseBranch, cfg.CopyPatterns)
+	}
+	if want := (Maintenance{Register: true, GCAfterRemovals: 5}); cfg.Maintenance != want {
+		t.Errorf("maintenance = %+v, want %+v (tables merge key by key)", cfg.Maintenance, want)
+	}
+	if len(cfg.Packages) != 2 {
+		t.Errorf("packages = %v, want web and api", cfg.Packages)
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := t.TempDir()
+	dir := filepath.Join(root, "repo")
+	files := map[string]string{
+		filepath.Join(root, "shared", "common.toml"): "include = [\"~/hooks.toml\"]\nbase_branch = \"develop\"\ncopy_patterns = [\".env\"]\n",
+		filepath.Join(home, "hooks.toml"):            "base_branch = \"trunk\"\n[[post_hooks]]\nrun = \"make\"\n",
+		filepath.Join(dir, ConfigFileName):           "include = [\"../shared/common.toml\", \"~/hooks.toml\"]\ncopy_patterns = [\".env.local\"]\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := LoadFromDir(dir)
+	if err != nil {
+		t.Fatalf("LoadFromDir: %v", err)
+	}
+	if cfg.BaseBranch != "develop" {
+		t.Errorf("base_branch = %q, want develop (the including file wins)", cfg.BaseBranch)
+	}
+	if !reflect.DeepEqual(cfg.CopyPatterns, []string{".env.local"}) {
+		t.Errorf("copy_patterns = %v, want [.env.local]", cfg.CopyPatterns)
+	}
+	if len(cfg.PostHooks) != 1 || cfg.PostHooks[0].Run != "make" {
+		t.Errorf("post_hooks = %+v, want the included make hook", cfg.PostHooks)
+	}
+	wantSources := []string{filepath.Join(home, "hooks.toml"), filepath.Join(root, "shared", "common.toml"), filepath.Join(dir, ConfigFileName)}
+	if !reflect.DeepEqual(cfg.Sources, wantSources) {
+		t.Errorf("sources = %v, want %v", cfg.Sources, wantSources)
+	}
+
+	if err := os.WriteFile(filepath.Join(home, "hooks.toml"), []byte("include = [\"../"+filepath.Base(root)+"/repo/.wt.toml\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFromDir(dir); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("cycle: err = %v, want an include cycle", err)
+	}
+
+	if err := os.Remove(filepath.Join(home, "hooks.toml")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFromDir(dir); err == nil || !strings.Contains(err.Error(), `include "~/hooks.toml"`) {
+		t.Errorf("missing include: err = %v, want it named", err)
+	}
+}
+
+func TestIncludedHooksDontLeak(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	shared := "[[post_hooks]]\nname = \"shared\"\nrun = \"npm ci\"\nif_exists = \"package.json\"\nif_branch = \"release/*\"\n"
+	repo := "include = [\"shared.toml\"]\n\n[[post_hooks]]\nname = \"mine\"\nrun = \"make\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "shared.toml"), []byte(shared), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ConfigFileName), []byte(repo), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Hook{{Name: "mine", Run: "make"}}
+	cfg, err := LoadFromDir(dir)
+	if err != nil {
+		t.Fatalf("LoadFromDir: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.PostHooks, want) {
+		t.Errorf("LoadFromDir: post_hooks = %+v, want %+v", cfg.PostHooks, want)
+	}
+	cfg, err = LoadFile(filepath.Join(dir, ConfigFileName))
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.PostHooks, want) {
+		t.Errorf("LoadFile: post_hooks = %+v, want %+v", cfg.PostHooks, want)
+	}
+}
+
+func TestExpandVars(t *testing.T) {
+	t.Setenv("WT_TEST_HOME", "/home/ada")
+	t.Setenv("WT_TEST_EMPTY", "")
+	tests := []struct {
+		in, want, err string
+	}{
+		{in: "${WT_TEST_HOME}/worktrees", want: "/home/ada/worktrees"},
+		{in: "$WT_TEST_HOME/worktrees", want: "/home/ada/worktrees"},
+		{in: "${WT_TEST_HOME}_x and $WT_TEST_HOME_x", err: "$WT_TEST_HOME_x is not set"},
+		{in: "a${WT_TEST_EMPTY}b", want: "ab"},
+		{in: "cost $$5, $ and $1", want: "cost $5, $ and $1"},
+		{in: "${WT_TEST_MISSING}/x", err: "$WT_TEST_MISSING is not set"},
+		{in: "${WT_TEST_HOME", err: "unterminated"},
+		{in: "${1X}", err: "invalid variable"},
+	}
+	for _, tt := range tests {
+		got, err := ExpandVars(tt.in)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ExpandVars(%q) err = %v, want %q", tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ExpandVars(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	content := `worktree_dir = "${WT_TEST_HOME}/worktrees"
+copy_patterns = ["$WT_TEST_HOME/.env"]
+
+[[post_hooks]]
+run = "echo $WT_BRANCH $PPID"
+if_exists = "${WT_TEST_HOME}/marker"
+`
+	if err := os.WriteFile(filepath.Join(dir, ConfigFileName), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFromDir(dir)
+	if err != nil {
+		t.Fatalf("LoadFromDir: %v", err)
+	}
+	if cfg.WorktreeDir != "/home/ada/worktrees" || cfg.CopyPatterns[0] != "/home/ada/.env" || cfg.PostHooks[0].IfExists != "/home/ada/marker" {
+		t.Errorf("not expanded: %+v", cfg)
+	}
+	if cfg.PostHooks[0].Run != "echo $WT_BRANCH $PPID" {
+		t.Errorf("run = %q, want it left to the shell", cfg.PostHooks[0].Run)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ConfigFileName), []byte(`worktree_dir = "${WT_TEST_MISSING}/worktrees"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFromDir(dir); err == nil || !strings.Contains(err.Error(), "worktree_dir: $WT_TEST_MISSING is not set") {
+		t.Errorf("undefined variable: err = %v", err)
+	}
+
+	t.Setenv("TEST_TOKEN", "secret")
+	hookRuns := []struct {
+		section, run, want string
+	}{
+		{"post_hooks", "npm ci --token ${TEST_TOKEN} --branch $WT_BRANCH", ""},
+		{"pre_add_hooks", "echo ${WT_BRANCH}", ""},
+		{"post_hooks", "echo ${WT_BRANCH}", "post_hooks[0].run: ${WT_BRANCH} is not set"},
+		{"post_hooks", "for f in *; do echo $f ${f}; done", "post_hooks[0].run: ${f} is not set"},
+		{"post_hooks", "echo ${TEST_MISSING}", "post_hooks[0].run: ${TEST_MISSING} is not set"},
+		{"post_hooks", "echo '${TEST_MISSING}' \\${TEST_MISSING} ${TEST_TOKEN:-x}", ""},
+	}
+	for _, tt := range hookRuns {
+		content := fmt.Sprintf("[[%s]]\nrun = %q\n", tt.section, tt.run)
+		if err := os.WriteFile(filepath.Join(dir, ConfigFileName), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadFromDir(dir)
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("%s run = %q: err = %v, want %q", tt.section, tt.run, err, tt.want)
+		}
+	}
+}
+
+func TestStepsStringOrArray(t *testing.T) {
+	var cfg Config
+	content := "preprocess_script = \"a.sh\"\npreprocess_command = [\"one\", \"two --flag\"]\n"
+	if _, err := toml.Decode(content, &cfg); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if want := (Steps{"a.sh"}); !reflect.DeepEqual(cfg.PreprocessScript, want) {
+		t.Fatalf("preprocess_script = %q, want %q", cfg.PreprocessScript, want)
+	}
+	if want := (Steps{"one", "two --flag"}); !reflect.DeepEqual(cfg.PreprocessCommand, want) {
+		t.Fatalf("preprocess_command = %q, want %q", cfg.PreprocessCommand, want)
+	}
+}
+
+func TestBranchRulesCheck(t *testing.T) {
+	rules := BranchRules{
+		Allow:     []string{"^(feat|fix)/"},
+		Deny:      []string{"wip"},
+		MaxLength: 30,
+		Ticket:    "[A-Z]+-[0-9]+",
+	}
+	tests := []struct {
+		branch  string
+		wantErr string
+	}{
+		{branch: "feat/PROJ-12-login"},
+		{branch: "fix/PROJ-7"},
+		{branch: "feat/PROJ-12-a-very-long-description", wantErr: "branch_rules.max_length is 30"},
+		{branch: "feat/login", wantErr: "no ticket matching branch_rules.ticket"},
+		{branch: "feat/PROJ-12-wip", wantErr: "matches branch_rules.deny pattern \"wip\""},
+		{branch: "PROJ-12-login", wantErr: "matches none of the branch_rules.allow patterns (^(feat|fix)/)"},
+	}
+	for _, tt := range tests {
+		err := rules.Check(tt.branch)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("Check(%q): unexpected error: %v", tt.branch, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Check(%q) = %v, want error containing %q", tt.branch, err, tt.wantErr)
+		}
+	}
+
+	if err := (BranchRules{}).Check("anything goes"); err != nil {
+		t.Errorf("empty rules: %v", err)
+	}
+}
+
+func TestFindKeyLine(t *testing.T) {
+	content := `base_branch = "main"
+note = """
+path = "not a key"
+"""
+
+[packages.web]
+path = "web"
+
+[packages."api server"]
+path = "api"
+sanitize.lowercase = true
+
+[[post_hooks]]
+name = "x"
+run = "true"
+
+[sparse_profiles]
+docs = { paths = ["docs"] }
+`
+	tests := []struct {
+		key  toml.Key
+		want int
+	}{
+		{toml.Key{"base_branch"}, 1},
+		{toml.Key{"packages", "web", "path"}, 7},
+		{toml.Key{"packages", "api server", "path"}, 10},
+		{toml.Key{"packages", "api server", "sanitize", "lowercase"}, 11},
+		{toml.Key{"packages", "api server"}, 9},
+		{toml.Key{"post_hooks", "run"}, 15},
+		{toml.Key{"sparse_profiles", "docs", "paths"}, 18},
+		{toml.Key{"nope"}, 0},
+	}
+	for _, tt := range tests {
+		if got := findKeyLine(content, tt.key); got != tt.want {
+			t.Errorf("findKeyLine(%q) = %d, want %d", tt.key.String(), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ExpandVars replaces $NAME and ${NAME} in s with the value of the
+// environment variable NAME. $$ stands for a literal $, and a $ not followed
+// by a name is kept as is. A variable that is not set is an error, so a
+// typo doesn't quietly turn ${HOME}/worktrees into /worktrees.
+func ExpandVars(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		rest := s[i+1:]
+		if rest[0] == '$' {
+			b.WriteByte('$')
+			i++
+			continue
+		}
+		name, width := rest, 0
+		if rest[0] == '{' {
+			end := strings.IndexByte(rest, '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated ${ in %q", s)
+			}
+			name, width = rest[1:end], end+1
+			if !isVarName(name) {
+				return "", fmt.Errorf("invalid variable ${%s} in %q", name, s)
+			}
+		} else {
+			for width < len(rest) && isVarByte(rest[width], width == 0) {
+				width++
+			}
+			name = rest[:width]
+		}
+		if width == 0 {
+			b.WriteByte('$')
+			continue
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("$%s is not set (write $$ for a literal $)", name)
+		}
+		b.WriteString(value)
+		i += width
+	}
+	return b.String(), nil
+}
+
+func isVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isVarByte(name[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isVarByte(c byte, first bool) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || !first && '0' <= c && c <= '9'
+}
+
+// checkRunVars reports a ${NAME} in a hook's run command that names a
+// variable the environment doesn't set, as ExpandVars would. The command is
+// not expanded, since sh does that when the hook runs; with wtVars, as for
+// pre_add_hooks, the WT_* variables are set by then. $NAME is left for
+// variables the command sets itself. Like sh, it ignores what is
+// single-quoted or escaped with a backslash.
+func checkRunVars(run string, wtVars bool) error {
+	quoted := false
+	for i := 0; i < len(run); i++ {
+		switch {
+		case run[i] == '\'':
+			quoted = !quoted
+		case quoted:
+		case run[i] == '\\':
+			i++
+		case strings.HasPrefix(run[i:], "${"):
+			end := strings.IndexByte(run[i:], '}')
+			if end < 0 {
+				continue
+			}
+			name := run[i+2 : i+end]
+			if !isVarName(name) || wtVars && strings.HasPrefix(name, "WT_") {
+				continue
+			}
+			if _, ok := os.LookupEnv(name); !ok {
+				return fmt.Errorf("${%s} is not set (write $%s for a variable the command sets itself)", name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// expandVars expands environment variables in the settings that name paths.
+// Hook run commands are left alone, but checkRunVars vets their ${NAME}
+// references.
+func (c *Config) expandVars() error {
+	settings := []setting{
+		{"worktree_dir", &c.WorktreeDir},
+		{"template_dir", &c.TemplateDir},
+	}
+	lists := []struct {
+		key    string
+		values []string
+	}{
+		{"preprocess_script", c.PreprocessScript},
+		{"copy_patterns", c.CopyPatterns},
+		{"env_files", c.EnvFiles},
+		{"sparse_paths", c.SparsePaths},
+	}
+	// Packages are stored by value: expand copies and put them back.
+	packages := make(map[string]*Package, len(c.Packages))
+	for name, pkg := range c.Packages {
+		packages[name] = &pkg
+		settings = append(settings, setting{"packages." + name + ".path", &pkg.Path})
+		lists = append(lists, struct {
+			key    string
+			values []string
+		}{"packages." + name + ".copy_patterns", pkg.CopyPatterns})
+	}
+	for name, profile := range c.Profiles {
+		lists = append(lists, struct {
+			key    string
+			values []string
+		}{"profiles." + name + ".copy_patterns", profile.CopyPatterns}, struct {
+			key    string
+			values []string
+		}{"profiles." + name + ".sparse_paths", profile.SparsePaths})
+	}
+	for _, list := range lists {
+		for i := range list.values {
+			settings = append(settings, setting{fmt.Sprintf("%s[%d]", list.key, i), &list.values[i]})
+		}
+	}
+
+	hookLists := map[string][]Hook{
+		"pre_add_hooks":     c.PreAddHooks,
+		"post_hooks":        c.PostHooks,
+		"post_switch_hooks": c.PostSwitchHooks,
+	}
+	for name, list := range c.HookProfiles {
+		hookLists["hook_profiles."+name] = list
+	}
+	for name, pkg := range c.Packages {
+		hookLists["packages."+name+".post_hooks"] = pkg.PostHooks
+	}
+	for name, profile := range c.Profiles {
+		hookLists["profiles."+name+".post_hooks"] = profile.PostHooks
+	}
+	for section, hooks := range hookLists {
+		for i := range hooks {
+			key := fmt.Sprintf("%s[%d].", section, i)
+			settings = append(settings,
+				setting{key + "dir", &hooks[i].Dir},
+				setting{key + "if_exists", &hooks[i].IfExists},
+				setting{key + "if_changed", &hooks[i].IfChanged})
+			if err := checkRunVars(hooks[i].Run, section == "pre_add_hooks"); err != nil {
+				return fmt.Errorf("%srun: %w", key, err)
+			}
+		}
+	}
+
+	for _, s := range settings {
+		expanded, err := ExpandVars(*s.value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.key, err)
+		}
+		*s.value = expanded
+	}
+	for name, pkg := range packages {
+		c.Packages[name] = *pkg
+	}
+	return nil
+}
+
+// setting is a config value to expand, with its key for errors.
+type setting struct {
+	key   string
+	value *string
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Layers returns the config files that loading path applies, in order: the
+// files its include key lists, each preceded by its own includes, and then
+// path itself, so that every file overrides what it includes. Included paths
+// are relative to the including file, or to the home directory with a
+// leading ~/, and may use environment variables as in ExpandVars. A file
+// included twice is applied once, where it first appears.
+func Layers(path string) ([]string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	var layers []string
+	seen := make(map[string]bool)
+	if err := addLayers(path, nil, seen, &layers); err != nil {
+		return nil, err
+	}
+	return layers, nil
+}
+
+// addLayers appends the includes of path, then path, to layers. chain holds
+// the files including path, to report cycles.
+func addLayers(path string, chain []string, seen map[string]bool, layers *[]string) error {
+	for _, p := range chain {
+		if p == path {
+			return fmt.Errorf("include cycle: %s", strings.Join(append(chain, path), " -> "))
+		}
+	}
+	if seen[path] {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var includes struct {
+		Include []string `toml:"include"`
+	}
+	if _, err := toml.Decode(string(data), &includes); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, include := range includes.Include {
+		included, err := includePath(path, include)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(included); err != nil {
+			return fmt.Errorf("%s: include %q: %w", path, include, err)
+		}
+		if err := addLayers(included, append(chain, path), seen, layers); err != nil {
+			return err
+		}
+	}
+
+	seen[path] = true
+	*layers = append(*layers, path)
+	return nil
+}
+
+// includePath resolves an include of the config file at from.
+func includePath(from, include string) (string, error) {
+	include, err := ExpandVars(include)
+	if err != nil {
+		return "", fmt.Errorf("%s: include: %w", from, err)
+	}
+	if rest, ok := strings.CutPrefix(include, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, rest), nil
+	}
+	if filepath.IsAbs(include) {
+		return filepath.Clean(include), nil
+	}
+	return filepath.Join(filepath.Dir(from), include), nil
+}
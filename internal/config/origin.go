@@ -0,0 +1,130 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Origin is where a setting was defined: a config file and line, or the
+// built-in default when Path is empty.
+type Origin struct {
+	Path string
+	Line int
+}
+
+func (o Origin) String() string {
+	switch {
+	case o.Path == "":
+		return "default"
+	case o.Line == 0:
+		return o.Path
+	}
+	return fmt.Sprintf("%s:%d", o.Path, o.Line)
+}
+
+// Origins maps settings to the config file that set them. Keys are top-level
+// names ("base_branch"), table entries ("packages.api"), and hooks by
+// position ("post_hooks[1]", "packages.api.post_hooks[0]").
+type Origins map[string]Origin
+
+// LoadOrigins reads the config files applied to a Config (its Sources, in
+// order) and records where each setting comes from. As when loading, a later
+// file wins: arrays such as copy_patterns and post_hooks are replaced whole,
+// tables such as packages are merged per entry.
+func LoadOrigins(sources []string) (Origins, error) {
+	origins := make(Origins)
+	for _, path := range sources {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		content := string(data)
+
+		var raw map[string]any
+		md, err := toml.Decode(content, &raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		for _, key := range md.Keys() {
+			if len(key) > 2 {
+				continue
+			}
+			if _, isTable := raw[key[0]].(map[string]any); len(key) == 2 && !isTable {
+				continue // a field of an array table entry such as post_hooks.name
+			}
+			origins[key.String()] = Origin{Path: path, Line: findKeyLine(content, key)}
+		}
+
+		for _, section := range hookSections(raw) {
+			for key := range origins {
+				if strings.HasPrefix(key, section+"[") {
+					delete(origins, key)
+				}
+			}
+			for i, line := range arrayTableLines(content, section) {
+				origins[fmt.Sprintf("%s[%d]", section, i)] = Origin{Path: path, Line: line}
+			}
+		}
+	}
+	return origins, nil
+}
+
+// Lookup returns the origin of key, falling back to the entry or table that
+// contains it ("packages.api.path" is set where "packages.api" is).
+func (o Origins) Lookup(key string) Origin {
+	for {
+		if origin, ok := o[key]; ok {
+			return origin
+		}
+		i := strings.LastIndexAny(key, ".[")
+		if i < 0 {
+			return Origin{}
+		}
+		key = key[:i]
+	}
+}
+
+// hookSections lists the hook arrays defined in a decoded config file.
+func hookSections(raw map[string]any) []string {
+	var sections []string
+	for _, name := range []string{"pre_add_hooks", "post_hooks", "post_switch_hooks"} {
+		if _, ok := raw[name]; ok {
+			sections = append(sections, name)
+		}
+	}
+	profiles, _ := raw["hook_profiles"].(map[string]any)
+	for name := range profiles {
+		sections = append(sections, "hook_profiles."+name)
+	}
+	for _, table := range []string{"packages", "profiles"} {
+		entries, _ := raw[table].(map[string]any)
+		for name, entry := range entries {
+			if fields, ok := entry.(map[string]any); ok {
+				if _, ok := fields["post_hooks"]; ok {
+					sections = append(sections, table+"."+name+".post_hooks")
+				}
+			}
+		}
+	}
+	sort.Strings(sections)
+	return sections
+}
+
+// arrayTableLines returns the 1-based lines of the [[section]] headers in
+// content, in order.
+func arrayTableLines(content, section string) []int {
+	re := regexp.MustCompile(`^[ \t]*\[\[[ \t]*` + regexp.QuoteMeta(section) + `[ \t]*\]\]`)
+	var lines []int
+	for i, line := range strings.Split(content, "\n") {
+		if re.MatchString(line) {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOrigins(t *testing.T) {
+	dir := t.TempDir()
+	global := filepath.Join(dir, "global.toml")
+	repo := filepath.Join(dir, ".wt.toml")
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(global, "base_branch = \"develop\"\n\n[[post_hooks]]\nname = \"a\"\nrun = \"true\"\n\n[[post_hooks]]\nname = \"b\"\nrun = \"true\"\n\n[packages.api]\npath = \"apps/api\"\n")
+	writeFile(repo, "worktree_dir = \".wt\"\n\n[[post_hooks]]\nname = \"c\"\nrun = \"true\"\n\n[packages.web]\npath = \"apps/web\"\n\n[[packages.web.post_hooks]]\nname = \"d\"\nrun = \"true\"\n")
+
+	origins, err := LoadOrigins([]string{global, repo})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"base_branch", global + ":1"},
+		{"worktree_dir", repo + ":1"},
+		{"post_hooks[0]", repo + ":3"},
+		{"packages.api", global + ":11"},
+		{"packages.api.path", global + ":11"},
+		{"packages.web", repo + ":7"},
+		{"packages.web.post_hooks[0]", repo + ":10"},
+		{"theme", "default"},
+	}
+	if _, ok := origins["post_hooks[1]"]; ok {
+		t.Error("post_hooks[1] of the global config should be replaced by the repo's hooks")
+	}
+	for _, tt := range tests {
+		if got := origins.Lookup(tt.key).String(); got != tt.want {
+			t.Errorf("Lookup(%q) = %s, want %s", tt.key, got, tt.want)
+		}
+	}
+}
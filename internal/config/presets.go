@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// nodeInstall installs exactly what the lockfile pins, with whichever package
+// manager the lockfile belongs to.
+const nodeInstall = `if [ -f pnpm-lock.yaml ]; then pnpm install --frozen-lockfile; ` +
+	`elif [ -f yarn.lock ]; then yarn install --frozen-lockfile; ` +
+	`elif [ -f bun.lock ] || [ -f bun.lockb ]; then bun install --frozen-lockfile; ` +
+	`elif [ -f package-lock.json ]; then npm ci; ` +
+	`else npm install; fi`
+
+// HookPresets are the hooks a hook entry can name with use = "<preset>"
+// instead of spelling them out. Fields the entry sets itself override the
+// preset's.
+var HookPresets = map[string]Hook{
+	"node-install":      {Name: "Install Node dependencies", Run: nodeInstall, IfExists: "package.json"},
+	"bundler":           {Name: "Install gems", Run: "bundle install", IfExists: "Gemfile"},
+	"go-mod-download":   {Name: "Download Go modules", Run: "go mod download", IfExists: "go.mod"},
+	"rails-db-prepare":  {Name: "Prepare the database", Run: "bin/rails db:prepare", IfExists: "bin/rails"},
+	"docker-compose-up": {Name: "Start services", Run: "docker compose up -d", IfExists: "docker-compose.yml"},
+}
+
+// HookPresetNames returns the names of the hook presets, sorted.
+func HookPresetNames() []string {
+	names := make([]string, 0, len(HookPresets))
+	for name := range HookPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// expandPresets fills in the hooks of every hook list that use a preset.
+func (c *Config) expandPresets() error {
+	if err := expandHooks("post_hooks", c.PostHooks); err != nil {
+		return err
+	}
+	if err := expandHooks("pre_add_hooks", c.PreAddHooks); err != nil {
+		return err
+	}
+	if err := expandHooks("post_switch_hooks", c.PostSwitchHooks); err != nil {
+		return err
+	}
+	for name, list := range c.HookProfiles {
+		if err := expandHooks("hook_profiles."+name, list); err != nil {
+			return err
+		}
+	}
+	for name, pkg := range c.Packages {
+		if err := expandHooks("packages."+name+".post_hooks", pkg.PostHooks); err != nil {
+			return err
+		}
+	}
+	for name, profile := range c.Profiles {
+		if err := expandHooks("profiles."+name+".post_hooks", profile.PostHooks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandHooks fills in, in place, the fields hooks leave empty from the
+// presets they use.
+func expandHooks(section string, hooks []Hook) error {
+	for i, hook := range hooks {
+		if hook.Use == "" {
+			continue
+		}
+		preset, ok := HookPresets[hook.Use]
+		if !ok {
+			return fmt.Errorf("%s[%d]: unknown hook preset %q (available: %s)", section, i, hook.Use, strings.Join(HookPresetNames(), ", "))
+		}
+		if hook.Name == "" {
+			hook.Name = preset.Name
+		}
+		if hook.Run == "" {
+			hook.Run = preset.Run
+		}
+		if hook.Dir == "" {
+			hook.Dir = preset.Dir
+		}
+		if hook.IfExists == "" {
+			hook.IfExists = preset.IfExists
+		}
+		if hook.IfBranch == "" {
+			hook.IfBranch = preset.IfBranch
+		}
+		if hook.IfChanged == "" {
+			hook.IfChanged = preset.IfChanged
+		}
+		hooks[i] = hook
+	}
+	return nil
+}
@@ -11,12 +11,42 @@ import (
 	"strings"
 
 	"github.com/bmatcuk/doublestar/v4"
+
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/runner"
 )
 
-// CopyFiles copies files matching the given patterns from srcDir to destDir.
-func CopyFiles(patterns []string, srcDir, destDir string) error {
+// Options tunes CopyFilesWith.
+type Options struct {
+	// Update overwrites files that already exist in destDir when the source
+	// was modified more recently, instead of leaving them alone.
+	Update bool
+	// Overwrite replaces files that already exist in destDir regardless of
+	// their age.
+	Overwrite bool
+}
+
+// CopyFiles copies files matching the given patterns from srcDir to destDir
+// and returns the paths it copied, relative to srcDir.
+func CopyFiles(patterns []string, srcDir, destDir string) ([]string, error) {
+	return CopyFilesWith(patterns, srcDir, destDir, Options{})
+}
+
+// CopyFilesWith is CopyFiles with options.
+func CopyFilesWith(patterns []string, srcDir, destDir string, opts Options) ([]string, error) {
+	paths, err := Matches(patterns, srcDir)
+	if err != nil {
+		return nil, err
+	}
+	return CopyPaths(paths, srcDir, destDir, opts)
+}
+
+// Matches returns the paths in srcDir, relative to it and sorted, that the
+// patterns select for copying. Paths inside a matched directory are left
+// out, as the directory is copied whole.
+func Matches(patterns []string, srcDir string) ([]string, error) {
 	if len(patterns) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	var includePatterns, excludePatterns []string
@@ -32,8 +62,9 @@ func CopyFiles(patterns []string, srcDir, destDir string) error {
 	for _, pattern := range includePatterns {
 		found, err := findMatches(srcDir, pattern)
 		if err != nil {
-			return fmt.Errorf("error matching pattern %q: %w", pattern, err)
+			return nil, fmt.Errorf("error matching pattern %q: %w", pattern, err)
 		}
+		log.Explainf("copy: %q matched %d path(s) in %s", pattern, len(found), srcDir)
 		for _, f := range found {
 			if f == "" {
 				continue
@@ -45,8 +76,9 @@ func CopyFiles(patterns []string, srcDir, destDir string) error {
 	for _, pattern := range excludePatterns {
 		excluded, err := findMatches(srcDir, pattern)
 		if err != nil {
-			return fmt.Errorf("error matching exclude pattern %q: %w", pattern, err)
+			return nil, fmt.Errorf("error matching exclude pattern %q: %w", pattern, err)
 		}
+		log.Explainf("copy: exclude %q matched %d path(s)", pattern, len(excluded))
 		for _, f := range excluded {
 			delete(matches, f)
 		}
@@ -54,21 +86,34 @@ func CopyFiles(patterns []string, srcDir, destDir string) error {
 
 	paths := filterDescendants(matches, srcDir)
 	sort.Strings(paths)
+	return paths, nil
+}
 
+// CopyPaths copies the given paths, relative to srcDir, to destDir and
+// returns the ones it copied.
+func CopyPaths(paths []string, srcDir, destDir string, opts Options) ([]string, error) {
+	var done []string
 	for _, relPath := range paths {
 		srcPath := filepath.Join(srcDir, relPath)
 		destPath := filepath.Join(destDir, relPath)
 
-		copied, err := copyPath(srcPath, destPath)
+		copied, err := copyPath(srcPath, destPath, opts)
 		if err != nil {
-			return fmt.Errorf("failed to copy %q: %w", relPath, err)
+			return nil, fmt.Errorf("failed to copy %q: %w", relPath, err)
 		}
 		if copied {
-			fmt.Fprintf(os.Stderr, "Copied: %s\n", relPath)
+			done = append(done, relPath)
+			if status := lockfileStatus(relPath, srcDir, destDir); status != "" {
+				log.Infof("Copied: %s (%s)", relPath, status)
+			} else {
+				log.Infof("Copied: %s", relPath)
+			}
+		} else {
+			log.Explainf("copy: skipped %s (already present in the worktree)", relPath)
 		}
 	}
 
-	return nil
+	return done, nil
 }
 
 func normalizeRelPath(p string) string {
@@ -105,15 +150,16 @@ func filterDescendants(matches map[string]bool, baseDir string) []string {
 			continue
 		}
 
-		isDescendant := false
+		parent := ""
 		for dir := range keptDirs {
 			if strings.HasPrefix(p, dir+string(filepath.Separator)) {
-				isDescendant = true
+				parent = dir
 				break
 			}
 		}
 
-		if isDescendant {
+		if parent != "" {
+			log.Explainf("copy: skipped %s (inside %s, which is copied whole)", p, parent)
 			continue
 		}
 
@@ -162,8 +208,9 @@ func findMatches(baseDir, pattern string) ([]string, error) {
 	return matches, err
 }
 
-// copyPath copies src to dest. Returns true if a copy was performed, false if skipped.
-func copyPath(src, dest string) (bool, error) {
+// copyPath copies src to dest. Returns true if a copy was performed, false if
+// skipped. Existing files are replaced as opts asks.
+func copyPath(src, dest string, opts Options) (bool, error) {
 	srcInfo, err := os.Lstat(src)
 	if err != nil {
 		return false, err
@@ -189,7 +236,13 @@ func copyPath(src, dest string) (bool, error) {
 
 	// For files/symlinks: skip if destination already exists (may have been copied as part of a parent directory)
 	if destExists && !srcIsDir {
-		return false, nil
+		if !opts.Overwrite && (!opts.Update || !srcInfo.ModTime().After(destInfo.ModTime())) {
+			return false, nil
+		}
+		if err := os.Remove(dest); err != nil {
+			return false, err
+		}
+		return true, copyFile(src, dest, srcInfo.Mode())
 	}
 
 	parentDir := filepath.Dir(dest)
@@ -207,6 +260,9 @@ func copyPath(src, dest string) (bool, error) {
 		// If destination directory already exists (e.g., from git checkout with tracked files),
 		// merge contents instead of skipping.
 		if destExists && destIsDir {
+			if opts.Update || opts.Overwrite {
+				return updateDirContents(src, dest, opts)
+			}
 			return true, mergeDirContents(src, dest)
 		}
 		return true, copyDir(src, dest)
@@ -219,13 +275,13 @@ func copyDir(src, dest string) error {
 	switch runtime.GOOS {
 	case "darwin":
 		// Try copy-on-write on macOS (APFS)
-		if err := exec.Command("cp", "-c", "-R", "-P", "-p", src, dest).Run(); err == nil {
+		if err := runner.Run(exec.Command("cp", "-c", "-R", "-P", "-p", src, dest)); err == nil {
 			return nil
 		}
 		return runWithOutput("cp", "-R", "-P", "-p", src, dest)
 	case "linux":
 		// Try copy-on-write on Btrfs/XFS
-		if err := exec.Command("cp", "-R", "-P", "-p", "--reflink=auto", src, dest).Run(); err == nil {
+		if err := runner.Run(exec.Command("cp", "-R", "-P", "-p", "--reflink=auto", src, dest)); err == nil {
 			return nil
 		}
 		return runWithOutput("cp", "-R", "-P", "-p", src, dest)
@@ -236,7 +292,7 @@ func copyDir(src, dest string) error {
 
 func runWithOutput(name string, args ...string) error {
 	cmd := exec.Command(name, args...)
-	output, err := cmd.CombinedOutput()
+	output, err := runner.CombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("%w: %s", err, string(output))
 	}
@@ -249,7 +305,7 @@ func mergeDirContents(src, dest string) error {
 	srcContents := src + string(filepath.Separator) + "."
 
 	cmd := exec.Command("cp", "-R", "-P", "-p", "-n", srcContents, dest)
-	output, err := cmd.CombinedOutput()
+	output, err := runner.CombinedOutput(cmd)
 	if err != nil {
 		outStr := string(output)
 		// macOS: cp -n returns exit code 1 when it skips files.
@@ -267,17 +323,36 @@ func mergeDirContents(src, dest string) error {
 	return nil
 }
 
+// updateDirContents copies the files of src that are missing from dest or
+// that opts says to replace there, reporting whether it copied any.
+func updateDirContents(src, dest string, opts Options) (bool, error) {
+	updated := false
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		copied, err := copyPath(path, filepath.Join(dest, rel), opts)
+		updated = updated || copied
+		return err
+	})
+	return updated, err
+}
+
 func copyFile(src, dest string, mode fs.FileMode) error {
 	switch runtime.GOOS {
 	case "darwin":
 		// Try copy-on-write on macOS (APFS)
-		if err := exec.Command("cp", "-c", "-P", "-p", src, dest).Run(); err == nil {
+		if err := runner.Run(exec.Command("cp", "-c", "-P", "-p", src, dest)); err == nil {
 			return nil
 		}
 		return runWithOutput("cp", "-P", "-p", src, dest)
 	case "linux":
 		// Try copy-on-write on Btrfs/XFS
-		if err := exec.Command("cp", "-P", "-p", "--reflink=auto", src, dest).Run(); err == nil {
+		if err := runner.Run(exec.Command("cp", "-P", "-p", "--reflink=auto", src, dest)); err == nil {
 			return nil
 		}
 		return runWithOutput("cp", "-P", "-p", src, dest)
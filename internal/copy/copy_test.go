@@ -5,7 +5,9 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestFindMatches_GlobPatternWithTrailingSlash(t *testing.T) {
@@ -228,7 +230,7 @@ func TestCopyFiles_MergesIntoExistingDir(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := CopyFiles([]string{".certs"}, srcDir, destDir); err != nil {
+	if _, err := CopyFiles([]string{".certs"}, srcDir, destDir); err != nil {
 		t.Fatalf("CopyFiles failed: %v", err)
 	}
 
@@ -252,6 +254,78 @@ func TestCopyFiles_MergesIntoExistingDir(t *testing.T) {
 	}
 }
 
+func TestCopyFilesWith_UpdateAndOverwrite(t *testing.T) {
+	srcDir := t.TempDir()
+	destDir := t.TempDir()
+	old := time.Now().Add(-time.Hour)
+
+	write := func(dir, rel, content string, mtime time.Time) {
+		t.Helper()
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(srcDir, ".env", "new", time.Now())
+	write(destDir, ".env", "stale", old)
+	write(srcDir, "config/local.yml", "new", time.Now())
+	write(destDir, "config/local.yml", "stale", old)
+	write(srcDir, "config/keep.yml", "source", old)
+	write(destDir, "config/keep.yml", "edited", time.Now())
+	write(srcDir, "config/added.yml", "added", old)
+
+	patterns := []string{".env", "config"}
+	copied, err := CopyFiles(patterns, srcDir, destDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(copied) != 1 || copied[0] != "config" {
+		t.Errorf("without Update: copied %v, want only the missing file merged into config", copied)
+	}
+	if got, _ := os.ReadFile(filepath.Join(destDir, ".env")); string(got) != "stale" {
+		t.Errorf("without Update: .env = %q, want it left alone", got)
+	}
+
+	copied, err = CopyFilesWith(patterns, srcDir, destDir, Options{Update: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{".env", "config"}; strings.Join(copied, ",") != strings.Join(want, ",") {
+		t.Errorf("copied %v, want %v", copied, want)
+	}
+	for rel, want := range map[string]string{
+		".env":             "new",
+		"config/local.yml": "new",
+		"config/keep.yml":  "edited",
+		"config/added.yml": "added",
+	} {
+		if got, _ := os.ReadFile(filepath.Join(destDir, rel)); string(got) != want {
+			t.Errorf("%s = %q, want %q", rel, got, want)
+		}
+	}
+
+	copied, err = CopyFilesWith(patterns, srcDir, destDir, Options{Update: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(copied) != 0 {
+		t.Errorf("second update copied %v, want nothing", copied)
+	}
+
+	if _, err := CopyFilesWith(patterns, srcDir, destDir, Options{Overwrite: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(destDir, "config/keep.yml")); string(got) != "source" {
+		t.Errorf("with Overwrite: config/keep.yml = %q, want the source", got)
+	}
+}
+
 func TestCopyFiles_DestinationConflict_FileOverDir(t *testing.T) {
 	srcDir := t.TempDir()
 	destDir := t.TempDir()
@@ -263,7 +337,7 @@ func TestCopyFiles_DestinationConflict_FileOverDir(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := CopyFiles([]string{"conflict"}, srcDir, destDir); err == nil {
+	if _, err := CopyFiles([]string{"conflict"}, srcDir, destDir); err == nil {
 		t.Fatal("expected error due to destination conflict, got nil")
 	}
 }
@@ -283,7 +357,7 @@ func TestCopyFiles_DoesNotFollowSymlink(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := CopyFiles([]string{"link"}, srcDir, destDir); err != nil {
+	if _, err := CopyFiles([]string{"link"}, srcDir, destDir); err != nil {
 		t.Fatalf("CopyFiles failed: %v", err)
 	}
 
@@ -336,8 +410,10 @@ func TestCopyFiles_ProgressToStderr_DeterministicOrder(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	var copied []string
 	out := captureStderr(t, func() {
-		if err := CopyFiles([]string{"b.txt", "a.txt"}, srcDir, destDir); err != nil {
+		var err error
+		if copied, err = CopyFiles([]string{"b.txt", "a.txt"}, srcDir, destDir); err != nil {
 			t.Fatalf("CopyFiles failed: %v", err)
 		}
 	})
@@ -346,6 +422,9 @@ func TestCopyFiles_ProgressToStderr_DeterministicOrder(t *testing.T) {
 	if out != want {
 		t.Fatalf("unexpected stderr.\nGot:\n%s\nWant:\n%s", out, want)
 	}
+	if got := strings.Join(copied, ","); got != "a.txt,b.txt" {
+		t.Fatalf("CopyFiles returned %q, want a.txt,b.txt", got)
+	}
 }
 
 func TestCopyFiles_DestinationConflict_DirOverFile(t *testing.T) {
@@ -362,7 +441,7 @@ func TestCopyFiles_DestinationConflict_DirOverFile(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := CopyFiles([]string{"conflict"}, srcDir, destDir); err == nil {
+	if _, err := CopyFiles([]string{"conflict"}, srcDir, destDir); err == nil {
 		t.Fatal("expected error due to destination conflict, got nil")
 	}
 }
@@ -384,7 +463,7 @@ func TestCopyFiles_DirCopy_DoesNotFollowSymlink(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := CopyFiles([]string{"d"}, srcDir, destDir); err != nil {
+	if _, err := CopyFiles([]string{"d"}, srcDir, destDir); err != nil {
 		t.Fatalf("CopyFiles failed: %v", err)
 	}
 
@@ -423,7 +502,7 @@ func TestCopyFiles_MergeDir_DoesNotFollowSymlink(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := CopyFiles([]string{"d"}, srcDir, destDir); err != nil {
+	if _, err := CopyFiles([]string{"d"}, srcDir, destDir); err != nil {
 		t.Fatalf("CopyFiles failed: %v", err)
 	}
 
@@ -439,3 +518,67 @@ func TestCopyFiles_MergeDir_DoesNotFollowSymlink(t *testing.T) {
 		t.Fatalf("expected dest/d/link/file.txt to not exist (symlink not followed), err=%v", err)
 	}
 }
+
+func TestLockfileStatus(t *testing.T) {
+	srcDir, destDir := t.TempDir(), t.TempDir()
+	write := func(dir, name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(srcDir, "package-lock.json", "v1")
+	write(destDir, "package-lock.json", "v1")
+	write(srcDir, "apps/web/pnpm-lock.yaml", "v1")
+	write(destDir, "apps/web/pnpm-lock.yaml", "v2")
+	write(srcDir, "Gemfile.lock", "gems")
+
+	tests := []struct {
+		relPath string
+		want    string
+	}{
+		{"node_modules", "package-lock.json unchanged"},
+		{"apps/web/node_modules", "stale: pnpm-lock.yaml differs from the copy source"},
+		{"vendor/bundle", "stale: Gemfile.lock differs from the copy source"},
+		{"apps/api/node_modules", ""},
+		{"my_node_modules", ""},
+		{".env", ""},
+	}
+	for _, tt := range tests {
+		if got := lockfileStatus(tt.relPath, srcDir, destDir); got != tt.want {
+			t.Errorf("lockfileStatus(%q) = %q, want %q", tt.relPath, got, tt.want)
+		}
+	}
+}
+
+func TestMissingDepDir(t *testing.T) {
+	srcDir, destDir := t.TempDir(), t.TempDir()
+	for _, dir := range []string{"node_modules", "apps/web/node_modules", "vendor/bundle"} {
+		if err := os.MkdirAll(filepath.Join(srcDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(destDir, "node_modules"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		lockfile string
+		want     string
+	}{
+		{"package-lock.json", ""},
+		{"apps/web/pnpm-lock.yaml", filepath.Join("apps", "web", "node_modules")},
+		{"Gemfile.lock", filepath.Join("vendor", "bundle")},
+		{"apps/api/yarn.lock", ""},
+		{"requirements.txt", ""},
+	}
+	for _, tt := range tests {
+		if got := MissingDepDir(tt.lockfile, srcDir, destDir); got != tt.want {
+			t.Errorf("MissingDepDir(%q) = %q, want %q", tt.lockfile, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package copy
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// lockfiles maps dependency directories to the lockfiles that pin their
+// contents, looked up next to the directory.
+var lockfiles = []struct {
+	depDir    string
+	lockfiles []string
+}{
+	{"node_modules", []string{"package-lock.json", "npm-shrinkwrap.json", "pnpm-lock.yaml", "yarn.lock", "bun.lock"}},
+	{"vendor/bundle", []string{"Gemfile.lock"}},
+	{".bundle", []string{"Gemfile.lock"}},
+}
+
+// MissingDepDir returns a dependency directory that lockfile, a path
+// relative to both srcDir and destDir, pins and that srcDir has but destDir
+// lacks, e.g. node_modules that wasn't copied. It returns "" when there is
+// none.
+func MissingDepDir(lockfile, srcDir, destDir string) string {
+	parent, name := filepath.Split(lockfile)
+	for _, entry := range lockfiles {
+		for _, candidate := range entry.lockfiles {
+			if candidate != name {
+				continue
+			}
+			depDir := filepath.Join(parent, filepath.FromSlash(entry.depDir))
+			if exists(filepath.Join(srcDir, depDir)) && !exists(filepath.Join(destDir, depDir)) {
+				return depDir
+			}
+		}
+	}
+	return ""
+}
+
+// Changed reports whether the file at dest differs from the one at src. A
+// file present on only one side counts as changed; missing on both does not.
+func Changed(src, dest string) (bool, error) {
+	srcData, srcErr := os.ReadFile(src)
+	destData, destErr := os.ReadFile(dest)
+	srcMissing, destMissing := errors.Is(srcErr, fs.ErrNotExist), errors.Is(destErr, fs.ErrNotExist)
+	if srcErr != nil && !srcMissing {
+		return false, srcErr
+	}
+	if destErr != nil && !destMissing {
+		return false, destErr
+	}
+	if srcMissing || destMissing {
+		return srcMissing != destMissing, nil
+	}
+	return !bytes.Equal(srcData, destData), nil
+}
+
+// lockfileStatus describes whether a copied dependency directory still matches
+// the new worktree's lockfile, e.g. "package-lock.json unchanged". It returns
+// "" for paths that aren't dependency directories or have no lockfile.
+func lockfileStatus(relPath, srcDir, destDir string) string {
+	relPath = filepath.ToSlash(relPath)
+	for _, entry := range lockfiles {
+		parent, ok := strings.CutSuffix(relPath, entry.depDir)
+		if !ok || (parent != "" && !strings.HasSuffix(parent, "/")) {
+			continue
+		}
+		for _, name := range entry.lockfiles {
+			lockPath := filepath.FromSlash(parent + name)
+			srcLock, destLock := filepath.Join(srcDir, lockPath), filepath.Join(destDir, lockPath)
+			if !exists(srcLock) && !exists(destLock) {
+				continue
+			}
+			changed, err := Changed(srcLock, destLock)
+			switch {
+			case err != nil:
+				return "could not compare " + name + ": " + err.Error()
+			case changed:
+				return "stale: " + name + " differs from the copy source"
+			default:
+				return name + " unchanged"
+			}
+		}
+	}
+	return ""
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,142 @@
+// Package detect inspects a repository for well-known project files and
+// suggests copy_patterns and post_hooks for `wt init --detect`.
+package detect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/default-anton/wt/internal/config"
+)
+
+// Result holds the suggested settings and a line per finding explaining them.
+type Result struct {
+	CopyPatterns []string      `toml:"copy_patterns,omitempty"`
+	PostHooks    []config.Hook `toml:"post_hooks,omitempty"`
+	Findings     []string      `toml:"-"`
+}
+
+// nodeInstallers lists Node lockfiles in order of preference with the
+// command that installs exactly what they pin.
+var nodeInstallers = []struct{ lockfile, run string }{
+	{"pnpm-lock.yaml", "pnpm install --frozen-lockfile"},
+	{"yarn.lock", "yarn install --frozen-lockfile"},
+	{"bun.lock", "bun install --frozen-lockfile"},
+	{"bun.lockb", "bun install --frozen-lockfile"},
+	{"package-lock.json", "npm ci"},
+}
+
+// envExamples are committed templates of env files, which never need copying.
+var envExamples = []string{".env.example", ".env.sample", ".env.template"}
+
+// Detect inspects the repository checked out at dir.
+func Detect(dir string) Result {
+	var r Result
+	has := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	if has("package.json") {
+		hook := config.Hook{Name: "Install Node dependencies", Run: "npm install"}
+		finding := "package.json without a lockfile: npm install"
+		for _, installer := range nodeInstallers {
+			if has(installer.lockfile) {
+				hook.Run, hook.IfChanged = installer.run, installer.lockfile
+				finding = "package.json with " + installer.lockfile + ": " + installer.run + " when the lockfile differs from the copied node_modules"
+				break
+			}
+		}
+		r.CopyPatterns = append(r.CopyPatterns, "node_modules")
+		r.PostHooks = append(r.PostHooks, hook)
+		r.Findings = append(r.Findings, finding)
+	}
+
+	if has("Gemfile") {
+		hook := config.Hook{Name: "Install gems", Run: "bundle install"}
+		finding := "Gemfile: bundle install"
+		if has("Gemfile.lock") {
+			hook.IfChanged = "Gemfile.lock"
+			finding += " when Gemfile.lock changes"
+		}
+		for _, dir := range []string{".bundle", "vendor/bundle"} {
+			if has(dir) {
+				r.CopyPatterns = append(r.CopyPatterns, dir)
+				finding += ", copying " + dir
+			}
+		}
+		r.PostHooks = append(r.PostHooks, hook)
+		r.Findings = append(r.Findings, finding)
+	}
+
+	if has("go.mod") {
+		hook := config.Hook{Name: "Download Go modules", Run: "go mod download"}
+		finding := "go.mod: go mod download"
+		if has("go.sum") {
+			hook.IfChanged = "go.sum"
+			finding += " when go.sum changes"
+		}
+		r.PostHooks = append(r.PostHooks, hook)
+		r.Findings = append(r.Findings, finding)
+	}
+
+	for _, name := range []string{"docker-compose.override.yml", "docker-compose.override.yaml", "compose.override.yml", "compose.override.yaml"} {
+		if has(name) {
+			r.CopyPatterns = append(r.CopyPatterns, name)
+			r.Findings = append(r.Findings, name+": copying local compose overrides")
+		}
+	}
+
+	if envs := envFiles(dir); len(envs) > 0 {
+		r.CopyPatterns = append(r.CopyPatterns, ".env*")
+		for _, example := range envExamples {
+			if has(example) {
+				r.CopyPatterns = append(r.CopyPatterns, "!"+example)
+			}
+		}
+		r.Findings = append(r.Findings, strings.Join(envs, ", ")+": copying .env files")
+	}
+
+	return r
+}
+
+// envFiles returns the env files in dir, leaving out committed examples.
+func envFiles(dir string) []string {
+	matches, _ := filepath.Glob(filepath.Join(dir, ".env*"))
+	var names []string
+	for _, m := range matches {
+		name := filepath.Base(m)
+		if info, err := os.Stat(m); err != nil || info.IsDir() {
+			continue
+		}
+		isExample := false
+		for _, example := range envExamples {
+			if name == example {
+				isExample = true
+			}
+		}
+		if !isExample {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Empty reports whether nothing was detected.
+func (r Result) Empty() bool {
+	return len(r.CopyPatterns) == 0 && len(r.PostHooks) == 0
+}
+
+// TOML renders the suggested settings as config file entries.
+func (r Result) TOML() (string, error) {
+	var b strings.Builder
+	enc := toml.NewEncoder(&b)
+	enc.Indent = ""
+	if err := enc.Encode(r); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
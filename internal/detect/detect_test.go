@@ -0,0 +1,112 @@
+package detect
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/default-anton/wt/internal/config"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        []string
+		copyPatterns []string
+		hooks        []config.Hook
+	}{
+		{
+			name:         "pnpm",
+			files:        []string{"package.json", "pnpm-lock.yaml", "package-lock.json"},
+			copyPatterns: []string{"node_modules"},
+			hooks:        []config.Hook{{Name: "Install Node dependencies", Run: "pnpm install --frozen-lockfile", IfChanged: "pnpm-lock.yaml"}},
+		},
+		{
+			name:         "npm without lockfile",
+			files:        []string{"package.json"},
+			copyPatterns: []string{"node_modules"},
+			hooks:        []config.Hook{{Name: "Install Node dependencies", Run: "npm install"}},
+		},
+		{
+			name:         "bundler with vendored gems",
+			files:        []string{"Gemfile", "Gemfile.lock", "vendor/bundle/ruby"},
+			copyPatterns: []string{"vendor/bundle"},
+			hooks:        []config.Hook{{Name: "Install gems", Run: "bundle install", IfChanged: "Gemfile.lock"}},
+		},
+		{
+			name:         "env files and compose override",
+			files:        []string{".env.local", ".env.sample", "docker-compose.yml", "docker-compose.override.yml"},
+			copyPatterns: []string{"docker-compose.override.yml", ".env*", "!.env.sample"},
+		},
+		{
+			name:  "only an env example",
+			files: []string{".env.example", "README.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, f := range tt.files {
+				path := filepath.Join(dir, f)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			r := Detect(dir)
+			if !reflect.DeepEqual(r.CopyPatterns, tt.copyPatterns) {
+				t.Errorf("CopyPatterns = %q, want %q", r.CopyPatterns, tt.copyPatterns)
+			}
+			if !reflect.DeepEqual(r.PostHooks, tt.hooks) {
+				t.Errorf("PostHooks = %+v, want %+v", r.PostHooks, tt.hooks)
+			}
+			if r.Empty() != (len(tt.copyPatterns) == 0 && len(tt.hooks) == 0) {
+				t.Errorf("Empty() = %v", r.Empty())
+			}
+		})
+	}
+}
+
+func TestWorktrees(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "app")
+	touch := func(path string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{".env", ".envrc", ".gitignore", ".tool-versions", "README.md"} {
+		touch(filepath.Join(root, name))
+	}
+	paths := []string{
+		filepath.Join(base, "app-wt", "one"),
+		filepath.Join(base, "app-wt", "two"),
+		filepath.Join(base, "elsewhere", "three"),
+	}
+	for _, path := range paths {
+		for _, name := range []string{".env", ".gitignore", "README.md"} {
+			touch(filepath.Join(path, name))
+		}
+	}
+	touch(filepath.Join(paths[0], ".envrc"))
+
+	l := Worktrees(root, paths, map[string]bool{".gitignore": true, "README.md": true})
+	if l.WorktreeDir != "../app-wt" {
+		t.Errorf("WorktreeDir = %q, want ../app-wt", l.WorktreeDir)
+	}
+	if want := []string{".env"}; !reflect.DeepEqual(l.CopyPatterns, want) {
+		t.Errorf("CopyPatterns = %q, want %q", l.CopyPatterns, want)
+	}
+
+	if l := Worktrees(root, nil, nil); l.WorktreeDir != "" || l.CopyPatterns != nil {
+		t.Errorf("no worktrees: %+v", l)
+	}
+}
@@ -0,0 +1,68 @@
+package detect
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Layout holds what existing worktrees say about how the repository is
+// already worked on, for `wt init` to follow.
+type Layout struct {
+	WorktreeDir  string   // where most linked worktrees live, relative to the main worktree
+	CopyPatterns []string // untracked dotfiles of the main worktree that every linked worktree has too
+	Findings     []string
+}
+
+// Worktrees inspects the linked worktrees at paths of the repository checked
+// out at root. tracked holds the names git tracks at the top of root, which
+// are in every checkout anyway.
+func Worktrees(root string, paths []string, tracked map[string]bool) Layout {
+	var l Layout
+	if len(paths) == 0 {
+		return l
+	}
+
+	parents := make(map[string]int)
+	for _, path := range paths {
+		parents[filepath.Dir(path)]++
+	}
+	best, count := "", 0
+	for parent, n := range parents {
+		if n > count || n == count && parent < best {
+			best, count = parent, n
+		}
+	}
+	if rel, err := filepath.Rel(root, best); err == nil && rel != "." {
+		l.WorktreeDir = filepath.ToSlash(rel)
+		l.Findings = append(l.Findings, fmt.Sprintf("%d of %d worktrees in %s: worktree_dir = %q", count, len(paths), best, l.WorktreeDir))
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return l
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, ".") || name == ".git" || tracked[name] || name == l.WorktreeDir {
+			continue
+		}
+		shared := true
+		for _, path := range paths {
+			if _, err := os.Lstat(filepath.Join(path, name)); err != nil {
+				shared = false
+				break
+			}
+		}
+		if shared {
+			l.CopyPatterns = append(l.CopyPatterns, name)
+		}
+	}
+	if len(l.CopyPatterns) > 0 {
+		sort.Strings(l.CopyPatterns)
+		l.Findings = append(l.Findings, strings.Join(l.CopyPatterns, ", ")+": untracked, but in every worktree, so copying them")
+	}
+	return l
+}
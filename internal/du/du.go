@@ -0,0 +1,59 @@
+// Package du measures how much disk space directory trees take up.
+package du
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// Size returns the bytes allocated on disk for the files below dir, like
+// du -s. Hard-linked files count once where the system tells them apart
+// (elsewhere, e.g. on Windows, apparent sizes add up); symlinks are not
+// followed. Paths in
+// skip, e.g. worktrees nested inside the main one, are left out. Entries
+// that can't be read are skipped, so the result may be low but is never an
+// error past dir itself.
+func Size(dir string, skip map[string]bool) (int64, error) {
+	seen := make(map[inode]bool)
+	var total int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() && path != dir && skip[path] {
+			return filepath.SkipDir
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		size, key, linked := diskUsage(info)
+		if linked && !d.IsDir() {
+			if seen[key] {
+				return nil
+			}
+			seen[key] = true
+		}
+		total += size
+		return nil
+	})
+	return total, err
+}
+
+// Format renders n bytes with a binary unit, e.g. "512B", "4.0K", "1.2G".
+func Format(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,64 @@
+package du
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSize(t *testing.T) {
+	dir := t.TempDir()
+	data := make([]byte, 64*1024)
+	if err := os.WriteFile(filepath.Join(dir, "a"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	single, err := Size(dir, nil)
+	if err != nil {
+		t.Fatalf("Size: %v", err)
+	}
+	if single < int64(len(data)) {
+		t.Fatalf("Size = %d, want at least %d", single, len(data))
+	}
+
+	// A hard link takes no extra space.
+	if err := os.Link(filepath.Join(dir, "a"), filepath.Join(dir, "b")); err != nil {
+		t.Fatal(err)
+	}
+	if linked, _ := Size(dir, nil); linked != single {
+		t.Fatalf("Size with hard link = %d, want %d", linked, single)
+	}
+
+	nested := filepath.Join(dir, "nested")
+	if err := os.Mkdir(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(nested, "c"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	with, _ := Size(dir, nil)
+	without, _ := Size(dir, map[string]bool{nested: true})
+	if with <= without {
+		t.Fatalf("Size skipping %s = %d, want less than %d", nested, without, with)
+	}
+
+	if _, err := Size(filepath.Join(dir, "missing"), nil); err == nil {
+		t.Fatal("Size of a missing directory succeeded")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := map[int64]string{
+		0:               "0B",
+		1023:            "1023B",
+		1024:            "1.0K",
+		1536:            "1.5K",
+		5 * 1024 * 1024: "5.0M",
+		1288490188:      "1.2G",
+		3 << 40:         "3.0T",
+	}
+	for n, want := range tests {
+		if got := Format(n); got != want {
+			t.Errorf("Format(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
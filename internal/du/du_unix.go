@@ -0,0 +1,21 @@
+//go:build unix
+
+package du
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// inode identifies a file across its hard links.
+type inode struct{ dev, ino uint64 }
+
+// diskUsage returns the bytes allocated for the file of info and, for files
+// with several hard links, its inode.
+func diskUsage(info fs.FileInfo) (size int64, key inode, linked bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.Size(), inode{}, false
+	}
+	return st.Blocks * 512, inode{uint64(st.Dev), uint64(st.Ino)}, st.Nlink > 1
+}
@@ -0,0 +1,63 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Backend answers the read-only questions wt asks on nearly every command.
+// Anything that changes the repository always runs git itself.
+type Backend interface {
+	ListWorktrees() ([]Worktree, error)
+	BranchExists(branch string) (local, remote bool)
+	Status(ctx context.Context, path string, check DirtyCheck) (Status, error)
+}
+
+// BackendName selects a Backend.
+type BackendName string
+
+const (
+	// BackendExec runs a git process for every question.
+	BackendExec BackendName = "exec"
+	// BackendGoGit reads worktrees and refs from the repository files with
+	// go-git, saving the git processes on repositories with many refs or on
+	// systems where starting processes is slow. Status still runs git.
+	BackendGoGit BackendName = "go-git"
+)
+
+// Backends lists the supported backends.
+var Backends = []BackendName{BackendExec, BackendGoGit}
+
+// ParseBackend validates a backend name. An empty name means BackendExec.
+func ParseBackend(name string) (BackendName, error) {
+	if name == "" {
+		return BackendExec, nil
+	}
+	for _, b := range Backends {
+		if string(b) == name {
+			return b, nil
+		}
+	}
+	names := make([]string, len(Backends))
+	for i, b := range Backends {
+		names[i] = string(b)
+	}
+	return "", fmt.Errorf("unknown git backend %q (supported: %s)", name, strings.Join(names, ", "))
+}
+
+// backend answers ListWorktrees, BranchExists and status questions.
+var backend Backend = execBackend{}
+
+// UseBackend makes name answer the read-only questions from now on. Call it
+// before any goroutines start asking.
+func UseBackend(name BackendName) {
+	if name == BackendGoGit {
+		backend = &goGitBackend{}
+		return
+	}
+	backend = execBackend{}
+}
+
+// execBackend runs git for everything.
+type execBackend struct{}
@@ -0,0 +1,51 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/default-anton/wt/internal/runner"
+)
+
+// Commits lists the commits on HEAD of the worktree at path that ref does
+// not have, newest first, as "<short hash> <subject>".
+func Commits(path, ref string) ([]string, error) {
+	cmd := exec.Command("git", "-C", path, "log", "--format=%h %s", ref+"..HEAD")
+	output, err := runner.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits since %s: %w", ref, err)
+	}
+	var commits []string
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		if line != "" {
+			commits = append(commits, line)
+		}
+	}
+	return commits, nil
+}
+
+// DiffStat returns git's diffstat of the changes HEAD of the worktree at
+// path made since it forked from ref, or "" when there are none.
+func DiffStat(path, ref string) (string, error) {
+	cmd := exec.Command("git", "-C", path, "diff", "--stat", ref+"...HEAD")
+	output, err := runner.Output(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to diff against %s: %w", ref, err)
+	}
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+// ShowDiff prints the diffstat and full diff DiffStat summarizes, through
+// git's pager when stdout is a terminal.
+func ShowDiff(path, ref string) error {
+	cmd := exec.Command("git", "-C", path, "diff", "--stat", "--patch", ref+"...HEAD")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := runner.Run(cmd); err != nil {
+		return fmt.Errorf("failed to diff against %s: %w", ref, err)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+
+	"github.com/default-anton/wt/internal/log"
+)
+
+// goGitBackend reads worktrees and refs from the files of the repository
+// around the current directory. Repositories it can't read the way git does
+// (GIT_DIR in the environment, core.worktree, reftable refs) are handed to
+// execBackend, as is status: go-git hashes every file to find changes, which
+// is slower than git status with its index and untracked cache.
+type goGitBackend struct {
+	execBackend
+
+	once      sync.Once
+	repo      *gogit.Repository
+	commonDir string
+	bare      bool
+	err       error
+}
+
+// open opens the repository once, returning an error when execBackend has to
+// answer instead.
+func (b *goGitBackend) open() error {
+	b.once.Do(func() {
+		b.err = b.load()
+		if b.err != nil {
+			log.Debugf("go-git backend: %v; running git instead", b.err)
+		}
+	})
+	return b.err
+}
+
+func (b *goGitBackend) load() error {
+	for _, name := range []string{"GIT_DIR", "GIT_COMMON_DIR", "GIT_WORK_TREE"} {
+		if os.Getenv(name) != "" {
+			return fmt.Errorf("%s is set", name)
+		}
+	}
+	commonDir, err := findCommonDir()
+	if err != nil {
+		return err
+	}
+	repo, err := gogit.Open(filesystem.NewStorage(osfs.New(commonDir), cache.NewObjectLRUDefault()), nil)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", commonDir, err)
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read the config of %s: %w", commonDir, err)
+	}
+	if storage := cfg.Raw.Section("extensions").Option("refStorage"); storage != "" && storage != "files" {
+		return fmt.Errorf("unsupported ref storage %q", storage)
+	}
+	if cfg.Raw.Section("core").Option("worktree") != "" {
+		return errors.New("core.worktree is set")
+	}
+	b.repo, b.commonDir, b.bare = repo, commonDir, cfg.Core.IsBare
+	return nil
+}
+
+// ListWorktrees reads the main worktree and the ones registered under
+// $GIT_COMMON_DIR/worktrees, as git worktree list does. Linked worktrees are
+// sorted by their administrative directory name.
+func (b *goGitBackend) ListWorktrees() ([]Worktree, error) {
+	if err := b.open(); err != nil {
+		return b.execBackend.ListWorktrees()
+	}
+
+	var worktrees []Worktree
+	if b.bare {
+		worktrees = append(worktrees, Worktree{Path: b.commonDir, IsMain: true, Bare: true})
+	} else {
+		main := Worktree{Path: filepath.Dir(b.commonDir), IsMain: true}
+		if err := b.readHead(&main, filepath.Join(b.commonDir, "HEAD")); err != nil {
+			return nil, fmt.Errorf("failed to list worktrees: %w", err)
+		}
+		worktrees = append(worktrees, main)
+	}
+
+	adminDirs, err := os.ReadDir(filepath.Join(b.commonDir, "worktrees"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	sort.Slice(adminDirs, func(i, j int) bool { return adminDirs[i].Name() < adminDirs[j].Name() })
+	for _, entry := range adminDirs {
+		adminDir := filepath.Join(b.commonDir, "worktrees", entry.Name())
+		// git skips entries whose gitdir it can't read, too.
+		gitdir, err := os.ReadFile(filepath.Join(adminDir, "gitdir"))
+		if err != nil {
+			continue
+		}
+		dotGit := strings.TrimSpace(string(gitdir))
+		if !filepath.IsAbs(dotGit) {
+			dotGit = filepath.Join(adminDir, dotGit)
+		}
+		wt := Worktree{Path: filepath.Dir(dotGit)}
+		if err := b.readHead(&wt, filepath.Join(adminDir, "HEAD")); err != nil {
+			continue
+		}
+		worktrees = append(worktrees, wt)
+	}
+	return worktrees, nil
+}
+
+// readHead fills in the branch and commit of wt from its HEAD file. An unborn
+// branch has the all-zero commit, as in git worktree list.
+func (b *goGitBackend) readHead(wt *Worktree, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	head := strings.TrimSpace(string(data))
+	ref, symbolic := strings.CutPrefix(head, "ref: ")
+	if !symbolic {
+		wt.Commit, wt.Detached = head, true
+		return nil
+	}
+	wt.Branch = strings.TrimPrefix(ref, "refs/heads/")
+	wt.Commit = plumbing.ZeroHash.String()
+	if resolved, err := b.repo.Reference(plumbing.ReferenceName(ref), true); err == nil {
+		wt.Commit = resolved.Hash().String()
+	}
+	return nil
+}
+
+// BranchExists looks up refs/heads/branch and refs/remotes/origin/branch,
+// loose or packed.
+func (b *goGitBackend) BranchExists(branch string) (local bool, remote bool) {
+	if err := b.open(); err != nil {
+		return b.execBackend.BranchExists(branch)
+	}
+	_, err := b.repo.Reference(plumbing.NewBranchReferenceName(branch), false)
+	local = err == nil
+	_, err = b.repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), false)
+	remote = err == nil
+	return local, remote
+}
+
+// findCommonDir finds the git directory shared by all worktrees of the
+// repository around the current directory, the way git discovers it: the
+// nearest .git directory or gitdir file, or a bare repository.
+func findCommonDir() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		gitDir := ""
+		dotGit := filepath.Join(dir, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			gitDir = dotGit
+			if !info.IsDir() {
+				if gitDir, err = readGitFile(dotGit); err != nil {
+					return "", err
+				}
+			}
+		} else if isGitDir(dir) {
+			gitDir = dir
+		}
+		if gitDir != "" {
+			commonDir := gitDir
+			if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+				commonDir = strings.TrimSpace(string(data))
+				if !filepath.IsAbs(commonDir) {
+					commonDir = filepath.Join(gitDir, commonDir)
+				}
+			}
+			return filepath.EvalSymlinks(commonDir)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("not a git repository")
+		}
+		dir = parent
+	}
+}
+
+// readGitFile returns the git directory a .git file points to.
+func readGitFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return "", fmt.Errorf("%s is not a gitdir file", path)
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	return gitDir, nil
+}
+
+// isGitDir reports whether dir looks like a git directory itself, as bare
+// repositories do.
+func isGitDir(dir string) bool {
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LinkedWorktreeHead finds the worktree containing dir from the .git files
+// alone, without running git, and returns the branch checked out in it, or
+// the abbreviated commit when HEAD is detached. ok is false in the main
+// worktree, in submodules and outside any repository.
+func LinkedWorktreeHead(dir string) (head string, ok bool) {
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			if info.IsDir() {
+				return "", false
+			}
+			gitDir, err := readGitFile(dotGit)
+			if err != nil || filepath.Base(filepath.Dir(gitDir)) != "worktrees" {
+				return "", false
+			}
+			data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+			if err != nil {
+				return "", false
+			}
+			head := strings.TrimSpace(string(data))
+			if ref, symbolic := strings.CutPrefix(head, "ref: "); symbolic {
+				return strings.TrimPrefix(ref, "refs/heads/"), true
+			}
+			return head[:min(len(head), 7)], true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,88 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/default-anton/wt/internal/runner"
+)
+
+// Identity is the commit identity git resolves in a directory, including
+// any includeIf overrides that apply there.
+type Identity struct {
+	Name          string
+	Email         string
+	Sign          bool
+	SigningKey    string
+	SigningFormat string
+}
+
+// ResolveIdentity reads the commit identity git would use inside dir.
+func ResolveIdentity(dir string) Identity {
+	return Identity{
+		Name:          configGet(dir, "user.name"),
+		Email:         configGet(dir, "user.email"),
+		Sign:          configGet(dir, "--type=bool", "commit.gpgsign") == "true",
+		SigningKey:    configGet(dir, "user.signingkey"),
+		SigningFormat: configGet(dir, "gpg.format"),
+	}
+}
+
+// Problems describes what would go wrong when committing with this identity.
+func (id Identity) Problems() []string {
+	var problems []string
+	if id.Name == "" {
+		problems = append(problems, "user.name is not set")
+	}
+	if id.Email == "" {
+		problems = append(problems, "user.email is not set")
+	}
+	if !id.Sign {
+		return problems
+	}
+	if id.SigningKey == "" {
+		return append(problems, "commit.gpgsign is enabled but user.signingkey is not set")
+	}
+	if id.SigningFormat == "ssh" && !strings.HasPrefix(id.SigningKey, "key::") {
+		keyPath := expandHome(id.SigningKey)
+		if _, err := os.Stat(keyPath); err != nil {
+			problems = append(problems, fmt.Sprintf("user.signingkey %s does not exist", id.SigningKey))
+		}
+	}
+	return problems
+}
+
+func (id Identity) String() string {
+	s := fmt.Sprintf("%s <%s>", id.Name, id.Email)
+	if id.Sign {
+		format := id.SigningFormat
+		if format == "" {
+			format = "openpgp"
+		}
+		s += fmt.Sprintf(", signing with %s key %s", format, id.SigningKey)
+	}
+	return s
+}
+
+// configGet returns the value of a git config key as seen from dir, or ""
+// if it is unset.
+func configGet(dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", dir, "config", "--get"}, args...)...)
+	output, err := runner.Output(cmd)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
@@ -0,0 +1,53 @@
+package git
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/default-anton/wt/internal/runner"
+)
+
+func TestResolveIdentity(t *testing.T) {
+	unset := runner.Response{Err: errors.New("exit status 1")}
+	fake := runner.NewFake().
+		On("git -C /wt config --get user.name", runner.Response{Stdout: "Jane Doe\n"}).
+		On("git -C /wt config --get user.email", runner.Response{Stdout: "jane@work.example\n"}).
+		On("git -C /wt config --get --type=bool commit.gpgsign", runner.Response{Stdout: "true\n"}).
+		On("git -C /wt config --get user.signingkey", unset).
+		On("git -C /wt config --get gpg.format", unset)
+	t.Cleanup(runner.Set(fake))
+
+	id := ResolveIdentity("/wt")
+	want := Identity{Name: "Jane Doe", Email: "jane@work.example", Sign: true}
+	if id != want {
+		t.Fatalf("got %+v, want %+v", id, want)
+	}
+
+	problems := id.Problems()
+	wantProblems := []string{"commit.gpgsign is enabled but user.signingkey is not set"}
+	if !reflect.DeepEqual(problems, wantProblems) {
+		t.Fatalf("got %v, want %v", problems, wantProblems)
+	}
+}
+
+func TestIdentityProblems(t *testing.T) {
+	tests := []struct {
+		name string
+		id   Identity
+		want []string
+	}{
+		{"complete", Identity{Name: "a", Email: "a@b"}, nil},
+		{"missing email", Identity{Name: "a"}, []string{"user.email is not set"}},
+		{"inline ssh key", Identity{Name: "a", Email: "a@b", Sign: true, SigningFormat: "ssh", SigningKey: "key::ssh-ed25519 AAAA"}, nil},
+		{"missing ssh key file", Identity{Name: "a", Email: "a@b", Sign: true, SigningFormat: "ssh", SigningKey: "/nonexistent/key.pub"},
+			[]string{"user.signingkey /nonexistent/key.pub does not exist"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.id.Problems(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
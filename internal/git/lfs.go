@@ -0,0 +1,91 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/runner"
+)
+
+// LFSMode controls how Git LFS files are fetched into new worktrees.
+type LFSMode string
+
+const (
+	// LFSAuto leaves LFS to git: files are smudged during checkout if git-lfs
+	// is installed.
+	LFSAuto LFSMode = "auto"
+	// LFSPull checks out pointers, then downloads LFS files with one batched
+	// git lfs pull.
+	LFSPull LFSMode = "pull"
+	// LFSSkip checks out pointers only (GIT_LFS_SKIP_SMUDGE=1).
+	LFSSkip LFSMode = "skip"
+)
+
+// LFSModes lists the supported LFS modes.
+var LFSModes = []LFSMode{LFSAuto, LFSPull, LFSSkip}
+
+// ParseLFSMode validates an LFS mode name. An empty name means LFSAuto.
+func ParseLFSMode(name string) (LFSMode, error) {
+	if name == "" {
+		return LFSAuto, nil
+	}
+	for _, mode := range LFSModes {
+		if string(mode) == name {
+			return mode, nil
+		}
+	}
+	names := make([]string, len(LFSModes))
+	for i, mode := range LFSModes {
+		names[i] = string(mode)
+	}
+	return "", fmt.Errorf("unknown lfs mode %q (supported: %s)", name, strings.Join(names, ", "))
+}
+
+// UsesLFS reports whether the checkout at path tracks files with Git LFS,
+// judged by a filter=lfs attribute in its top-level .gitattributes.
+func UsesLFS(path string) bool {
+	f, err := os.Open(filepath.Join(path, ".gitattributes"))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, field := range strings.Fields(line) {
+			if field == "filter=lfs" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LFSInstalled reports whether the git-lfs extension is available.
+func LFSInstalled() bool {
+	return runner.Run(exec.Command("git", "lfs", "version")) == nil
+}
+
+// PullLFS downloads the LFS files of the worktree at path and replaces their
+// pointers, showing git's progress on stderr.
+func PullLFS(path string) error {
+	cmd := exec.Command("git", "-C", path, "lfs", "pull")
+	out, flush := log.Progress()
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := runner.Run(cmd)
+	flush()
+	if err != nil {
+		return fmt.Errorf("git lfs pull: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/default-anton/wt/internal/runner"
+)
+
+// MaintenanceRegistered reports whether the repository at repoRoot is
+// already listed in the global maintenance.repo setting.
+func MaintenanceRegistered(repoRoot string) bool {
+	output, err := runner.Output(exec.Command("git", "config", "--global", "--get-all", "maintenance.repo"))
+	if err != nil {
+		// Exit code 1 means the key is not set.
+		return false
+	}
+	want := canonicalPath(repoRoot)
+	for _, repo := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if repo != "" && canonicalPath(repo) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// StartMaintenance registers the repository at repoRoot for git's background
+// maintenance and schedules it with the system scheduler.
+func StartMaintenance(repoRoot string) error {
+	output, err := runner.CombinedOutput(exec.Command("git", "-C", repoRoot, "maintenance", "start"))
+	if err != nil {
+		return fmt.Errorf("git maintenance start failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// GC runs git gc --auto, which only repacks and prunes when the object store
+// has crossed git's own thresholds.
+func GC() error {
+	output, err := runner.CombinedOutput(exec.Command("git", "gc", "--auto", "--quiet"))
+	if err != nil {
+		return fmt.Errorf("git gc failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// canonicalPath resolves symlinks so paths git recorded compare equal to
+// the ones wt computes.
+func canonicalPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
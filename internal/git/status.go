@@ -0,0 +1,295 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/default-anton/wt/internal/runner"
+)
+
+// Status summarizes work in a worktree that would be lost or stranded by
+// removing it.
+type Status struct {
+	Dirty    bool // uncommitted or untracked changes
+	Unpushed int  // commits not on the upstream (or on any remote without one)
+}
+
+// Risky reports whether the worktree has changes or commits that exist nowhere else.
+func (s Status) Risky() bool {
+	return s.Dirty || s.Unpushed > 0
+}
+
+// DirtyCheck selects how thoroughly GetStatusWith looks for uncommitted
+// changes.
+type DirtyCheck string
+
+const (
+	// DirtyFull reports modified tracked files and untracked files. git
+	// status uses core.untrackedCache and core.fsmonitor when configured.
+	DirtyFull DirtyCheck = "full"
+	// DirtyFast only reports modified tracked files, skipping the scan for
+	// untracked ones.
+	DirtyFast DirtyCheck = "fast"
+	// DirtyOff does not look at files at all, only at unpushed commits.
+	DirtyOff DirtyCheck = "off"
+)
+
+// DirtyChecks lists the supported dirty checks.
+var DirtyChecks = []DirtyCheck{DirtyFull, DirtyFast, DirtyOff}
+
+// ParseDirtyCheck validates a dirty check name. An empty name means DirtyFull.
+func ParseDirtyCheck(name string) (DirtyCheck, error) {
+	if name == "" {
+		return DirtyFull, nil
+	}
+	for _, check := range DirtyChecks {
+		if string(check) == name {
+			return check, nil
+		}
+	}
+	names := make([]string, len(DirtyChecks))
+	for i, check := range DirtyChecks {
+		names[i] = string(check)
+	}
+	return "", fmt.Errorf("unknown dirty check %q (supported: %s)", name, strings.Join(names, ", "))
+}
+
+// GetStatus inspects the worktree at path.
+func GetStatus(path string) (Status, error) {
+	return GetStatusWith(path, DirtyFull)
+}
+
+// GetStatusWith inspects the worktree at path, looking for uncommitted
+// changes as thoroughly as check asks for.
+func GetStatusWith(path string, check DirtyCheck) (Status, error) {
+	return getStatus(context.Background(), path, check)
+}
+
+// statusWorkers bounds how many worktrees StatusAll inspects at once.
+const statusWorkers = 8
+
+// StatusAll inspects the worktrees at paths concurrently, as GetStatusWith
+// does, passing each status to found (if not nil) as soon as it is known.
+// It returns once every worktree is done or ctx is, whichever comes first:
+// worktrees that failed or were still being inspected, say on a slow network
+// mount, are missing from the result, and their git status is killed.
+func StatusAll(ctx context.Context, paths []string, check DirtyCheck, found func(path string, status Status)) map[string]Status {
+	results := make(map[string]Status, len(paths))
+	var mu sync.Mutex
+	finished := false
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, statusWorkers)
+
+	for _, path := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			status, err := getStatus(ctx, path, check)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if finished {
+				return
+			}
+			results[path] = status
+			if found != nil {
+				found(path, status)
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	// Statuses arriving from now on are dropped, so found is never called
+	// after StatusAll returns.
+	mu.Lock()
+	defer mu.Unlock()
+	finished = true
+	return results
+}
+
+func getStatus(ctx context.Context, path string, check DirtyCheck) (Status, error) {
+	return backend.Status(ctx, path, check)
+}
+
+func (execBackend) Status(ctx context.Context, path string, check DirtyCheck) (Status, error) {
+	if check == DirtyOff {
+		return Status{Unpushed: unpushed(path)}, nil
+	}
+	args := []string{"-C", path, "status", "--porcelain=v2", "--branch"}
+	if check == DirtyFast {
+		args = append(args, "--untracked-files=no")
+	}
+	output, err := runner.Output(exec.CommandContext(ctx, "git", args...))
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to get status of %s: %w", path, err)
+	}
+
+	var status Status
+	hasUpstream := false
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "# branch.upstream "):
+			hasUpstream = true
+		case strings.HasPrefix(line, "# branch.ab "):
+			fields := strings.Fields(strings.TrimPrefix(line, "# branch.ab "))
+			if len(fields) > 0 {
+				status.Unpushed, _ = strconv.Atoi(strings.TrimPrefix(fields[0], "+"))
+			}
+		case strings.HasPrefix(line, "#"):
+		case line != "":
+			status.Dirty = true
+		}
+	}
+
+	if !hasUpstream {
+		status.Unpushed = unpushedWithoutUpstream(path)
+	}
+	return status, nil
+}
+
+// Upstream returns the upstream of the branch checked out at path, e.g.
+// "origin/main", or "" if it has none.
+func Upstream(path string) string {
+	cmd := exec.Command("git", "-C", path, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+	output, err := runner.Output(cmd)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// AheadBehind counts the commits on HEAD of the worktree at path that are
+// not on ref (ahead), and the other way around (behind).
+func AheadBehind(path, ref string) (ahead, behind int, err error) {
+	cmd := exec.Command("git", "-C", path, "rev-list", "--left-right", "--count", "HEAD..."+ref)
+	output, err := runner.Output(cmd)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to compare HEAD with %s: %w", ref, err)
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected git rev-list output %q", output)
+	}
+	ahead, _ = strconv.Atoi(fields[0])
+	behind, _ = strconv.Atoi(fields[1])
+	return ahead, behind, nil
+}
+
+// LastCommitTime returns when HEAD of the worktree at path was committed.
+func LastCommitTime(path string) (time.Time, error) {
+	output, err := runner.Output(exec.Command("git", "-C", path, "log", "-1", "--format=%ct"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read the last commit of %s: %w", path, err)
+	}
+	secs, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unexpected git log output %q", output)
+	}
+	return time.Unix(secs, 0), nil
+}
+
+// Changes lists the uncommitted and untracked files of the worktree at path
+// in git status --short format, e.g. " M README.md".
+func Changes(path string) ([]string, error) {
+	output, err := runner.Output(exec.Command("git", "-C", path, "status", "--short"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status of %s: %w", path, err)
+	}
+	var changes []string
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		if line != "" {
+			changes = append(changes, line)
+		}
+	}
+	return changes, nil
+}
+
+// unpushed counts commits on HEAD that are not on its upstream, or on any
+// remote without one.
+func unpushed(path string) int {
+	if Upstream(path) == "" {
+		return unpushedWithoutUpstream(path)
+	}
+	ahead, _, err := AheadBehind(path, "@{upstream}")
+	if err != nil {
+		return 0
+	}
+	return ahead
+}
+
+// unpushedWithoutUpstream counts commits on HEAD that no remote-tracking
+// branch contains. Repositories without remotes have nowhere to push, so
+// nothing counts as unpushed.
+func unpushedWithoutUpstream(path string) int {
+	remotes, err := runner.Output(exec.Command("git", "-C", path, "remote"))
+	if err != nil || strings.TrimSpace(string(remotes)) == "" {
+		return 0
+	}
+	output, err := runner.Output(exec.Command("git", "-C", path, "rev-list", "--count", "HEAD", "--not", "--remotes"))
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(string(output)))
+	return n
+}
+
+// DiffFiles writes a unified diff between the files at a and b to w. The
+// files don't need to be tracked, or even inside a repository.
+func DiffFiles(a, b string, w io.Writer) error {
+	cmd := exec.Command("git", "diff", "--no-index", "--", a, b)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	err := runner.Run(cmd)
+	// Like diff(1), git diff --no-index exits with 1 when the files differ.
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return nil
+	}
+	return err
+}
+
+// TrackedNames returns the names of the files and directories that HEAD
+// tracks at the top of the worktree at path.
+func TrackedNames(path string) (map[string]bool, error) {
+	cmd := exec.Command("git", "-C", path, "ls-tree", "-z", "--name-only", "HEAD")
+	output, err := runner.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of %s: %w", path, err)
+	}
+	names := make(map[string]bool)
+	for _, name := range strings.Split(string(output), "\x00") {
+		if name != "" {
+			names[name] = true
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,159 @@
+package git
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/default-anton/wt/internal/runner"
+)
+
+func TestGetStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		fake *runner.Fake
+		want Status
+	}{
+		{
+			name: "clean with upstream",
+			fake: runner.NewFake().On("git -C /wt status", runner.Response{
+				Stdout: "# branch.oid abc\n# branch.head feature\n# branch.upstream origin/feature\n# branch.ab +0 -2\n",
+			}),
+			want: Status{},
+		},
+		{
+			name: "dirty and ahead of upstream",
+			fake: runner.NewFake().On("git -C /wt status", runner.Response{
+				Stdout: "# branch.oid abc\n# branch.head feature\n# branch.upstream origin/feature\n# branch.ab +3 -0\n" +
+					"1 .M N... 100644 100644 100644 aaa aaa README.md\n? new.txt\n",
+			}),
+			want: Status{Dirty: true, Unpushed: 3},
+		},
+		{
+			name: "no upstream counts commits missing from remotes",
+			fake: runner.NewFake().
+				On("git -C /wt status", runner.Response{Stdout: "# branch.oid abc\n# branch.head feature\n"}).
+				On("git -C /wt remote", runner.Response{Stdout: "origin\n"}).
+				On("git -C /wt rev-list", runner.Response{Stdout: "2\n"}),
+			want: Status{Unpushed: 2},
+		},
+		{
+			name: "no remotes",
+			fake: runner.NewFake().
+				On("git -C /wt status", runner.Response{Stdout: "# branch.oid abc\n# branch.head feature\n"}).
+				On("git -C /wt remote", runner.Response{}),
+			want: Status{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(runner.Set(tt.fake))
+			got, err := GetStatus("/wt")
+			if err != nil {
+				t.Fatalf("GetStatus: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetStatusWith(t *testing.T) {
+	t.Run("fast skips untracked files", func(t *testing.T) {
+		fake := runner.NewFake().On("git -C /wt status", runner.Response{
+			Stdout: "# branch.oid abc\n# branch.upstream origin/feature\n# branch.ab +1 -0\n1 .M N... 100644 100644 100644 aaa aaa README.md\n",
+		})
+		t.Cleanup(runner.Set(fake))
+		got, err := GetStatusWith("/wt", DirtyFast)
+		if err != nil {
+			t.Fatalf("GetStatusWith: %v", err)
+		}
+		if want := (Status{Dirty: true, Unpushed: 1}); got != want {
+			t.Fatalf("got %+v, want %+v", got, want)
+		}
+		if call := fake.Calls()[0].String(); call != "git -C /wt status --porcelain=v2 --branch --untracked-files=no" {
+			t.Fatalf("unexpected call %q", call)
+		}
+	})
+
+	t.Run("off only counts unpushed commits", func(t *testing.T) {
+		fake := runner.NewFake().
+			On("git -C /wt rev-parse", runner.Response{Stdout: "origin/feature\n"}).
+			On("git -C /wt rev-list", runner.Response{Stdout: "2\t0\n"})
+		t.Cleanup(runner.Set(fake))
+		got, err := GetStatusWith("/wt", DirtyOff)
+		if err != nil {
+			t.Fatalf("GetStatusWith: %v", err)
+		}
+		if want := (Status{Unpushed: 2}); got != want {
+			t.Fatalf("got %+v, want %+v", got, want)
+		}
+		for _, call := range fake.Calls() {
+			if strings.Contains(call.String(), " status") {
+				t.Fatalf("off ran %q", call)
+			}
+		}
+	})
+}
+
+// stallRunner blocks commands run in dir until release is closed, and hands
+// the rest to Fake.
+type stallRunner struct {
+	*runner.Fake
+	dir     string
+	release chan struct{}
+}
+
+func (r stallRunner) Run(cmd *exec.Cmd) error {
+	if len(cmd.Args) > 2 && cmd.Args[2] == r.dir {
+		<-r.release
+	}
+	return r.Fake.Run(cmd)
+}
+
+func TestStatusAll(t *testing.T) {
+	fake := runner.NewFake().
+		On("git -C /a status", runner.Response{Stdout: "# branch.upstream origin/a\n# branch.ab +0 -0\n? new.txt\n"}).
+		On("git -C /b status", runner.Response{Stdout: "# branch.upstream origin/b\n# branch.ab +2 -0\n"}).
+		On("git -C /slow status", runner.Response{Stdout: "# branch.upstream origin/slow\n# branch.ab +0 -0\n"})
+	release := make(chan struct{})
+	defer close(release)
+	t.Cleanup(runner.Set(stallRunner{Fake: fake, dir: "/slow", release: release}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	found := 0
+	got := StatusAll(ctx, []string{"/a", "/slow", "/b"}, DirtyFull, func(string, Status) { found++ })
+
+	want := map[string]Status{"/a": {Dirty: true}, "/b": {Unpushed: 2}}
+	if len(got) != len(want) || got["/a"] != want["/a"] || got["/b"] != want["/b"] {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if found != 2 {
+		t.Fatalf("found called %d times, want 2", found)
+	}
+}
+
+func TestParseDirtyCheck(t *testing.T) {
+	if check, err := ParseDirtyCheck(""); err != nil || check != DirtyFull {
+		t.Fatalf("ParseDirtyCheck(\"\") = %q, %v", check, err)
+	}
+	if _, err := ParseDirtyCheck("quick"); err == nil || !strings.Contains(err.Error(), `unknown dirty check "quick"`) {
+		t.Fatalf("ParseDirtyCheck(\"quick\") error = %v", err)
+	}
+}
+
+func TestLastCommitTime(t *testing.T) {
+	t.Cleanup(runner.Set(runner.NewFake().On("git -C /wt log -1", runner.Response{Stdout: "1577836800\n"})))
+	got, err := LastCommitTime("/wt")
+	if err != nil {
+		t.Fatalf("LastCommitTime: %v", err)
+	}
+	if want := time.Unix(1577836800, 0); !got.Equal(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/runner"
+)
+
+// SubmoduleMode controls how submodules are initialized in new worktrees.
+type SubmoduleMode string
+
+const (
+	// SubmodulesNone leaves submodules uninitialized.
+	SubmodulesNone SubmoduleMode = "none"
+	// SubmodulesRecursive initializes submodules and their nested submodules.
+	SubmodulesRecursive SubmoduleMode = "recursive"
+	// SubmodulesShallow is SubmodulesRecursive, fetching only the recorded commits.
+	SubmodulesShallow SubmoduleMode = "shallow"
+)
+
+// SubmoduleModes lists the supported submodule modes.
+var SubmoduleModes = []SubmoduleMode{SubmodulesNone, SubmodulesRecursive, SubmodulesShallow}
+
+// ParseSubmoduleMode validates a submodule mode name. An empty name means
+// SubmodulesNone.
+func ParseSubmoduleMode(name string) (SubmoduleMode, error) {
+	if name == "" {
+		return SubmodulesNone, nil
+	}
+	for _, mode := range SubmoduleModes {
+		if string(mode) == name {
+			return mode, nil
+		}
+	}
+	names := make([]string, len(SubmoduleModes))
+	for i, mode := range SubmoduleModes {
+		names[i] = string(mode)
+	}
+	return "", fmt.Errorf("unknown submodule mode %q (supported: %s)", name, strings.Join(names, ", "))
+}
+
+// HasSubmodules reports whether the checkout at path declares submodules.
+func HasSubmodules(path string) bool {
+	_, err := os.Stat(filepath.Join(path, ".gitmodules"))
+	return err == nil
+}
+
+// UpdateSubmodules runs git submodule update --init in the worktree at path,
+// showing git's progress on stderr unless quiet mode is on.
+func UpdateSubmodules(path string, mode SubmoduleMode) error {
+	args := []string{"-C", path, "submodule", "update", "--init"}
+	switch mode {
+	case SubmodulesNone:
+		return nil
+	case SubmodulesRecursive:
+		args = append(args, "--recursive")
+	case SubmodulesShallow:
+		args = append(args, "--recursive", "--depth", "1")
+	}
+	if log.Quiet() {
+		args = append(args, "--quiet")
+	}
+	cmd := exec.Command("git", args...)
+	out, flush := log.Progress()
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := runner.Run(cmd)
+	flush()
+	if err != nil {
+		return fmt.Errorf("git submodule update: %w", err)
+	}
+	return nil
+}
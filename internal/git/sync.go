@@ -0,0 +1,220 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/runner"
+)
+
+// SyncStrategy selects how wt sync brings a worktree's branch up to date
+// with its base.
+type SyncStrategy string
+
+const (
+	// SyncRebase replays the branch's own commits on top of the base.
+	SyncRebase SyncStrategy = "rebase"
+	// SyncMerge merges the base into the branch.
+	SyncMerge SyncStrategy = "merge"
+	// SyncFastForward only moves branches that have no commits of their own.
+	SyncFastForward SyncStrategy = "ff-only"
+)
+
+// SyncStrategies lists the supported sync strategies.
+var SyncStrategies = []SyncStrategy{SyncRebase, SyncMerge, SyncFastForward}
+
+// ParseSyncStrategy validates a sync strategy name. An empty name means
+// SyncRebase.
+func ParseSyncStrategy(name string) (SyncStrategy, error) {
+	if name == "" {
+		return SyncRebase, nil
+	}
+	for _, s := range SyncStrategies {
+		if string(s) == name {
+			return s, nil
+		}
+	}
+	names := make([]string, len(SyncStrategies))
+	for i, s := range SyncStrategies {
+		names[i] = string(s)
+	}
+	return "", fmt.Errorf("unknown sync strategy %q (supported: %s)", name, strings.Join(names, ", "))
+}
+
+// ErrNotFastForward means a fast-forward was asked for, but the branch has
+// commits of its own.
+var ErrNotFastForward = errors.New("not a fast-forward")
+
+// ConflictError reports a rebase or merge that stopped on conflicts and was
+// aborted, leaving the worktree as it was.
+type ConflictError struct {
+	Files []string
+}
+
+func (e *ConflictError) Error() string {
+	if len(e.Files) == 0 {
+		return "conflicts"
+	}
+	return "conflicts in " + strings.Join(e.Files, ", ")
+}
+
+// Fetch fetches branch from remote, updating its remote-tracking branch.
+func Fetch(remote, branch string) error {
+	args := []string{"fetch", remote, branch}
+	if log.Quiet() {
+		args = append(args, "--quiet")
+	}
+	cmd := exec.Command("git", args...)
+	out, flush := log.Progress()
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := runner.Run(cmd)
+	flush()
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s from %s: %w", branch, remote, err)
+	}
+	return nil
+}
+
+// FetchPrune fetches all remotes, deleting remote-tracking branches whose
+// remote branch is gone.
+func FetchPrune() error {
+	args := []string{"fetch", "--all", "--prune"}
+	if log.Quiet() {
+		args = append(args, "--quiet")
+	}
+	cmd := exec.Command("git", args...)
+	out, flush := log.Progress()
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := runner.Run(cmd)
+	flush()
+	if err != nil {
+		return fmt.Errorf("failed to fetch: %w", err)
+	}
+	return nil
+}
+
+// HasRemote reports whether the repository has a remote called name.
+func HasRemote(name string) bool {
+	return runner.Run(exec.Command("git", "remote", "get-url", name)) == nil
+}
+
+// RemoteBranch returns the branch name on the remote for ref, leaving out
+// the remote of remote-tracking refs: origin/main and
+// refs/remotes/origin/main become main. Other refs are returned as they are.
+func RemoteBranch(ref string) string {
+	name := strings.TrimPrefix(ref, "refs/remotes/")
+	if name == ref {
+		name = strings.TrimPrefix(ref, "remotes/")
+	}
+	if remote, branch, ok := strings.Cut(name, "/"); ok && (name != ref || HasRemote(remote)) {
+		return branch
+	}
+	return ref
+}
+
+// openTerminal opens the terminal an interactive rebase or merge runs the
+// user's editor on. wt's own stdout may be captured by the shell
+// integration, so the editor can't inherit it.
+var openTerminal = func() (*os.File, error) {
+	return os.OpenFile("/dev/tty", os.O_RDWR, 0)
+}
+
+// Integrate brings the branch checked out at path up to date with ref
+// using strategy. A rebase or merge that runs into conflicts is aborted
+// and reported as a *ConflictError.
+//
+// With interactive, a rebase is git rebase -i and a merge opens its message
+// for editing, in the user's editor (GIT_SEQUENCE_EDITOR, GIT_EDITOR, ...)
+// on the terminal. Otherwise git never waits for an editor: GIT_EDITOR and
+// GIT_SEQUENCE_EDITOR are set to true, which accepts what git proposes.
+func Integrate(path, ref string, strategy SyncStrategy, interactive bool) error {
+	var args, abort []string
+	switch strategy {
+	case SyncRebase:
+		args, abort = []string{"rebase", ref}, []string{"rebase", "--abort"}
+		if interactive {
+			args = []string{"rebase", "--interactive", ref}
+		}
+	case SyncMerge:
+		args, abort = []string{"merge", "--no-edit", ref}, []string{"merge", "--abort"}
+		if interactive {
+			args = []string{"merge", "--edit", ref}
+		}
+	case SyncFastForward:
+		args = []string{"merge", "--ff-only", ref}
+		interactive = false // nothing to edit
+	default:
+		return fmt.Errorf("unknown sync strategy %q", strategy)
+	}
+	cmd := exec.Command("git", append([]string{"-C", path}, args...)...)
+	var output []byte
+	var err error
+	if interactive {
+		tty, ttyErr := openTerminal()
+		if ttyErr != nil {
+			return fmt.Errorf("editing needs a terminal: %w", ttyErr)
+		}
+		defer tty.Close()
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = tty, tty, tty
+		err = runner.Run(cmd)
+		if err == nil && rebaseInProgress(path) {
+			return fmt.Errorf("the rebase stopped before it was done; finish it in %s with git rebase --continue", path)
+		}
+	} else {
+		cmd.Env = append(os.Environ(), "GIT_EDITOR=true", "GIT_SEQUENCE_EDITOR=true")
+		output, err = runner.CombinedOutput(cmd)
+	}
+	if err == nil {
+		return nil
+	}
+	// An interactive run showed git's messages on the terminal instead.
+	failure := strings.TrimSpace(string(output))
+	if failure == "" {
+		failure = err.Error()
+	}
+	if abort == nil {
+		if ahead, _, abErr := AheadBehind(path, ref); abErr == nil && ahead > 0 {
+			return ErrNotFastForward
+		}
+		return fmt.Errorf("git %s: %s", strings.Join(args, " "), failure)
+	}
+
+	conflicted, _ := runner.Output(exec.Command("git", "-C", path, "diff", "--name-only", "--diff-filter=U"))
+	if abortErr := runner.Run(exec.Command("git", append([]string{"-C", path}, abort...)...)); abortErr != nil {
+		return fmt.Errorf("git %s failed and could not be aborted: %s", strings.Join(args, " "), failure)
+	}
+	files := strings.Fields(string(conflicted))
+	if len(files) == 0 {
+		return fmt.Errorf("git %s: %s", strings.Join(args, " "), failure)
+	}
+	return &ConflictError{Files: files}
+}
+
+// rebaseInProgress reports whether the worktree at path is in the middle of
+// a rebase, e.g. one stopped by an edit or break in its todo list.
+func rebaseInProgress(path string) bool {
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		out, err := runner.Output(exec.Command("git", "-C", path, "rev-parse", "--git-path", dir))
+		if err != nil {
+			continue
+		}
+		gitPath := strings.TrimSpace(string(out))
+		if gitPath == "" {
+			continue
+		}
+		if !filepath.IsAbs(gitPath) {
+			gitPath = filepath.Join(path, gitPath)
+		}
+		if _, err := os.Stat(gitPath); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/default-anton/wt/internal/runner"
+)
+
+// cmdRecorder is a Runner that succeeds without running anything, keeping
+// the commands for inspection.
+type cmdRecorder struct {
+	cmds []*exec.Cmd
+}
+
+func (r *cmdRecorder) Run(cmd *exec.Cmd) error {
+	r.cmds = append(r.cmds, cmd)
+	return nil
+}
+
+func TestIntegrateEditor(t *testing.T) {
+	tty, err := os.CreateTemp(t.TempDir(), "tty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tty.Close()
+	restore := openTerminal
+	openTerminal = func() (*os.File, error) { return tty, nil }
+	t.Cleanup(func() { openTerminal = restore })
+
+	tests := []struct {
+		strategy    SyncStrategy
+		interactive bool
+		args        string
+	}{
+		{SyncRebase, false, "git -C /wt rebase main"},
+		{SyncMerge, false, "git -C /wt merge --no-edit main"},
+		{SyncRebase, true, "git -C /wt rebase --interactive main"},
+		{SyncMerge, true, "git -C /wt merge --edit main"},
+		{SyncFastForward, true, "git -C /wt merge --ff-only main"},
+	}
+	for _, tt := range tests {
+		r := &cmdRecorder{}
+		restore := runner.Set(r)
+		err := Integrate("/wt", "main", tt.strategy, tt.interactive)
+		restore()
+		if err != nil {
+			t.Fatalf("Integrate(%s, %v): %v", tt.strategy, tt.interactive, err)
+		}
+		cmd := r.cmds[0]
+		if got := strings.Join(cmd.Args, " "); got != tt.args {
+			t.Errorf("Integrate(%s, %v) ran %q, want %q", tt.strategy, tt.interactive, got, tt.args)
+		}
+		onTerminal := cmd.Stdin == tty && cmd.Stdout == tty && cmd.Stderr == tty
+		noEditor := slices.Contains(cmd.Env, "GIT_EDITOR=true") && slices.Contains(cmd.Env, "GIT_SEQUENCE_EDITOR=true")
+		if interactive := tt.strategy != SyncFastForward && tt.interactive; onTerminal != interactive || noEditor == interactive {
+			t.Errorf("Integrate(%s, %v): on the terminal = %v, editor turned off = %v", tt.strategy, tt.interactive, onTerminal, noEditor)
+		}
+	}
+}
+
+func TestIntegrateWithoutTerminal(t *testing.T) {
+	restore := openTerminal
+	openTerminal = func() (*os.File, error) { return nil, errors.New("no such device or address") }
+	t.Cleanup(func() { openTerminal = restore })
+	t.Cleanup(runner.Set(runner.NewFake()))
+
+	err := Integrate("/wt", "main", SyncRebase, true)
+	if err == nil || !strings.Contains(err.Error(), "editing needs a terminal") {
+		t.Fatalf("err = %v, want one saying a terminal is needed", err)
+	}
+}
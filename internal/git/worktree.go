@@ -9,22 +9,85 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"unicode"
+
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/runner"
 )
 
 // ErrDirtyWorktree indicates the worktree contains modified or untracked files.
 var ErrDirtyWorktree = errors.New("worktree contains modified or untracked files")
 
+// UnpushedError means a worktree was kept because its HEAD has commits that
+// no remote has. Once the worktree is gone they are easy to forget about.
+type UnpushedError struct {
+	Path    string
+	Commits int
+}
+
+func (e *UnpushedError) Error() string {
+	return fmt.Sprintf("%s has %d commit(s) that are on no remote", e.Path, e.Commits)
+}
+
+// Worktree is a registered worktree. The JSON form is the `wt ls --json`
+// output, which is also how worktrees on remote machines are read.
 type Worktree struct {
-	Path   string
-	Branch string
-	Commit string
-	IsMain bool
+	Path     string `json:"path"`
+	Branch   string `json:"branch"`
+	Commit   string `json:"commit"`
+	IsMain   bool   `json:"main"`
+	Bare     bool   `json:"bare"` // the main entry of a bare repository: no checkout
+	Detached bool   `json:"detached"`
+}
+
+// shortSHALen is the number of commit hash characters shown for detached worktrees.
+const shortSHALen = 7
+
+// Label names the worktree for display: its branch, the short commit SHA when
+// HEAD is detached, or the directory name as a last resort.
+func (w Worktree) Label() string {
+	if w.Branch != "" {
+		return w.Branch
+	}
+	if w.Commit != "" {
+		if len(w.Commit) > shortSHALen {
+			return w.Commit[:shortSHALen]
+		}
+		return w.Commit
+	}
+	return filepath.Base(w.Path)
 }
 
-// GetRepoRoot returns the root directory of the git repository.
+// GetRepoRoot returns the root directory of the git repository. In a bare
+// repository, which has no working tree, that is the git directory itself.
 func GetRepoRoot() (string, error) {
 	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	output, err := cmd.Output()
+	output, err := runner.Output(cmd)
+	if err == nil {
+		return strings.TrimSpace(string(output)), nil
+	}
+	if IsBareRepository() {
+		cmd := exec.Command("git", "rev-parse", "--absolute-git-dir")
+		if output, err := runner.Output(cmd); err == nil {
+			return strings.TrimSpace(string(output)), nil
+		}
+	}
+	return "", fmt.Errorf("not a git repository")
+}
+
+// IsBareRepository reports whether the current directory is inside a bare
+// repository (and not in one of its worktrees).
+func IsBareRepository() bool {
+	cmd := exec.Command("git", "rev-parse", "--is-bare-repository")
+	output, err := runner.Output(cmd)
+	return err == nil && strings.TrimSpace(string(output)) == "true"
+}
+
+// CommonDir returns the absolute path of the git directory shared by all
+// worktrees of the repository.
+func CommonDir() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--path-format=absolute", "--git-common-dir")
+	output, err := runner.Output(cmd)
 	if err != nil {
 		return "", fmt.Errorf("not a git repository")
 	}
@@ -33,8 +96,12 @@ func GetRepoRoot() (string, error) {
 
 // ListWorktrees returns all worktrees in the repository.
 func ListWorktrees() ([]Worktree, error) {
+	return backend.ListWorktrees()
+}
+
+func (execBackend) ListWorktrees() ([]Worktree, error) {
 	cmd := exec.Command("git", "worktree", "list", "--porcelain")
-	output, err := cmd.Output()
+	output, err := runner.Output(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list worktrees: %w", err)
 	}
@@ -56,8 +123,11 @@ func ListWorktrees() ([]Worktree, error) {
 		case strings.HasPrefix(line, "branch "):
 			branch := strings.TrimPrefix(line, "branch ")
 			current.Branch = strings.TrimPrefix(branch, "refs/heads/")
+		case line == "detached":
+			current.Detached = true
 		case line == "bare":
 			current.IsMain = true
+			current.Bare = true
 		}
 	}
 
@@ -73,47 +143,344 @@ func ListWorktrees() ([]Worktree, error) {
 	return worktrees, nil
 }
 
+// FindWorktree returns the worktree whose path or branch equals target.
+// Relative paths are resolved against the current directory.
+func FindWorktree(worktrees []Worktree, target string) (*Worktree, bool) {
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		absTarget = target
+	}
+	for i := range worktrees {
+		if worktrees[i].Path == absTarget || worktrees[i].Branch == target {
+			return &worktrees[i], true
+		}
+	}
+	return nil, false
+}
+
+// CurrentWorktree returns the worktree containing the current directory.
+func CurrentWorktree(worktrees []Worktree) (*Worktree, error) {
+	root, err := GetRepoRoot()
+	if err != nil {
+		return nil, err
+	}
+	for i := range worktrees {
+		if worktrees[i].Path == root {
+			return &worktrees[i], nil
+		}
+	}
+	return nil, fmt.Errorf("current directory is not inside a known worktree")
+}
+
+// MainWorktree returns the main worktree from a list returned by ListWorktrees.
+func MainWorktree(worktrees []Worktree) (*Worktree, bool) {
+	for i := range worktrees {
+		if worktrees[i].IsMain {
+			return &worktrees[i], true
+		}
+	}
+	return nil, false
+}
+
 // BranchExists checks if a branch exists locally or remotely.
 func BranchExists(branch string) (local bool, remote bool) {
+	return backend.BranchExists(branch)
+}
+
+func (execBackend) BranchExists(branch string) (local bool, remote bool) {
 	// Check local
 	cmd := exec.Command("git", "show-ref", "--verify", "--quiet", "refs/heads/"+branch)
-	if cmd.Run() == nil {
+	if runner.Run(cmd) == nil {
 		local = true
 	}
 
 	// Check remote
 	cmd = exec.Command("git", "show-ref", "--verify", "--quiet", "refs/remotes/origin/"+branch)
-	if cmd.Run() == nil {
+	if runner.Run(cmd) == nil {
 		remote = true
 	}
 
 	return local, remote
 }
 
+// MergedBranches returns the local branches whose tips are reachable from base.
+func MergedBranches(base string) (map[string]bool, error) {
+	cmd := exec.Command("git", "branch", "--merged", base, "--format=%(refname:short)")
+	output, err := runner.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches merged into %s: %w", base, err)
+	}
+	merged := make(map[string]bool)
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			merged[line] = true
+		}
+	}
+	return merged, nil
+}
+
+// GoneBranches returns the local branches whose upstream no longer exists,
+// typically because the remote branch was deleted after its pull request
+// was merged. git only notices once a fetch has pruned the remote-tracking
+// branch.
+func GoneBranches() ([]string, error) {
+	cmd := exec.Command("git", "for-each-ref", "--format=%(refname:short) %(upstream:track)", "refs/heads")
+	output, err := runner.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	var gone []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if branch, track, ok := strings.Cut(strings.TrimSpace(line), " "); ok && track == "[gone]" {
+			gone = append(gone, branch)
+		}
+	}
+	return gone, nil
+}
+
+// UpstreamCommits returns the commit the upstream of each local branch
+// points at, for the branches whose upstream still exists. Read before a
+// fetch prunes them, they tell whether a branch's commits made it to the
+// remote before the remote branch was deleted.
+func UpstreamCommits() (map[string]string, error) {
+	cmd := exec.Command("git", "for-each-ref", "--format=%(refname) %(objectname)", "refs/remotes")
+	output, err := runner.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remote branches: %w", err)
+	}
+	remote := make(map[string]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		if ref, sha, ok := strings.Cut(strings.TrimSpace(line), " "); ok {
+			remote[ref] = sha
+		}
+	}
+
+	cmd = exec.Command("git", "for-each-ref", "--format=%(refname:short) %(upstream)", "refs/heads")
+	if output, err = runner.Output(cmd); err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	upstreams := make(map[string]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		branch, upstream, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if sha, exists := remote[upstream]; ok && exists {
+			upstreams[branch] = sha
+		}
+	}
+	return upstreams, nil
+}
+
+// IsAncestor reports whether commit is other or one of its ancestors.
+func IsAncestor(commit, other string) bool {
+	return runner.Run(exec.Command("git", "merge-base", "--is-ancestor", commit, other)) == nil
+}
+
+// RefExists reports whether ref resolves to a commit (branch, remote branch, tag, or SHA).
+func RefExists(ref string) bool {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	return runner.Run(cmd) == nil
+}
+
+// ResolveCommit returns the SHA of the commit ref points at.
+func ResolveCommit(ref string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	output, err := runner.Output(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// BaseBranchCandidates returns likely base branches that exist in the repository,
+// starting with the branch origin/HEAD points at, followed by main, master, and develop.
+func BaseBranchCandidates() []string {
+	var names []string
+	cmd := exec.Command("git", "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD")
+	if output, err := runner.Output(cmd); err == nil {
+		names = append(names, strings.TrimPrefix(strings.TrimSpace(string(output)), "origin/"))
+	}
+	names = append(names, "main", "master", "develop")
+
+	seen := make(map[string]bool)
+	var candidates []string
+	for _, name := range names {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if local, remote := BranchExists(name); local || remote {
+			candidates = append(candidates, name)
+		}
+	}
+	return candidates
+}
+
+// CreateOptions tune how a new worktree is checked out.
+type CreateOptions struct {
+	// SparsePaths limits the checkout to these directories, relative to the
+	// repository root (cone-mode sparse checkout). Files directly in the
+	// root are always checked out. No paths means a full checkout.
+	SparsePaths []string
+	// SkipLFSSmudge checks out LFS pointers instead of downloading the files.
+	SkipLFSSmudge bool
+}
+
 // CreateWorktree creates a new worktree.
 // If the branch exists, it uses it. Otherwise, it creates a new branch from baseBranch.
-func CreateWorktree(branch, path, baseBranch string) error {
+func CreateWorktree(branch, path, baseBranch string, opts CreateOptions) error {
 	local, remote := BranchExists(branch)
 
-	var cmd *exec.Cmd
 	if local || remote {
 		// Use existing branch
-		cmd = exec.Command("git", "worktree", "add", path, branch)
-	} else {
-		// Create new branch from base
-		cmd = exec.Command("git", "worktree", "add", "-b", branch, path, baseBranch)
+		return addWorktree(path, opts, nil, branch)
+	}
+	// Create new branch from base
+	return addWorktree(path, opts, []string{"-b", branch}, baseBranch)
+}
+
+// SwitchBranch checks out branch in the existing worktree at path, creating
+// it from baseBranch if it exists neither locally nor on a remote.
+func SwitchBranch(path, branch, baseBranch string) error {
+	args := []string{"-C", path, "switch", "--quiet", branch}
+	if local, remote := BranchExists(branch); !local && !remote {
+		args = []string{"-C", path, "switch", "--quiet", "-c", branch, baseBranch}
+	}
+	if out, err := runner.CombinedOutput(exec.Command("git", args...)); err != nil {
+		return fmt.Errorf("git switch: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Stash saves the modified and untracked files of the worktree at path in a
+// stash entry labelled message, leaving a clean working tree.
+func Stash(path, message string) error {
+	cmd := exec.Command("git", "-C", path, "stash", "push", "--include-untracked", "-m", message)
+	if out, err := runner.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("git stash: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// DeleteMergedBranch deletes the local branch like git branch -d: only when
+// it is merged into its upstream, or into HEAD without one.
+func DeleteMergedBranch(branch string) error {
+	cmd := exec.Command("git", "branch", "-d", branch)
+	if out, err := runner.CombinedOutput(cmd); err != nil {
+		// Only git's first line: the rest suggests git branch -D.
+		msg, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+		return fmt.Errorf("git branch -d: %s", msg)
+	}
+	return nil
+}
+
+// DeleteBranch deletes the local branch, merged or not.
+func DeleteBranch(branch string) error {
+	cmd := exec.Command("git", "branch", "-D", branch)
+	if out, err := runner.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("git branch -D: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// CreateDetachedWorktree creates a worktree with a detached HEAD at ref
+// (a tag, commit SHA, or remote branch) without creating a branch.
+func CreateDetachedWorktree(path, ref string, opts CreateOptions) error {
+	return addWorktree(path, opts, []string{"--detach"}, ref)
+}
+
+// CreateOrphanWorktree creates a worktree on a new orphan branch: no history
+// and an empty index and working tree. The branch itself only comes into
+// existence with its first commit.
+func CreateOrphanWorktree(branch, path string, opts CreateOptions) error {
+	if err := addWorktree(path, opts, []string{"--detach"}); err != nil {
+		return err
+	}
+	return finishWorktree(path, opts, [][]string{
+		{"checkout", "--orphan", branch},
+		{"rm", "-r", "-q", "-f", "--ignore-unmatch", "."},
+	})
+}
+
+// finishWorktree runs git steps inside the newly added worktree at path,
+// removing the worktree again if one of them fails.
+func finishWorktree(path string, opts CreateOptions, steps [][]string) error {
+	for _, step := range steps {
+		cmd := exec.Command("git", append([]string{"-C", path}, step...)...)
+		if opts.SkipLFSSmudge {
+			cmd.Env = append(os.Environ(), "GIT_LFS_SKIP_SMUDGE=1")
+		}
+		if out, err := runner.CombinedOutput(cmd); err != nil {
+			_ = RemoveWorktree(path, true)
+			return fmt.Errorf("git %s: %s", strings.Join(step, " "), strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// addWorktree runs git worktree add with flags for path at commitish,
+// showing git's progress on stderr unless quiet mode is on. With a sparse
+// checkout in opts, the files are checked out only after the cone is set.
+func addWorktree(path string, opts CreateOptions, flags []string, commitish ...string) error {
+	if log.Quiet() {
+		flags = append([]string{"--quiet"}, flags...)
+	}
+	sparse := len(opts.SparsePaths) > 0
+	if sparse {
+		flags = append(flags, "--no-checkout")
+	}
+	args := append(append([]string{"worktree", "add"}, flags...), path)
+	cmd := exec.Command("git", append(args, commitish...)...)
+	if opts.SkipLFSSmudge {
+		cmd.Env = append(os.Environ(), "GIT_LFS_SKIP_SMUDGE=1")
+	}
+	out, flush := log.Progress()
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := runner.Run(cmd)
+	flush()
+	if err != nil {
+		return err
 	}
+	if !sparse {
+		return nil
+	}
+	return finishWorktree(path, opts, [][]string{
+		append([]string{"sparse-checkout", "set", "--cone"}, opts.SparsePaths...),
+		{"checkout"},
+	})
+}
 
-	cmd.Stdout = os.Stderr
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+// Push pushes branch from the worktree at path to the branch of the same
+// name on origin, which then becomes its upstream. The refspec is spelled
+// out, since the upstream may be another branch, e.g. the origin/main a
+// worktree was created from. git's progress goes to stderr.
+func Push(path, branch string) error {
+	args := []string{"-C", path, "push"}
+	if log.Quiet() {
+		args = append(args, "--quiet")
+	}
+	ref := "refs/heads/" + branch
+	args = append(args, "--set-upstream", "origin", ref+":"+ref)
+	cmd := exec.Command("git", args...)
+	out, flush := log.Progress()
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := runner.Run(cmd)
+	flush()
+	if err != nil {
+		return fmt.Errorf("failed to push %s: %w", branch, err)
+	}
+	return nil
 }
 
-// RemoveWorktree removes a worktree.
+// RemoveWorktree removes a worktree. Without force it refuses worktrees with
+// modified or untracked files (ErrDirtyWorktree) and, in repositories with
+// remotes, those with commits that are on no remote (*UnpushedError).
 func RemoveWorktree(path string, force bool) error {
 	args := []string{"worktree", "remove"}
 	if force {
 		args = append(args, "--force")
+	} else if n := unpushedWithoutUpstream(path); n > 0 {
+		return &UnpushedError{Path: path, Commits: n}
 	}
 	args = append(args, path)
 
@@ -123,7 +490,7 @@ func RemoveWorktree(path string, force bool) error {
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	err := runner.Run(cmd)
 	if err != nil {
 		stderrStr := stderr.String()
 		if strings.Contains(stderrStr, "contains modified or untracked files") {
@@ -136,6 +503,122 @@ func RemoveWorktree(path string, force bool) error {
 	return nil
 }
 
+// UnregisterWorktree makes git forget the linked worktree at path but leaves
+// its files: it deletes the worktree's administrative directory under
+// <git dir>/worktrees and the .git file that pointed the directory at it.
+// The git directories of its submodules, kept in the administrative
+// directory's modules/, move into the submodules first.
+func UnregisterWorktree(path string) error {
+	dotGit := filepath.Join(path, ".git")
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return fmt.Errorf("%s is not a linked worktree: %w", path, err)
+	}
+	adminDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return fmt.Errorf("%s is not a linked worktree: unexpected %s", path, dotGit)
+	}
+	if !filepath.IsAbs(adminDir) {
+		adminDir = filepath.Join(path, adminDir)
+	}
+	if filepath.Base(filepath.Dir(adminDir)) != "worktrees" {
+		return fmt.Errorf("%s is not a linked worktree: %s points at %s", path, dotGit, adminDir)
+	}
+	if _, err := os.Stat(filepath.Join(adminDir, "locked")); err == nil {
+		return fmt.Errorf("%s is locked (git worktree unlock %s)", path, path)
+	}
+	if err := detachModules(filepath.Join(adminDir, "modules")); err != nil {
+		return fmt.Errorf("failed to keep the submodules of %s: %w", path, err)
+	}
+	if err := os.RemoveAll(adminDir); err != nil {
+		return err
+	}
+	return os.Remove(dotGit)
+}
+
+// module is a submodule's git directory and the checkout it belongs to.
+type module struct {
+	gitDir, dir string
+}
+
+// detachModules moves the git directory of each checked-out submodule under
+// modules into the submodule, in place of its .git file, as if it had been
+// cloned there.
+func detachModules(modules string) error {
+	var found []module
+	if err := findModules(modules, &found); err != nil {
+		return err
+	}
+	// Nested submodules come after their parent; moving them first keeps
+	// their paths valid.
+	for i := len(found) - 1; i >= 0; i-- {
+		m := found[i]
+		dotGit := filepath.Join(m.dir, ".git")
+		if info, err := os.Lstat(dotGit); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if err := os.Remove(dotGit); err != nil {
+			return err
+		}
+		if err := os.Rename(m.gitDir, dotGit); err != nil {
+			return err
+		}
+		cmd := exec.Command("git", "config", "--file", filepath.Join(dotGit, "config"), "--unset", "core.worktree")
+		if err := runner.Run(cmd); err != nil {
+			return fmt.Errorf("failed to update %s: %w", dotGit, err)
+		}
+	}
+	return nil
+}
+
+// findModules appends the submodule git directories under dir, and those of
+// their own submodules, to found. Submodule names may contain slashes, so
+// directories that aren't git directories are searched too.
+func findModules(dir string, found *[]module) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		gitDir := filepath.Join(dir, entry.Name())
+		if _, err := os.Stat(filepath.Join(gitDir, "HEAD")); err != nil {
+			if err := findModules(gitDir, found); err != nil {
+				return err
+			}
+			continue
+		}
+		cmd := exec.Command("git", "config", "--file", filepath.Join(gitDir, "config"), "core.worktree")
+		if output, err := runner.Output(cmd); err == nil {
+			checkout := strings.TrimSpace(string(output))
+			if !filepath.IsAbs(checkout) {
+				checkout = filepath.Join(gitDir, checkout)
+			}
+			*found = append(*found, module{gitDir: gitDir, dir: checkout})
+		}
+		if err := findModules(filepath.Join(gitDir, "modules"), found); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PruneWorktrees removes the registrations of worktrees whose directories no
+// longer exist and returns git's report of what it pruned.
+func PruneWorktrees() (string, error) {
+	cmd := exec.Command("git", "worktree", "prune", "--verbose")
+	output, err := runner.CombinedOutput(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to prune worktrees: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
 // GetWorktreeDir returns the directory where worktrees should be created.
 func GetWorktreeDir(configDir string) (string, error) {
 	repoRoot, err := GetRepoRoot()
@@ -150,8 +633,73 @@ func GetWorktreeDir(configDir string) (string, error) {
 	return filepath.Join(repoRoot, configDir), nil
 }
 
-// SanitizeBranchName sanitizes a branch name for use as a directory name.
-func SanitizeBranchName(branch string) string {
-	// Replace / with -
-	return strings.ReplaceAll(branch, "/", "-")
+// SanitizeOptions tune how branch names become directory names.
+type SanitizeOptions struct {
+	Replacement string // replaces characters invalid in paths (default "-")
+	MaxLength   int    // maximum length in characters; 0 means no limit
+	Lowercase   bool
+	Strip       string // characters removed before anything else
+}
+
+// invalidPathChars can't appear in a directory name on every platform wt
+// supports (Windows being the strictest).
+const invalidPathChars = `/\:*?"<>|`
+
+// SanitizeBranchName sanitizes a branch name for use as a directory name
+// with the default options.
+func SanitizeBranchName(branch string) (string, error) {
+	return Sanitize(branch, SanitizeOptions{})
+}
+
+// Sanitize turns branch into a directory name: characters invalid in paths,
+// whitespace, and control characters become opts.Replacement (one per run),
+// and the result never starts or ends with the replacement or a dot. A
+// branch with nothing left, such as "." or "/", is an error, since its
+// worktree would be worktree_dir itself.
+func Sanitize(branch string, opts SanitizeOptions) (string, error) {
+	repl := opts.Replacement
+	if repl == "" {
+		repl = "-"
+	}
+	if opts.Lowercase {
+		branch = strings.ToLower(branch)
+	}
+
+	var b strings.Builder
+	replaced := false
+	for _, r := range branch {
+		switch {
+		case strings.ContainsRune(opts.Strip, r):
+			continue
+		case strings.ContainsRune(invalidPathChars, r) || unicode.IsSpace(r) || unicode.IsControl(r):
+			if !replaced {
+				b.WriteString(repl)
+				replaced = true
+			}
+			continue
+		}
+		b.WriteRune(r)
+		replaced = false
+	}
+
+	name := trimDirName(b.String(), repl)
+	if opts.MaxLength > 0 {
+		if runes := []rune(name); len(runes) > opts.MaxLength {
+			name = trimDirName(string(runes[:opts.MaxLength]), repl)
+		}
+	}
+	if name == "" {
+		return "", fmt.Errorf("%q leaves no directory name once sanitized", branch)
+	}
+	return name, nil
+}
+
+func trimDirName(name, repl string) string {
+	for {
+		trimmed := strings.Trim(strings.TrimPrefix(strings.TrimSuffix(name, repl), repl), ".")
+		if trimmed == name {
+			return name
+		}
+		name = trimmed
+	}
 }
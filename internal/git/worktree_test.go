@@ -0,0 +1,257 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/default-anton/wt/internal/runner"
+)
+
+func TestListWorktrees(t *testing.T) {
+	fake := runner.NewFake().On("git worktree list --porcelain", runner.Response{
+		Stdout: "worktree /repo\nHEAD aaa\nbranch refs/heads/main\n\n" +
+			"worktree /repo/.worktrees/feature\nHEAD bbb\nbranch refs/heads/feature/x\n\n" +
+			"worktree /repo/.worktrees/detached\nHEAD ccc\ndetached\n",
+	})
+	t.Cleanup(runner.Set(fake))
+
+	got, err := ListWorktrees()
+	if err != nil {
+		t.Fatalf("ListWorktrees: %v", err)
+	}
+
+	want := []Worktree{
+		{Path: "/repo", Branch: "main", Commit: "aaa", IsMain: true},
+		{Path: "/repo/.worktrees/feature", Branch: "feature/x", Commit: "bbb"},
+		{Path: "/repo/.worktrees/detached", Commit: "ccc", Detached: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestWorktreeLabel(t *testing.T) {
+	tests := []struct {
+		wt   Worktree
+		want string
+	}{
+		{Worktree{Path: "/repo/.worktrees/x", Branch: "feature/x", Commit: "0123456789abcdef"}, "feature/x"},
+		{Worktree{Path: "/repo/.worktrees/v1", Commit: "0123456789abcdef", Detached: true}, "0123456"},
+		{Worktree{Path: "/repo/.worktrees/v1"}, "v1"},
+	}
+	for _, tt := range tests {
+		if got := tt.wt.Label(); got != tt.want {
+			t.Errorf("Label(%+v) = %q, want %q", tt.wt, got, tt.want)
+		}
+	}
+}
+
+func TestMergedBranches(t *testing.T) {
+	fake := runner.NewFake().On("git branch --merged main", runner.Response{Stdout: "main\nfeature/done\n"})
+	t.Cleanup(runner.Set(fake))
+
+	got, err := MergedBranches("main")
+	if err != nil {
+		t.Fatalf("MergedBranches: %v", err)
+	}
+	want := map[string]bool{"main": true, "feature/done": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if call := fake.Calls()[0].String(); call != "git branch --merged main --format=%(refname:short)" {
+		t.Fatalf("unexpected call: %s", call)
+	}
+}
+
+func TestGoneBranches(t *testing.T) {
+	fake := runner.NewFake().On("git for-each-ref", runner.Response{
+		Stdout: "main \nmerged-pr [gone]\nahead [ahead 2]\nfeature/old [gone]\n",
+	})
+	t.Cleanup(runner.Set(fake))
+
+	got, err := GoneBranches()
+	if err != nil {
+		t.Fatalf("GoneBranches: %v", err)
+	}
+	if want := []string{"merged-pr", "feature/old"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestRemoveWorktreeDirty(t *testing.T) {
+	fake := runner.NewFake().
+		On("git -C /repo/.worktrees/x remote", runner.Response{}).
+		On("git worktree remove", runner.Response{
+			Stderr: "fatal: '/repo/.worktrees/x' contains modified or untracked files, use --force to delete it\n",
+			Err:    errors.New("exit status 128"),
+		})
+	t.Cleanup(runner.Set(fake))
+
+	err := RemoveWorktree("/repo/.worktrees/x", false)
+	if !errors.Is(err, ErrDirtyWorktree) {
+		t.Fatalf("expected ErrDirtyWorktree, got %v", err)
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 2 || calls[1].String() != "git worktree remove /repo/.worktrees/x" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+}
+
+func TestRemoveWorktreeUnpushed(t *testing.T) {
+	fake := runner.NewFake().
+		On("git -C /repo/.worktrees/x remote", runner.Response{Stdout: "origin\n"}).
+		On("git -C /repo/.worktrees/x rev-list", runner.Response{Stdout: "2\n"}).
+		On("git worktree remove", runner.Response{})
+	t.Cleanup(runner.Set(fake))
+
+	err := RemoveWorktree("/repo/.worktrees/x", false)
+	var unpushed *UnpushedError
+	if !errors.As(err, &unpushed) || unpushed.Commits != 2 {
+		t.Fatalf("expected UnpushedError with 2 commits, got %v", err)
+	}
+	for _, call := range fake.Calls() {
+		if strings.HasPrefix(call.String(), "git worktree remove") {
+			t.Fatalf("removed despite unpushed commits: %s", call)
+		}
+	}
+
+	if err := RemoveWorktree("/repo/.worktrees/x", true); err != nil {
+		t.Fatalf("RemoveWorktree with force: %v", err)
+	}
+}
+
+func TestCreateWorktreeNewBranch(t *testing.T) {
+	fake := runner.NewFake().
+		On("git show-ref", runner.Response{Err: errors.New("exit status 1")}).
+		On("git worktree add", runner.Response{})
+	t.Cleanup(runner.Set(fake))
+
+	if err := CreateWorktree("feature", "/repo/.worktrees/feature", "main", CreateOptions{}); err != nil {
+		t.Fatalf("CreateWorktree: %v", err)
+	}
+
+	calls := fake.Calls()
+	last := calls[len(calls)-1].String()
+	if last != "git worktree add -b feature /repo/.worktrees/feature main" {
+		t.Fatalf("unexpected worktree add call: %s", last)
+	}
+}
+
+func TestCreateOrphanWorktreeCleansUpOnFailure(t *testing.T) {
+	fake := runner.NewFake().
+		On("git worktree", runner.Response{}).
+		On("git -C /repo/.worktrees/docs checkout", runner.Response{
+			Stderr: "fatal: a branch named 'docs' already exists\n",
+			Err:    errors.New("exit status 128"),
+		})
+	t.Cleanup(runner.Set(fake))
+
+	err := CreateOrphanWorktree("docs", "/repo/.worktrees/docs", CreateOptions{})
+	if err == nil || !strings.Contains(err.Error(), "a branch named 'docs' already exists") {
+		t.Fatalf("expected checkout error, got %v", err)
+	}
+
+	var got []string
+	for _, call := range fake.Calls() {
+		got = append(got, call.String())
+	}
+	want := []string{
+		"git worktree add --detach /repo/.worktrees/docs",
+		"git -C /repo/.worktrees/docs checkout --orphan docs",
+		"git worktree remove --force /repo/.worktrees/docs",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("calls = %q, want %q", got, want)
+	}
+}
+
+func TestGetRepoRootBare(t *testing.T) {
+	fake := runner.NewFake().
+		On("git rev-parse --show-toplevel", runner.Response{
+			Stderr: "fatal: this operation must be run in a work tree\n",
+			Err:    errors.New("exit status 128"),
+		}).
+		On("git rev-parse --is-bare-repository", runner.Response{Stdout: "true\n"}).
+		On("git rev-parse --absolute-git-dir", runner.Response{Stdout: "/src/app.git\n"})
+	t.Cleanup(runner.Set(fake))
+
+	got, err := GetRepoRoot()
+	if err != nil || got != "/src/app.git" {
+		t.Fatalf("GetRepoRoot() = %q, %v; want /src/app.git", got, err)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		branch string
+		opts   SanitizeOptions
+		want   string
+	}{
+		{branch: "feature/login", want: "feature-login"},
+		{branch: "fix: crash", want: "fix-crash"},
+		{branch: "a--b", want: "a--b"},
+		{branch: `what?|"why"<now>*`, want: "what-why-now"},
+		{branch: "/leading/and/trailing/", want: "leading-and-trailing"},
+		{branch: "release/1.0.", want: "release-1.0"},
+		{branch: "Feature/Login", opts: SanitizeOptions{Lowercase: true, Replacement: "_"}, want: "feature_login"},
+		{branch: "feature/PROJ-1-long-name", opts: SanitizeOptions{MaxLength: 15}, want: "feature-PROJ-1"},
+		{branch: "feat(api)/x", opts: SanitizeOptions{Strip: "()"}, want: "featapi-x"},
+	}
+	for _, tt := range tests {
+		if got, err := Sanitize(tt.branch, tt.opts); err != nil || got != tt.want {
+			t.Errorf("Sanitize(%q, %+v) = %q, %v; want %q", tt.branch, tt.opts, got, err, tt.want)
+		}
+	}
+
+	for _, branch := range []string{".", "..", "/", "-/.", "()"} {
+		if got, err := Sanitize(branch, SanitizeOptions{Strip: "()"}); err == nil {
+			t.Errorf("Sanitize(%q) = %q, want an error", branch, got)
+		}
+	}
+}
+
+func TestBaseBranchCandidates(t *testing.T) {
+	fake := runner.NewFake().
+		On("git symbolic-ref", runner.Response{Stdout: "origin/trunk\n"}).
+		On("git show-ref", runner.Response{Err: errors.New("exit status 1")}).
+		On("git show-ref --verify --quiet refs/remotes/origin/trunk", runner.Response{}).
+		On("git show-ref --verify --quiet refs/heads/master", runner.Response{})
+	t.Cleanup(runner.Set(fake))
+
+	got := BaseBranchCandidates()
+	want := []string{"trunk", "master"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestUsesLFS(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes string
+		want       bool
+	}{
+		{"lfs pattern", "*.psd filter=lfs diff=lfs merge=lfs -text\n", true},
+		{"commented out", "# *.psd filter=lfs\n", false},
+		{"other filter", "*.go filter=gofmt\n", false},
+		{"no attributes", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.attributes != "" {
+				if err := os.WriteFile(filepath.Join(dir, ".gitattributes"), []byte(tt.attributes), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := UsesLFS(dir); got != tt.want {
+				t.Errorf("UsesLFS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
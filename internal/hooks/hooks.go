@@ -1,20 +1,89 @@
 package hooks
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/charmbracelet/lipgloss"
 
 	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/copy"
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/runner"
+	"github.com/default-anton/wt/internal/styles"
+)
+
+// Status is how a hook ended.
+type Status string
+
+const (
+	OK      Status = "ok"
+	Skipped Status = "skipped" // a guard did not match
+	Failed  Status = "failed"
 )
 
+// Result is the outcome of one hook.
+type Result struct {
+	Name     string
+	Status   Status
+	Duration time.Duration // zero for skipped hooks
+}
+
+// output is the hook_output mode; see SetOutput.
+var output = config.HookOutputStream
+
+// SetOutput sets when Run shows the output of hooks, as one of the
+// hook_output values. Empty means config.HookOutputStream.
+func SetOutput(mode string) {
+	if mode == "" {
+		mode = config.HookOutputStream
+	}
+	output = mode
+}
+
 // Run executes the post-creation hooks in the given working directory.
 // Hooks are executed in order. If a hook fails, execution stops and an error is returned.
 // Output from hooks is redirected to os.Stderr to ensure it is visible even when
-// stdout is captured (e.g., in shell integrations).
-func Run(hooks []config.Hook, workDir string) error {
-	for _, hook := range hooks {
+// stdout is captured (e.g., in shell integrations), with every line prefixed
+// by the hook's name in a color of its own unless quiet mode is on; without a
+// prefix the hook gets stderr itself, terminal and all. Unless
+// SetOutput chose to stream it, the output is captured instead, and shown
+// only for a failed hook with hook_output = "on-failure".
+// srcDir is the directory files were copied from, used by if_changed; when
+// empty, hooks guarded by if_changed always run.
+// repoRoot is where hooks with dir = "@repo_root" run.
+// The results cover every hook that was run or skipped, up to a failure.
+func Run(hooks []config.Hook, workDir, srcDir, repoRoot, branch string) ([]Result, error) {
+	return RunEnv(hooks, workDir, srcDir, repoRoot, branch, nil)
+}
+
+// RunEnv is Run with env, KEY=VALUE pairs, added to the hooks' environment.
+func RunEnv(hooks []config.Hook, workDir, srcDir, repoRoot, branch string, env []string) ([]Result, error) {
+	var results []Result
+	for i, hook := range hooks {
+		// Check if_branch condition
+		if hook.IfBranch != "" {
+			matched, err := MatchBranch(hook.IfBranch, branch)
+			if err != nil {
+				return append(results, Result{Name: hook.Name, Status: Failed}), fmt.Errorf("hook %q: invalid if_branch: %w", hook.Name, err)
+			}
+			if !matched {
+				log.Infof("Skipping hook %q: branch %s does not match %s", hook.Name, branch, hook.IfBranch)
+				results = append(results, Result{Name: hook.Name, Status: Skipped})
+				continue
+			}
+			log.Explainf("hook %q: branch %s matches %s", hook.Name, branch, hook.IfBranch)
+		}
+
 		// Check if_exists condition
 		if hook.IfExists != "" {
 			checkPath := hook.IfExists
@@ -22,23 +91,163 @@ func Run(hooks []config.Hook, workDir string) error {
 				checkPath = filepath.Join(workDir, checkPath)
 			}
 			if _, err := os.Stat(checkPath); os.IsNotExist(err) {
-				fmt.Fprintf(os.Stderr, "Skipping hook %q: %s not found\n", hook.Name, hook.IfExists)
+				log.Infof("Skipping hook %q: %s not found", hook.Name, hook.IfExists)
+				results = append(results, Result{Name: hook.Name, Status: Skipped})
 				continue
 			}
+			log.Explainf("hook %q: %s exists", hook.Name, checkPath)
 		}
 
-		fmt.Fprintf(os.Stderr, "Running hook: %s\n", hook.Name)
+		// Check if_changed condition
+		if hook.IfChanged != "" && srcDir != "" {
+			changed, err := copy.Changed(filepath.Join(srcDir, hook.IfChanged), filepath.Join(workDir, hook.IfChanged))
+			if err != nil {
+				return append(results, Result{Name: hook.Name, Status: Failed}), fmt.Errorf("hook %q: if_changed: %w", hook.Name, err)
+			}
+			missing := copy.MissingDepDir(hook.IfChanged, srcDir, workDir)
+			switch {
+			case changed:
+				log.Explainf("hook %q: %s differs from %s", hook.Name, hook.IfChanged, srcDir)
+			case missing != "":
+				log.Explainf("hook %q: %s unchanged, but %s is missing", hook.Name, hook.IfChanged, missing)
+			default:
+				log.Infof("Skipping hook %q: %s unchanged", hook.Name, hook.IfChanged)
+				results = append(results, Result{Name: hook.Name, Status: Skipped})
+				continue
+			}
+		}
+
+		dir, err := hookDir(hook.Dir, workDir, repoRoot)
+		if err != nil {
+			return append(results, Result{Name: hook.Name, Status: Failed}), fmt.Errorf("hook %q: %w", hook.Name, err)
+		}
+
+		log.Infof("Running hook: %s", hook.Name)
 
 		cmd := exec.Command("sh", "-c", hook.Run)
-		cmd.Dir = workDir
-		cmd.Env = os.Environ() // Inherit environment variables
-		cmd.Stdout = os.Stderr
-		cmd.Stderr = os.Stderr
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), env...) // Inherit environment variables
+		out, flush := log.Progress()
+		var captured bytes.Buffer
+		prefixed := &prefixWriter{out: out, start: true}
+		if output != config.HookOutputStream {
+			prefixed.out = &captured
+		}
+		if !log.Quiet() {
+			prefixed.prefix = prefixStyles[i%len(prefixStyles)]().Render("["+hook.Name+"]") + " "
+		}
+		if prefixed.prefix == "" && output == config.HookOutputStream {
+			// Nothing to add: hand the hook the terminal itself, so it can
+			// show progress bars and colors.
+			cmd.Stdout, cmd.Stderr = out, out
+		} else {
+			cmd.Stdout, cmd.Stderr = prefixed, prefixed
+		}
 		cmd.Stdin = os.Stdin
 
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("hook %q failed: %w", hook.Name, err)
+		started := time.Now()
+		err = runner.Run(cmd)
+		prefixed.end()
+		if err != nil && output == config.HookOutputOnFailure {
+			out.Write(captured.Bytes())
 		}
+		flush()
+		elapsed := time.Since(started)
+		if err != nil {
+			err = fmt.Errorf("hook %q failed: %w", hook.Name, err)
+			if output == config.HookOutputQuiet {
+				err = fmt.Errorf("%w (output hidden by hook_output = %q)", err, output)
+			}
+			return append(results, Result{Name: hook.Name, Status: Failed, Duration: elapsed}), err
+		}
+		results = append(results, Result{Name: hook.Name, Status: OK, Duration: elapsed})
+	}
+	return results, nil
+}
+
+// prefixStyles color the prefixes of consecutive hooks differently. They are
+// looked up per hook so the active theme applies.
+var prefixStyles = []func() lipgloss.Style{
+	func() lipgloss.Style { return styles.BranchStyle },
+	func() lipgloss.Style { return styles.CursorStyle },
+	func() lipgloss.Style { return styles.AheadStyle },
+	func() lipgloss.Style { return styles.MatchStyle },
+}
+
+// prefixWriter passes a hook's output through, starting every line with
+// prefix, if any. A carriage return starts a line too, so redrawn progress lines keep
+// their prefix. Output is not buffered, so prompts without a newline still
+// show up.
+type prefixWriter struct {
+	out    io.Writer
+	prefix string
+	start  bool // the next byte starts a line
+	mu     sync.Mutex
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var buf []byte
+	for _, c := range b {
+		if p.start && c != '\n' && c != '\r' {
+			buf = append(buf, p.prefix...)
+			p.start = false
+		}
+		buf = append(buf, c)
+		if c == '\n' || c == '\r' {
+			p.start = true
+		}
+	}
+	if _, err := p.out.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// end terminates an unfinished last line.
+func (p *prefixWriter) end() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.start {
+		p.out.Write([]byte{'\n'})
+		p.start = true
+	}
+}
+
+// hookDir resolves a hook's dir setting to the directory it runs in.
+func hookDir(dir, workDir, repoRoot string) (string, error) {
+	path := filepath.Join(workDir, dir)
+	if rest, ok := strings.CutPrefix(dir, config.RepoRootDir); ok {
+		if repoRoot == "" {
+			return "", fmt.Errorf("dir %s: no repository root to run in", dir)
+		}
+		path = filepath.Join(repoRoot, rest)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("dir %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("dir %s: %s is not a directory", dir, path)
+	}
+	return path, nil
+}
+
+// MatchBranch reports whether branch matches pattern. Patterns wrapped in slashes
+// (e.g. "/^release-\d+$/") are treated as regular expressions; anything else is a
+// glob where "*" stops at "/" and "**" spans path segments.
+func MatchBranch(pattern, branch string) (bool, error) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(branch), nil
+	}
+
+	if !doublestar.ValidatePattern(pattern) {
+		return false, fmt.Errorf("bad glob pattern %q", pattern)
 	}
-	return nil
+	return doublestar.Match(pattern, branch)
 }
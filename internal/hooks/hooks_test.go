@@ -0,0 +1,155 @@
+package hooks
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/runner"
+)
+
+func TestMatchBranch(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		branch  string
+		want    bool
+		wantErr bool
+	}{
+		{name: "glob prefix", pattern: "hotfix/*", branch: "hotfix/crash", want: true},
+		{name: "glob does not cross slash", pattern: "hotfix/*", branch: "hotfix/a/b", want: false},
+		{name: "double star crosses slash", pattern: "hotfix/**", branch: "hotfix/a/b", want: true},
+		{name: "glob mismatch", pattern: "hotfix/*", branch: "feature/login", want: false},
+		{name: "exact", pattern: "main", branch: "main", want: true},
+		{name: "regex", pattern: `/^release-\d+$/`, branch: "release-42", want: true},
+		{name: "regex mismatch", pattern: `/^release-\d+$/`, branch: "release-x", want: false},
+		{name: "invalid regex", pattern: "/(/", branch: "x", wantErr: true},
+		{name: "invalid glob", pattern: "[", branch: "x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MatchBranch(tt.pattern, tt.branch)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for pattern %q", tt.pattern)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MatchBranch(%q, %q) error: %v", tt.pattern, tt.branch, err)
+			}
+			if got != tt.want {
+				t.Fatalf("MatchBranch(%q, %q) = %v, want %v", tt.pattern, tt.branch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunResults(t *testing.T) {
+	hooks := []config.Hook{
+		{Name: "Install", Run: "true"},
+		{Name: "Hotfix only", Run: "true", IfBranch: "hotfix/*"},
+		{Name: "Migrate", Run: "exit 3"},
+		{Name: "Never reached", Run: "true"},
+	}
+	results, err := Run(hooks, t.TempDir(), "", "", "feature")
+	if err == nil {
+		t.Fatal("Run() succeeded, want the failure of Migrate")
+	}
+	for i := range results {
+		results[i].Duration = 0
+	}
+	want := []Result{{Name: "Install", Status: OK}, {Name: "Hotfix only", Status: Skipped}, {Name: "Migrate", Status: Failed}}
+	if !reflect.DeepEqual(results, want) {
+		t.Fatalf("results = %v, want %v", results, want)
+	}
+}
+
+func TestRunDir(t *testing.T) {
+	workDir, repoRoot := t.TempDir(), t.TempDir()
+	if err := os.Mkdir(filepath.Join(workDir, "web"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	hooks := []config.Hook{
+		{Name: "Web", Run: "pwd > " + filepath.Join(workDir, "web.out"), Dir: "web"},
+		{Name: "Root", Run: "pwd > " + filepath.Join(workDir, "root.out"), Dir: config.RepoRootDir},
+	}
+	if _, err := Run(hooks, workDir, "", repoRoot, "feature"); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	for file, want := range map[string]string{"web.out": filepath.Join(workDir, "web"), "root.out": repoRoot} {
+		got, err := os.ReadFile(filepath.Join(workDir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.TrimSpace(string(got)) != want {
+			t.Errorf("%s = %q, want %q", file, strings.TrimSpace(string(got)), want)
+		}
+	}
+
+	_, err := Run([]config.Hook{{Name: "Missing", Run: "true", Dir: "api"}}, workDir, "", repoRoot, "feature")
+	if err == nil {
+		t.Fatal("Run() with a missing dir succeeded, want an error")
+	}
+}
+
+// outputRunner records where a command's output would go.
+type outputRunner struct {
+	stdout, stderr io.Writer
+}
+
+func (r *outputRunner) Run(cmd *exec.Cmd) error {
+	r.stdout, r.stderr = cmd.Stdout, cmd.Stderr
+	return nil
+}
+
+func TestRunOutput(t *testing.T) {
+	r := &outputRunner{}
+	t.Cleanup(runner.Set(r))
+	hooks := []config.Hook{{Name: "Install", Run: "npm ci"}}
+
+	if _, err := Run(hooks, t.TempDir(), "", "", "feature"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.stdout.(*prefixWriter); !ok {
+		t.Errorf("stdout = %T, want a *prefixWriter adding the hook's name", r.stdout)
+	}
+
+	log.SetQuiet(true)
+	t.Cleanup(func() { log.SetQuiet(false) })
+	if _, err := Run(hooks, t.TempDir(), "", "", "feature"); err != nil {
+		t.Fatal(err)
+	}
+	if r.stdout != os.Stderr || r.stderr != os.Stderr {
+		t.Errorf("quiet: output goes to %T and %T, want os.Stderr itself", r.stdout, r.stderr)
+	}
+
+	SetOutput(config.HookOutputOnFailure)
+	t.Cleanup(func() { SetOutput("") })
+	if _, err := Run(hooks, t.TempDir(), "", "", "feature"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.stdout.(*prefixWriter); !ok {
+		t.Errorf("on-failure: stdout = %T, want a *prefixWriter capturing it", r.stdout)
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var out strings.Builder
+	p := &prefixWriter{out: &out, prefix: "[x] ", start: true}
+	for _, chunk := range []string{"one\ntw", "o\r\nspin\rdone", ""} {
+		p.Write([]byte(chunk))
+	}
+	p.end()
+	want := "[x] one\n[x] two\r\n[x] spin\r[x] done\n"
+	if out.String() != want {
+		t.Fatalf("got %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,250 @@
+// Package log writes wt's own messages to stderr: informational progress
+// lines (silenced by --quiet), explanations of decisions (shown with
+// --explain), and debug output (only shown with --verbose or WT_DEBUG=1).
+// Callers can log unconditionally.
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+
+	"github.com/default-anton/wt/internal/shquote"
+)
+
+var (
+	mu      sync.Mutex
+	out     io.Writer // nil means os.Stderr, looked up on every write
+	enabled bool
+	explain bool
+	quiet   bool
+	reduced bool
+	trace   bool
+)
+
+// Enable turns debug output on or off.
+func Enable(on bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = on
+}
+
+// Enabled reports whether debug output is on.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return enabled
+}
+
+// SetExplain turns explanations of decisions on or off.
+func SetExplain(on bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	explain = on
+}
+
+// SetQuiet turns informational output off or on.
+func SetQuiet(on bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	quiet = on
+}
+
+// Quiet reports whether informational output is off.
+func Quiet() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return quiet
+}
+
+// SetTrace turns tracing of external commands on or off.
+func SetTrace(on bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	trace = on
+}
+
+// SetReducedMotion turns plain, line-by-line progress output on or off.
+func SetReducedMotion(on bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	reduced = on
+}
+
+// ReducedMotion reports whether progress output should avoid animation.
+func ReducedMotion() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return reduced
+}
+
+// Infof prints a formatted progress line such as "Copying files...", unless
+// quiet mode is on. Warnings and errors should not use it.
+func Infof(format string, args ...any) {
+	mu.Lock()
+	defer mu.Unlock()
+	if quiet {
+		return
+	}
+	fmt.Fprintf(writer(), format+"\n", args...)
+}
+
+// SetOutput redirects informational and debug output to w and returns a function restoring the
+// previous writer.
+func SetOutput(w io.Writer) (restore func()) {
+	mu.Lock()
+	prev := out
+	out = w
+	mu.Unlock()
+	return func() {
+		mu.Lock()
+		out = prev
+		mu.Unlock()
+	}
+}
+
+// Debugf prints a formatted debug line.
+func Debugf(format string, args ...any) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !enabled {
+		return
+	}
+	fmt.Fprintf(writer(), "[wt] "+format+"\n", args...)
+}
+
+// Explainf prints why wt made a decision, such as checking out an existing
+// branch or skipping a hook. Explanations are part of the debug output and
+// can also be shown on their own with --explain, even in quiet mode.
+func Explainf(format string, args ...any) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !explain && !enabled {
+		return
+	}
+	fmt.Fprintf(writer(), "[wt] "+format+"\n", args...)
+}
+
+// writer returns the output writer; callers hold mu.
+func writer() io.Writer {
+	if out == nil {
+		return os.Stderr
+	}
+	return out
+}
+
+// Progress returns the writer for the output of a child process that may
+// draw progress (git, hooks) and a function to call once the process has
+// exited. Normally that is stderr itself. With reduced motion the child gets
+// a pipe instead, so tools that animate only on a terminal print plain
+// lines, and a line redrawn with carriage returns is printed once, as it
+// ended up. When stdin is a terminal the child may ask for input, so a line
+// it leaves unfinished, such as a prompt, is shown right away.
+func Progress() (w io.Writer, flush func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !reduced {
+		return os.Stderr, func() {}
+	}
+	p := &plainWriter{out: writer(), prompts: term.IsTerminal(os.Stdin.Fd())}
+	return p, p.flush
+}
+
+// plainWriter passes output through line by line, dropping the parts of a
+// line that a carriage return would have overwritten.
+type plainWriter struct {
+	out     io.Writer
+	prompts bool // show an unfinished line at the end of every write
+	line    []byte
+	sent    int  // how much of line was shown before it was finished
+	cr      bool // the last byte was a carriage return
+	redrawn bool // a carriage return started line over
+}
+
+func (p *plainWriter) Write(b []byte) (int, error) {
+	for _, c := range b {
+		switch {
+		case c == '\n':
+			p.cr, p.redrawn = false, false
+			p.line = append(p.line, c)
+			if _, err := p.out.Write(p.line[p.sent:]); err != nil {
+				return 0, err
+			}
+			p.line, p.sent = p.line[:0], 0
+		case c == '\r':
+			p.cr = true
+		default:
+			if p.cr {
+				if p.sent > 0 {
+					// What was shown can't be taken back: end it, and let
+					// the line as it ends up follow on a line of its own.
+					if _, err := p.out.Write([]byte{'\n'}); err != nil {
+						return 0, err
+					}
+				}
+				p.line, p.sent = p.line[:0], 0
+				p.cr, p.redrawn = false, true
+			}
+			p.line = append(p.line, c)
+		}
+	}
+	if p.prompts && !p.cr && !p.redrawn && p.sent < len(p.line) {
+		if _, err := p.out.Write(p.line[p.sent:]); err != nil {
+			return 0, err
+		}
+		p.sent = len(p.line)
+	}
+	return len(b), nil
+}
+
+// flush prints an unterminated last line.
+func (p *plainWriter) flush() {
+	if len(p.line) > 0 {
+		p.out.Write(append(p.line[p.sent:], '\n'))
+		p.line, p.sent = p.line[:0], 0
+	}
+}
+
+// Command logs an executed command with its working directory, how long it
+// took, and how it failed, if it did.
+func Command(args []string, dir string, elapsed time.Duration, err error) {
+	if !Enabled() {
+		return
+	}
+	line := shquote.Join(args)
+	if dir != "" {
+		line += " (in " + dir + ")"
+	}
+	line += " " + elapsed.Round(time.Millisecond).String()
+	if err != nil {
+		line += ": " + err.Error()
+	}
+	Debugf("%s", line)
+}
+
+// Trace prints a command about to run as a shell line reproducing it, like
+// set -x does: "+ cd /repo && GIT_DIR=x git status". env lists variables the
+// command gets on top of wt's own environment. Traces ignore quiet mode.
+func Trace(args []string, dir string, env []string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !trace {
+		return
+	}
+	var b strings.Builder
+	b.WriteString("+ ")
+	if dir != "" {
+		b.WriteString("cd " + shquote.Quote(dir) + " && ")
+	}
+	for _, kv := range env {
+		name, value, _ := strings.Cut(kv, "=")
+		b.WriteString(name + "=" + shquote.Quote(value) + " ")
+	}
+	b.WriteString(shquote.Join(args))
+	fmt.Fprintln(writer(), b.String())
+}
@@ -0,0 +1,114 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCommand(t *testing.T) {
+	var buf bytes.Buffer
+	t.Cleanup(SetOutput(&buf))
+	t.Cleanup(func() { Enable(false) })
+
+	Command([]string{"git", "status"}, "", time.Millisecond, nil)
+	if buf.Len() != 0 {
+		t.Fatalf("logged while disabled: %q", buf.String())
+	}
+
+	Enable(true)
+	Command([]string{"sh", "-c", "npm install"}, "/repo/.worktrees/x", 1500*time.Microsecond, errors.New("exit status 1"))
+	want := "[wt] sh -c 'npm install' (in /repo/.worktrees/x) 2ms: exit status 1\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	var buf bytes.Buffer
+	t.Cleanup(SetOutput(&buf))
+	t.Cleanup(func() { SetTrace(false); SetQuiet(false) })
+
+	Trace([]string{"git", "status"}, "", nil)
+	if buf.Len() != 0 {
+		t.Fatalf("traced while off: %q", buf.String())
+	}
+
+	SetTrace(true)
+	SetQuiet(true)
+	Trace([]string{"sh", "-c", "echo 'hi' $HOME"}, "/repo/my worktree", []string{"GIT_LFS_SKIP_SMUDGE=1", "MSG=a b"})
+	Trace([]string{"git", "worktree", "add", "-b", "feat/x", "/repo/.worktrees/feat-x", ""}, "", nil)
+	want := `+ cd '/repo/my worktree' && GIT_LFS_SKIP_SMUDGE=1 MSG='a b' sh -c 'echo '\''hi'\'' $HOME'
++ git worktree add -b feat/x /repo/.worktrees/feat-x ''
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestInfofQuiet(t *testing.T) {
+	var buf bytes.Buffer
+	t.Cleanup(SetOutput(&buf))
+	t.Cleanup(func() { SetQuiet(false) })
+
+	Infof("Branch name: %s", "feature")
+	SetQuiet(true)
+	Infof("Copying files...")
+	if got, want := buf.String(), "Branch name: feature\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestExplainf(t *testing.T) {
+	var buf bytes.Buffer
+	t.Cleanup(SetOutput(&buf))
+	t.Cleanup(func() { SetExplain(false); SetQuiet(false) })
+
+	Explainf("branch: %s exists", "feature")
+	if buf.Len() != 0 {
+		t.Fatalf("explained while off: %q", buf.String())
+	}
+
+	SetExplain(true)
+	SetQuiet(true)
+	Explainf("branch: %s exists", "feature")
+	if got, want := buf.String(), "[wt] branch: feature exists\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestProgressReducedMotion(t *testing.T) {
+	var buf bytes.Buffer
+	t.Cleanup(SetOutput(&buf))
+	t.Cleanup(func() { SetReducedMotion(false) })
+
+	SetReducedMotion(true)
+	w, flush := Progress()
+	w.Write([]byte("Preparing worktree\nUpdating files:  50% (1/2)\rUpdating files: 10"))
+	w.Write([]byte("0% (2/2)\rUpdating files: 100% (2/2), done.\r\nHEAD is now at abc"))
+	flush()
+	want := "Preparing worktree\nUpdating files: 100% (2/2), done.\nHEAD is now at abc\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestPlainWriterPrompts(t *testing.T) {
+	var buf bytes.Buffer
+	p := &plainWriter{out: &buf, prompts: true}
+
+	p.Write([]byte("Install dependencies? [y/N] "))
+	if got, want := buf.String(), "Install dependencies? [y/N] "; got != want {
+		t.Fatalf("prompt: got %q, want %q", got, want)
+	}
+	p.Write([]byte("y\nFetching:  50%\rFetching: 100%"))
+	p.Write([]byte(", done.\n"))
+	p.Write([]byte("Name: "))
+	p.Write([]byte("\rName? "))
+	p.flush()
+	want := "Install dependencies? [y/N] y\nFetching: 100%, done.\nName: \nName? \n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,99 @@
+package preprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/default-anton/wt/internal/log"
+)
+
+// Built-in preprocessing modes.
+const (
+	ModeSlug = "slug" // lowercase words joined by dashes
+	ModeJira = "jira" // an ABC-123 ticket key followed by the slug of the rest
+)
+
+var (
+	nonSlug   = regexp.MustCompile(`[^a-z0-9/]+`)
+	ticketKey = regexp.MustCompile(`(?i)\b([a-z][a-z0-9]+-[0-9]+)\b`)
+)
+
+// Builtin derives branch names without running a script: an optional regular
+// expression rewrite (preprocess_match/preprocess_replace), then an optional
+// mode (preprocess).
+type Builtin struct {
+	Mode    string
+	Match   *regexp.Regexp
+	Replace string
+}
+
+// ParseBuiltin validates the built-in preprocessing settings. It returns nil
+// when none are set.
+func ParseBuiltin(mode, match, replace string) (*Builtin, error) {
+	if mode == "" && match == "" {
+		if replace != "" {
+			return nil, fmt.Errorf("preprocess_replace needs preprocess_match")
+		}
+		return nil, nil
+	}
+	switch mode {
+	case "", ModeSlug, ModeJira:
+	default:
+		return nil, fmt.Errorf("preprocess: unknown mode %q (supported: %s, %s)", mode, ModeSlug, ModeJira)
+	}
+	b := &Builtin{Mode: mode, Replace: replace}
+	if match != "" {
+		re, err := regexp.Compile(match)
+		if err != nil {
+			return nil, fmt.Errorf("preprocess_match: %w", err)
+		}
+		b.Match = re
+	}
+	return b, nil
+}
+
+// Apply turns input into a branch name.
+func (b *Builtin) Apply(input string) (string, error) {
+	branch := input
+	if b.Match != nil {
+		if !b.Match.MatchString(branch) {
+			return "", fmt.Errorf("preprocess_match %q does not match %q", b.Match, input)
+		}
+		branch = strings.TrimSpace(b.Match.ReplaceAllString(branch, b.Replace))
+		log.Explainf("preprocess: %q rewritten by preprocess_match to %q", input, branch)
+	}
+	switch b.Mode {
+	case ModeSlug:
+		branch = Slug(branch)
+	case ModeJira:
+		loc := ticketKey.FindStringSubmatchIndex(branch)
+		if loc == nil {
+			return "", fmt.Errorf("no ticket key like ABC-123 in %q", input)
+		}
+		key := strings.ToUpper(branch[loc[2]:loc[3]])
+		rest := Slug(strings.ReplaceAll(branch[:loc[0]]+" "+branch[loc[1]:], "/", " "))
+		branch = key
+		if rest != "" {
+			branch += "-" + rest
+		}
+	}
+	if branch == "" {
+		return "", fmt.Errorf("preprocessing %q left an empty branch name", input)
+	}
+	log.Explainf("preprocess: %q -> %q (built-in)", input, branch)
+	return branch, nil
+}
+
+// Slug lowercases s and joins its words with dashes, keeping slashes so
+// "Feat/Add Login!" becomes "feat/add-login".
+func Slug(s string) string {
+	parts := strings.Split(nonSlug.ReplaceAllString(strings.ToLower(s), "-"), "/")
+	kept := parts[:0]
+	for _, part := range parts {
+		if part = strings.Trim(part, "-"); part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, "/")
+}
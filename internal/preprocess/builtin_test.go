@@ -0,0 +1,52 @@
+package preprocess
+
+import "testing"
+
+func TestBuiltinApply(t *testing.T) {
+	tests := []struct {
+		name, mode, match, replace string
+		input, want                string
+		wantErr                    bool
+	}{
+		{name: "slug", mode: ModeSlug, input: "Fix Login Bug!", want: "fix-login-bug"},
+		{name: "slug keeps slashes", mode: ModeSlug, input: "Feat/ Add SSO ", want: "feat/add-sso"},
+		{name: "jira key first", mode: ModeJira, input: "PROJ-123: Fix login", want: "PROJ-123-fix-login"},
+		{name: "jira key later", mode: ModeJira, input: "fix login proj-9", want: "PROJ-9-fix-login"},
+		{name: "jira key only", mode: ModeJira, input: "abc-7", want: "ABC-7"},
+		{name: "jira flattens slashes", mode: ModeJira, input: "ABC-7 api/auth fix", want: "ABC-7-api-auth-fix"},
+		{name: "jira without key", mode: ModeJira, input: "fix login", wantErr: true},
+		{name: "match", match: `^https://github\.com/.+/issues/(\d+)$`, replace: "issue-$1", input: "https://github.com/a/b/issues/42", want: "issue-42"},
+		{name: "match then slug", mode: ModeSlug, match: `^(\w+): (.*)$`, replace: "$1/$2", input: "Feat: Dark Mode", want: "feat/dark-mode"},
+		{name: "no match", match: `^\d+$`, input: "abc", wantErr: true},
+		{name: "slug of nothing", mode: ModeSlug, input: "!!!", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := ParseBuiltin(tt.mode, tt.match, tt.replace)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := b.Apply(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Apply(%q) = %q, want an error", tt.input, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("Apply(%q) = %q, %v; want %q", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBuiltin(t *testing.T) {
+	if b, err := ParseBuiltin("", "", ""); b != nil || err != nil {
+		t.Fatalf("ParseBuiltin() = %v, %v; want nil, nil", b, err)
+	}
+	for _, args := range [][3]string{{"kebab", "", ""}, {"", "(", ""}, {"", "", "x"}} {
+		if _, err := ParseBuiltin(args[0], args[1], args[2]); err == nil {
+			t.Errorf("ParseBuiltin(%q): expected error", args)
+		}
+	}
+}
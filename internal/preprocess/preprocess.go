@@ -2,18 +2,50 @@ package preprocess
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"syscall"
+
+	"github.com/default-anton/wt/internal/log"
+	"github.com/default-anton/wt/internal/runner"
 )
 
+// interpreters run scripts that can't be executed directly (no executable
+// bit or no shebang line), chosen by file extension.
+var interpreters = map[string]string{
+	".sh":   "sh",
+	".bash": "bash",
+	".zsh":  "zsh",
+	".py":   "python3",
+	".rb":   "ruby",
+	".js":   "node",
+	".mjs":  "node",
+	".pl":   "perl",
+}
+
+// Result is what preprocessing derived from the input. Scripts print either
+// a plain branch name or a JSON object with these fields, e.g.
+// {"branch": "feat/login", "base": "develop", "dir": "login"}.
+type Result struct {
+	Branch string `json:"branch"`
+	Base   string `json:"base,omitempty"` // base branch for a new branch
+	Dir    string `json:"dir,omitempty"`  // worktree directory name
+}
+
 // Run executes the preprocessing script with the given input and returns the branch name.
-// The script receives the input as the first argument and should output the branch name to stdout.
-func Run(scriptPath, input, repoRoot string) (string, error) {
+// The script receives the input as the first argument and should output the branch name
+// (or a JSON Result) to stdout.
+// Scripts that are not executable or lack a shebang line are run through an
+// interpreter picked by their extension.
+func Run(scriptPath, input, repoRoot string) (Result, error) {
 	if scriptPath == "" {
-		return input, nil
+		return Result{Branch: input}, nil
 	}
 
 	// Resolve script path relative to repo root
@@ -22,12 +54,83 @@ func Run(scriptPath, input, repoRoot string) (string, error) {
 	}
 
 	// Check if script exists
-	if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
-		return "", fmt.Errorf("preprocessing script not found: %s", scriptPath)
+	info, err := os.Stat(scriptPath)
+	if os.IsNotExist(err) {
+		return Result{}, fmt.Errorf("preprocessing script not found: %s", scriptPath)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	executable := info.Mode()&0111 != 0
+	args := []string{scriptPath, input}
+	if !executable || !hasShebang(scriptPath) {
+		if interpreter, ok := interpreters[filepath.Ext(scriptPath)]; ok {
+			log.Explainf("preprocess: running %s with %s", scriptPath, interpreter)
+			args = append([]string{interpreter}, args...)
+		} else if !executable {
+			return Result{}, fmt.Errorf("preprocessing script %s is not executable (run: chmod +x %s)", scriptPath, scriptPath)
+		}
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	res, err := run(cmd, "preprocess_script", input, repoRoot)
+	if errors.Is(err, syscall.ENOEXEC) {
+		return Result{}, fmt.Errorf("preprocessing script %s has no shebang line (add one such as #!/bin/sh, or set preprocess_command)", scriptPath)
+	}
+	return res, err
+}
+
+// RunScripts runs scripts as a pipeline: the first receives input and each
+// following one receives the previous one's branch. A base or dir a step
+// sets holds unless a later step sets it again. No scripts means input is
+// used as-is.
+func RunScripts(scripts []string, input, repoRoot string) (Result, error) {
+	return chain(scripts, input, func(script, input string) (Result, error) {
+		return Run(script, input, repoRoot)
+	})
+}
+
+// RunCommands is RunScripts for preprocess_command lines.
+func RunCommands(commands []string, input, repoRoot string) (Result, error) {
+	return chain(commands, input, func(command, input string) (Result, error) {
+		return RunCommand(command, input, repoRoot)
+	})
+}
+
+func chain(steps []string, input string, run func(step, input string) (Result, error)) (Result, error) {
+	res := Result{Branch: input}
+	for i, step := range steps {
+		if len(steps) > 1 {
+			log.Explainf("preprocess: step %d/%d: %s", i+1, len(steps), step)
+		}
+		output, err := run(step, res.Branch)
+		if err != nil {
+			if len(steps) > 1 {
+				return Result{}, fmt.Errorf("step %d (%s): %w", i+1, step, err)
+			}
+			return Result{}, err
+		}
+		res.Branch = output.Branch
+		if output.Base != "" {
+			res.Base = output.Base
+		}
+		if output.Dir != "" {
+			res.Dir = output.Dir
+		}
 	}
+	return res, nil
+}
+
+// RunCommand runs a preprocess_command such as "python3 scripts/branch.py"
+// through sh in repoRoot, with the input appended as the last argument.
+func RunCommand(command, input, repoRoot string) (Result, error) {
+	return run(exec.Command("sh", "-c", command+` "$@"`, "sh", input), "preprocess_command", input, repoRoot)
+}
 
-	// Execute the script
-	cmd := exec.Command(scriptPath, input)
+// run executes cmd, the step of setting (preprocess_script or
+// preprocess_command), and reads the branch it prints.
+func run(cmd *exec.Cmd, setting, input, repoRoot string) (Result, error) {
 	cmd.Dir = repoRoot
 	cmd.Env = os.Environ() // Inherit environment variables (including HOME for credential loading)
 	cmd.Stderr = os.Stderr
@@ -35,14 +138,54 @@ func Run(scriptPath, input, repoRoot string) (string, error) {
 	var stdout bytes.Buffer
 	cmd.Stdout = &stdout
 
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("preprocessing script failed: %w", err)
+	if err := runner.Run(cmd); err != nil {
+		return Result{}, fmt.Errorf("preprocessing script failed: %w", err)
 	}
 
-	branch := strings.TrimSpace(stdout.String())
-	if branch == "" {
-		return "", fmt.Errorf("preprocessing script returned empty branch name")
+	res, err := parseOutput(setting, strings.TrimSpace(stdout.String()))
+	if err != nil {
+		return Result{}, err
+	}
+	log.Explainf("preprocess: %q -> %q", input, res.Branch)
+	if res.Base != "" || res.Dir != "" {
+		log.Explainf("preprocess: base %q, dir %q", res.Base, res.Dir)
 	}
+	return res, nil
+}
 
-	return branch, nil
+// parseOutput reads what a script printed: a JSON object when it starts
+// with "{", or else the branch name itself. Errors name setting, the
+// config key the script came from.
+func parseOutput(setting, out string) (Result, error) {
+	if out == "" {
+		return Result{}, fmt.Errorf("%s returned an empty branch name", setting)
+	}
+	if !strings.HasPrefix(out, "{") {
+		return Result{Branch: out}, nil
+	}
+	var res Result
+	dec := json.NewDecoder(strings.NewReader(out))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&res); err != nil {
+		return Result{}, fmt.Errorf("%s printed invalid JSON (want {\"branch\": ..., \"base\": ..., \"dir\": ...}): %w", setting, err)
+	}
+	res.Branch, res.Base, res.Dir = strings.TrimSpace(res.Branch), strings.TrimSpace(res.Base), strings.TrimSpace(res.Dir)
+	if res.Branch == "" {
+		return Result{}, fmt.Errorf("%s returned JSON without a branch", setting)
+	}
+	if res.Dir != "" && (filepath.IsAbs(res.Dir) || res.Dir != filepath.Base(res.Dir) || res.Dir == "." || res.Dir == "..") {
+		return Result{}, fmt.Errorf("%s returned dir %q; it must be a plain directory name", setting, res.Dir)
+	}
+	return res, nil
+}
+
+func hasShebang(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, 2)
+	_, err = io.ReadFull(f, head)
+	return err == nil && string(head) == "#!"
 }
@@ -0,0 +1,56 @@
+package preprocess
+
+import "testing"
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		out     string
+		want    Result
+		wantErr bool
+	}{
+		{out: "feat/login", want: Result{Branch: "feat/login"}},
+		{out: `{"branch": "feat/login", "base": "develop", "dir": "login"}`, want: Result{Branch: "feat/login", Base: "develop", Dir: "login"}},
+		{out: `{"branch": "fix"}`, want: Result{Branch: "fix"}},
+		{out: "", wantErr: true},
+		{out: `{"base": "develop"}`, wantErr: true},
+		{out: `{"branch": "x", "directory": "y"}`, wantErr: true},
+		{out: `{"branch": "x", "dir": "../y"}`, wantErr: true},
+		{out: `{"branch": `, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseOutput("preprocess_script", tt.out)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseOutput(%q) = %+v, want an error", tt.out, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseOutput(%q) = %+v, %v; want %+v", tt.out, got, err, tt.want)
+		}
+	}
+}
+
+func TestChainKeepsBaseAndDir(t *testing.T) {
+	outputs := map[string]Result{
+		"ticket": {Branch: "PROJ-1", Base: "develop"},
+		"title":  {Branch: "PROJ-1-login", Dir: "login"},
+		"prefix": {Branch: "feat/PROJ-1-login"},
+	}
+	got, err := chain([]string{"ticket", "title", "prefix"}, "PROJ-1", func(step, input string) (Result, error) {
+		return outputs[step], nil
+	})
+	want := Result{Branch: "feat/PROJ-1-login", Base: "develop", Dir: "login"}
+	if err != nil || got != want {
+		t.Fatalf("chain() = %+v, %v; want %+v", got, err, want)
+	}
+}
+
+func TestParseOutputNamesSetting(t *testing.T) {
+	for _, setting := range []string{"preprocess_script", "preprocess_command"} {
+		_, err := parseOutput(setting, "")
+		if want := setting + " returned an empty branch name"; err == nil || err.Error() != want {
+			t.Errorf("parseOutput(%q, \"\") = %v, want %q", setting, err, want)
+		}
+	}
+}
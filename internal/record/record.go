@@ -0,0 +1,130 @@
+package record
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/default-anton/wt/internal/runner"
+	"github.com/default-anton/wt/internal/shquote"
+)
+
+// Shell starts an interactive subshell in dir with history redirected to a
+// temporary file, waits for it to exit, and returns the commands that were run.
+// zsh is used when $SHELL is zsh; every other shell falls back to bash.
+func Shell(dir string) ([]string, error) {
+	tmpDir, err := os.MkdirTemp("", "wt-record-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	histFile := filepath.Join(tmpDir, "history")
+
+	var cmd *exec.Cmd
+	if strings.HasSuffix(os.Getenv("SHELL"), "zsh") {
+		cmd, err = zshCommand(tmpDir, histFile)
+	} else {
+		cmd, err = bashCommand(tmpDir, histFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	// Lets prompts that replace PS1 show that the shell is being recorded.
+	cmd.Env = append(cmd.Env, "WT_RECORDING=1")
+	cmd.Dir = dir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := runner.Run(cmd); err != nil {
+		// A non-zero exit from the last command is not a recording failure.
+		if _, ok := err.(*exec.ExitError); !ok {
+			return nil, fmt.Errorf("recording shell failed: %w", err)
+		}
+	}
+
+	data, err := os.ReadFile(histFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return ParseHistory(string(data)), nil
+}
+
+func bashCommand(tmpDir, histFile string) (*exec.Cmd, error) {
+	rcFile := filepath.Join(tmpDir, "bashrc")
+	rc := `[ -f "$HOME/.bashrc" ] && . "$HOME/.bashrc"
+HISTFILE=` + shquote.Quote(histFile) + `
+HISTCONTROL=
+HISTIGNORE=
+history -c
+PROMPT_COMMAND="history -a${PROMPT_COMMAND:+; $PROMPT_COMMAND}"
+PS1="(wt record) $PS1"
+`
+	if err := os.WriteFile(rcFile, []byte(rc), 0600); err != nil {
+		return nil, err
+	}
+	return exec.Command("bash", "--rcfile", rcFile, "-i"), nil
+}
+
+func zshCommand(tmpDir, histFile string) (*exec.Cmd, error) {
+	zshenv := `[ -f "$HOME/.zshenv" ] && . "$HOME/.zshenv"
+`
+	zshrc := `[ -f "$HOME/.zshrc" ] && ZDOTDIR="$HOME" . "$HOME/.zshrc"
+fc -p ` + shquote.Quote(histFile) + `
+setopt INC_APPEND_HISTORY
+PS1="(wt record) $PS1"
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".zshenv"), []byte(zshenv), 0600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".zshrc"), []byte(zshrc), 0600); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command("zsh", "-i")
+	cmd.Env = append(os.Environ(), "ZDOTDIR="+tmpDir)
+	return cmd, nil
+}
+
+var (
+	bashTimestampRe = regexp.MustCompile(`^#\d+$`)
+	zshExtendedRe   = regexp.MustCompile(`^: \d+:\d+;`)
+)
+
+// ignoredCommands are navigation and housekeeping commands that never make useful hooks.
+var ignoredCommands = map[string]bool{
+	"exit": true, "logout": true, "cd": true, "ls": true, "ll": true, "pwd": true,
+	"clear": true, "history": true,
+}
+
+// ParseHistory extracts commands from bash or zsh history file contents, dropping
+// timestamps, consecutive duplicates, and navigation commands like cd/ls/exit.
+func ParseHistory(content string) []string {
+	var commands []string
+	for _, line := range strings.Split(content, "\n") {
+		if bashTimestampRe.MatchString(line) {
+			continue
+		}
+		line = zshExtendedRe.ReplaceAllString(line, "")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if ignoredCommands[fields[0]] {
+			continue
+		}
+		if len(commands) > 0 && commands[len(commands)-1] == line {
+			continue
+		}
+		commands = append(commands, line)
+	}
+	return commands
+}
@@ -0,0 +1,44 @@
+package record
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "bash with timestamps",
+			content: "#1700000000\nnpm ci\n#1700000001\ncp .env.example .env\n",
+			want:    []string{"npm ci", "cp .env.example .env"},
+		},
+		{
+			name:    "zsh extended history",
+			content: ": 1700000000:0;bundle install\n: 1700000005:2;bin/rails db:prepare\n",
+			want:    []string{"bundle install", "bin/rails db:prepare"},
+		},
+		{
+			name:    "drops navigation and consecutive duplicates",
+			content: "cd apps/api\nls -la\nmake deps\nmake deps\npwd\nmake deps\nexit\n",
+			want:    []string{"make deps"},
+		},
+		{
+			name:    "empty",
+			content: "\n\n",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseHistory(tt.content)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseHistory() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Package remote reaches worktrees on other machines over SSH.
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/runner"
+	"github.com/default-anton/wt/internal/shquote"
+)
+
+// List returns the worktrees of the repository on r, as reported by
+// `wt ls --json` running there.
+func List(r config.Remote) ([]git.Worktree, error) {
+	wt := r.Wt
+	if wt == "" {
+		wt = "wt"
+	}
+	// "--" keeps a host such as -oProxyCommand=... from being taken for an
+	// option.
+	cmd := exec.Command("ssh", "--", r.Host, InDir(r.Path, Quote(wt)+" ls --json"))
+	cmd.Stderr = os.Stderr
+	output, err := runner.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees on %s: %w", r.Host, err)
+	}
+
+	var worktrees []git.Worktree
+	if err := json.Unmarshal(output, &worktrees); err != nil {
+		return nil, fmt.Errorf("unexpected `wt ls --json` output from %s: %w", r.Host, err)
+	}
+	return worktrees, nil
+}
+
+// SessionCommand returns the command that opens an interactive login shell
+// in dir on r.
+func SessionCommand(r config.Remote, dir string) []string {
+	return []string{"ssh", "-t", "--", r.Host, InDir(dir, `exec "${SHELL:-sh}" -l`)}
+}
+
+// InDir returns a remote shell command line that runs command in dir.
+func InDir(dir, command string) string {
+	return "cd " + Quote(dir) + " && " + command
+}
+
+// Quote quotes s for a POSIX shell, leaving a leading "~/" unquoted so the
+// remote shell still expands it to the home directory.
+func Quote(s string) string {
+	prefix := ""
+	if s == "~" || strings.HasPrefix(s, "~/") {
+		prefix, s = "~/", strings.TrimPrefix(strings.TrimPrefix(s, "~"), "/")
+		if s == "" {
+			return prefix
+		}
+	}
+	return prefix + shquote.Quote(s)
+}
@@ -0,0 +1,58 @@
+package remote
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/git"
+	"github.com/default-anton/wt/internal/runner"
+)
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"/srv/app":      "/srv/app",
+		"~/src/app":     "~/src/app",
+		"~/my app":      "~/'my app'",
+		"~":             "~/",
+		"it's here":     `'it'\''s here'`,
+		"~other/x":      "'~other/x'",
+		"/path with sp": "'/path with sp'",
+	}
+	for in, want := range tests {
+		if got := Quote(in); got != want {
+			t.Errorf("Quote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	fake := runner.NewFake().On("ssh -- devbox", runner.Response{
+		Stdout: `[{"path":"/home/me/src/app","branch":"main","commit":"aaa","main":true,"detached":false},` +
+			`{"path":"/home/me/src/app/.worktrees/feature","branch":"feature","commit":"bbb","main":false,"detached":false}]`,
+	})
+	t.Cleanup(runner.Set(fake))
+
+	got, err := List(config.Remote{Host: "devbox", Path: "~/src/app"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := []git.Worktree{
+		{Path: "/home/me/src/app", Branch: "main", Commit: "aaa", IsMain: true},
+		{Path: "/home/me/src/app/.worktrees/feature", Branch: "feature", Commit: "bbb"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if call := fake.Calls()[0].String(); call != "ssh -- devbox cd ~/src/app && wt ls --json" {
+		t.Fatalf("unexpected call: %s", call)
+	}
+}
+
+func TestSessionCommand(t *testing.T) {
+	got := SessionCommand(config.Remote{Host: "me@devbox"}, "/home/me/src/app/.worktrees/feature")
+	want := []string{"ssh", "-t", "--", "me@devbox", `cd /home/me/src/app/.worktrees/feature && exec "${SHELL:-sh}" -l`}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Call records a command executed through a Fake.
+type Call struct {
+	Dir  string
+	Args []string
+}
+
+// String returns the command line of the call, e.g. "git worktree list --porcelain".
+func (c Call) String() string {
+	return strings.Join(c.Args, " ")
+}
+
+// Response is the scripted result for commands matching a prefix.
+type Response struct {
+	Stdout string
+	Stderr string
+	Err    error
+}
+
+// Fake is a Runner that records calls and replies with scripted responses
+// instead of executing anything. Commands are matched by the longest registered
+// prefix of their command line (program name without directory, then args).
+// Unmatched commands fail.
+type Fake struct {
+	mu        sync.Mutex
+	calls     []Call
+	responses map[string]Response
+}
+
+// NewFake returns an empty Fake.
+func NewFake() *Fake {
+	return &Fake{responses: make(map[string]Response)}
+}
+
+// On registers the response for commands whose command line starts with prefix.
+func (f *Fake) On(prefix string, resp Response) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[prefix] = resp
+	return f
+}
+
+// Calls returns the commands executed so far.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// Run records cmd and writes the scripted response to its stdout/stderr.
+func (f *Fake) Run(cmd *exec.Cmd) error {
+	args := append([]string{filepath.Base(cmd.Args[0])}, cmd.Args[1:]...)
+	line := strings.Join(args, " ")
+
+	f.mu.Lock()
+	f.calls = append(f.calls, Call{Dir: cmd.Dir, Args: args})
+	var (
+		resp    Response
+		best    = -1
+		matched bool
+	)
+	for prefix, r := range f.responses {
+		if (line == prefix || strings.HasPrefix(line, prefix+" ")) && len(prefix) > best {
+			resp, best, matched = r, len(prefix), true
+		}
+	}
+	f.mu.Unlock()
+
+	if !matched {
+		return fmt.Errorf("fake runner: unexpected command: %s", line)
+	}
+	if cmd.Stdout != nil && resp.Stdout != "" {
+		if _, err := io.WriteString(cmd.Stdout, resp.Stdout); err != nil {
+			return err
+		}
+	}
+	if cmd.Stderr != nil && resp.Stderr != "" {
+		if _, err := io.WriteString(cmd.Stderr, resp.Stderr); err != nil {
+			return err
+		}
+	}
+	return resp.Err
+}
@@ -0,0 +1,106 @@
+// Package runner is the single point through which wt executes external
+// commands (git, cp, tmux, hooks, preprocess scripts). Swapping the active
+// Runner lets wt's own tests exercise flows without real binaries; being
+// internal, the package is not a harness for other modules.
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/default-anton/wt/internal/log"
+)
+
+// Runner executes a prepared command.
+type Runner interface {
+	Run(cmd *exec.Cmd) error
+}
+
+// Exec runs commands for real via os/exec.
+type Exec struct{}
+
+// Run starts cmd and waits for it to complete.
+func (Exec) Run(cmd *exec.Cmd) error {
+	return cmd.Run()
+}
+
+var (
+	mu      sync.RWMutex
+	current Runner = Exec{}
+)
+
+// Set replaces the active Runner and returns a function restoring the previous one.
+func Set(r Runner) (restore func()) {
+	mu.Lock()
+	prev := current
+	current = r
+	mu.Unlock()
+	return func() {
+		mu.Lock()
+		current = prev
+		mu.Unlock()
+	}
+}
+
+func active() Runner {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Run executes cmd with the active Runner, tracing it with --trace and
+// logging it in verbose mode.
+func Run(cmd *exec.Cmd) error {
+	log.Trace(cmd.Args, cmd.Dir, addedEnv(cmd.Env))
+	start := time.Now()
+	err := active().Run(cmd)
+	log.Command(cmd.Args, cmd.Dir, time.Since(start), err)
+	return err
+}
+
+// addedEnv returns the entries of a command's environment that wt's own
+// environment lacks. A nil environment is wt's own.
+func addedEnv(env []string) []string {
+	if env == nil {
+		return nil
+	}
+	own := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		own[kv] = true
+	}
+	var added []string
+	for _, kv := range env {
+		if !own[kv] {
+			added = append(added, kv)
+		}
+	}
+	return added
+}
+
+// Output executes cmd and returns its standard output, like exec.Cmd.Output.
+func Output(cmd *exec.Cmd) ([]byte, error) {
+	if cmd.Stdout != nil {
+		return nil, errors.New("runner: Stdout already set")
+	}
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err := Run(cmd)
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput executes cmd and returns its combined standard output and
+// standard error, like exec.Cmd.CombinedOutput.
+func CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	if cmd.Stdout != nil || cmd.Stderr != nil {
+		return nil, errors.New("runner: Stdout or Stderr already set")
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := Run(cmd)
+	return out.Bytes(), err
+}
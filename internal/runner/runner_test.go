@@ -0,0 +1,156 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestFakeMatchesLongestPrefix(t *testing.T) {
+	fake := NewFake().
+		On("git", Response{Stdout: "git\n"}).
+		On("git worktree", Response{Stdout: "worktree\n"}).
+		On("git worktree list --porcelain", Response{Stdout: "porcelain\n"})
+	t.Cleanup(Set(fake))
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"git", "status"}, "git\n"},
+		{[]string{"git", "worktree", "add", "x"}, "worktree\n"},
+		{[]string{"git", "worktree", "list", "--porcelain"}, "porcelain\n"},
+		// A prefix matches whole words only.
+		{[]string{"git", "worktreex"}, "git\n"},
+		// The program matches without its directory.
+		{[]string{"/usr/bin/git", "worktree", "prune"}, "worktree\n"},
+	}
+	for _, tt := range tests {
+		out, err := Output(exec.Command(tt.args[0], tt.args[1:]...))
+		if err != nil {
+			t.Fatalf("Output(%v): %v", tt.args, err)
+		}
+		if string(out) != tt.want {
+			t.Errorf("Output(%v) = %q, want %q", tt.args, out, tt.want)
+		}
+	}
+}
+
+func TestFakeRecordsCalls(t *testing.T) {
+	fake := NewFake().On("git", Response{})
+	t.Cleanup(Set(fake))
+
+	cmd := exec.Command("git", "-C", "/wt", "status")
+	cmd.Dir = "/repo"
+	if err := Run(cmd); err != nil {
+		t.Fatal(err)
+	}
+	calls := fake.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("got %d calls, want 1", len(calls))
+	}
+	if calls[0].Dir != "/repo" || calls[0].String() != "git -C /wt status" {
+		t.Errorf("got call %q in %q", calls[0], calls[0].Dir)
+	}
+}
+
+func TestFakeUnexpectedCommand(t *testing.T) {
+	fake := NewFake().On("git status", Response{Stdout: "clean\n"})
+	t.Cleanup(Set(fake))
+
+	err := Run(exec.Command("tmux", "new-window"))
+	if err == nil || !strings.Contains(err.Error(), "unexpected command: tmux new-window") {
+		t.Fatalf("got %v, want an unexpected command error", err)
+	}
+	// Unexpected commands are still recorded.
+	if calls := fake.Calls(); len(calls) != 1 || calls[0].String() != "tmux new-window" {
+		t.Errorf("got calls %v", calls)
+	}
+}
+
+func TestOutput(t *testing.T) {
+	failed := errors.New("exit status 1")
+	t.Cleanup(Set(NewFake().
+		On("git rev-parse", Response{Stdout: "abc\n", Stderr: "warning\n"}).
+		On("git fetch", Response{Stdout: "partial\n", Err: failed})))
+
+	out, err := Output(exec.Command("git", "rev-parse", "HEAD"))
+	if err != nil || string(out) != "abc\n" {
+		t.Errorf("Output = %q, %v; want %q without the stderr", out, err, "abc\n")
+	}
+	out, err = Output(exec.Command("git", "fetch"))
+	if !errors.Is(err, failed) || string(out) != "partial\n" {
+		t.Errorf("Output = %q, %v; want %q, %v", out, err, "partial\n", failed)
+	}
+
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Stdout = &bytes.Buffer{}
+	if _, err := Output(cmd); err == nil {
+		t.Error("Output with Stdout set should fail")
+	}
+}
+
+func TestCombinedOutput(t *testing.T) {
+	t.Cleanup(Set(NewFake().On("git merge", Response{Stdout: "out\n", Stderr: "err\n"})))
+
+	out, err := CombinedOutput(exec.Command("git", "merge", "main"))
+	if err != nil || string(out) != "out\nerr\n" {
+		t.Errorf("CombinedOutput = %q, %v; want %q", out, err, "out\nerr\n")
+	}
+
+	cmd := exec.Command("git", "merge", "main")
+	cmd.Stderr = &bytes.Buffer{}
+	if _, err := CombinedOutput(cmd); err == nil {
+		t.Error("CombinedOutput with Stderr set should fail")
+	}
+}
+
+func TestSetRestores(t *testing.T) {
+	first := NewFake().On("git", Response{})
+	restoreFirst := Set(first)
+	second := NewFake().On("git", Response{})
+	restoreSecond := Set(second)
+
+	if err := Run(exec.Command("git", "status")); err != nil {
+		t.Fatal(err)
+	}
+	restoreSecond()
+	if err := Run(exec.Command("git", "log")); err != nil {
+		t.Fatal(err)
+	}
+	restoreFirst()
+
+	if len(second.Calls()) != 1 || len(first.Calls()) != 1 {
+		t.Errorf("got %d calls on the second fake and %d on the first, want 1 each", len(second.Calls()), len(first.Calls()))
+	}
+	if _, ok := active().(Exec); !ok {
+		t.Errorf("active runner is %T after restoring, want Exec", active())
+	}
+}
+
+func TestExecRuns(t *testing.T) {
+	git, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git not found")
+	}
+	out, err := Output(exec.Command(git, "--version"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(out), "git version") {
+		t.Errorf("Output = %q, want git's version", out)
+	}
+
+	err = Run(exec.Command(git, "no-such-command"))
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Errorf("Run of a failing command = %v, want an exit error", err)
+	}
+
+	out, err = CombinedOutput(exec.Command(git, "no-such-command"))
+	if err == nil || !strings.Contains(string(out), "no-such-command") {
+		t.Errorf("CombinedOutput = %q, %v; want git's complaint", out, err)
+	}
+}
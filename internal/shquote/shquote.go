@@ -0,0 +1,29 @@
+// Package shquote quotes strings for POSIX shells, for every command line
+// wt prints or hands to a shell: traces, eval'd exports, terminal tabs,
+// fzf bindings and recording shells.
+package shquote
+
+import "strings"
+
+// plain are the characters that never need quoting.
+const plain = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@%+,"
+
+// Quote single-quotes s unless it is made only of characters that need no
+// quoting. A leading = is quoted too, since zsh expands it to a command's
+// path.
+func Quote(s string) string {
+	if s != "" && strings.Trim(s, plain) == "" && s[0] != '=' {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Join quotes args and joins them with spaces, so the line can be pasted
+// back into a shell.
+func Join(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = Quote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,32 @@
+package shquote
+
+import "testing"
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"main":           "main",
+		"/repo/.wt/a-b":  "/repo/.wt/a-b",
+		"KEY=value":      "KEY=value",
+		"":               "''",
+		"two words":      "'two words'",
+		"it's":           `'it'\''s'`,
+		"$HOME":          "'$HOME'",
+		"a;rm -rf ~":     "'a;rm -rf ~'",
+		"=ls":            "'=ls'",
+		"`id`":           "'`id`'",
+		"feature/PROJ-1": "feature/PROJ-1",
+	}
+	for in, want := range tests {
+		if got := Quote(in); got != want {
+			t.Errorf("Quote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestJoin(t *testing.T) {
+	got := Join([]string{"sh", "-c", "echo 'hi' $HOME", ""})
+	want := `sh -c 'echo '\''hi'\'' $HOME' ''`
+	if got != want {
+		t.Errorf("Join() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FilePath returns where FileStore keeps state for the repository whose
+// common git directory is gitDir.
+func FilePath(gitDir string) string {
+	return filepath.Join(gitDir, "wt", "state.json")
+}
+
+// FileStore keeps state in a local JSON file.
+type FileStore struct {
+	Path string
+}
+
+func (f *FileStore) Load() (State, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return State{}, nil
+	}
+	if err != nil {
+		return State{}, err
+	}
+	s, err := decode(data)
+	if err != nil {
+		return State{}, fmt.Errorf("%s: %w", f.Path, err)
+	}
+	return s, nil
+}
+
+func (f *FileStore) Save(s State) error {
+	data, err := encode(s)
+	if err != nil {
+		return err
+	}
+	return WriteFile(f.Path, data)
+}
+
+func (f *FileStore) Update(fn func(*State) error) error {
+	unlock, err := Lock(f.Path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return update(f, fn)
+}
+
+// WriteFile replaces the file at path with data, creating its directory.
+// The data goes to a temporary file of its own first, so readers never see
+// half of it and concurrent writers never share one.
+func WriteFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// readJSON decodes the JSON file at path into v, upgrading it through s. A
+// missing file leaves v untouched.
+func readJSON(path string, s schema, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.decode(data, v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// writeJSON writes v to path as indented JSON stamped with the version of s.
+func writeJSON(path string, s schema, v any) error {
+	data, err := s.encode(v)
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, data)
+}
@@ -0,0 +1,189 @@
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/default-anton/wt/internal/runner"
+)
+
+// DefaultRef is the ref GitRefStore commits state to.
+const DefaultRef = "refs/wt/state"
+
+// stateFile is the name of the JSON file inside each state commit.
+const stateFile = "state.json"
+
+// GitRefStore keeps state as a JSON file in commits on Ref. When Remote is
+// set, Load fetches the remote copy and merges it into what it returns, and
+// Save commits that merge and pushes, so everyone using the same remote sees
+// the same notes.
+type GitRefStore struct {
+	GitDir string
+	Ref    string
+	Remote string
+}
+
+// Load only reads Ref: fetching updates the remote's copy of it, but the
+// merge is committed by the next Save, which runs under Update's lock.
+func (g *GitRefStore) Load() (State, error) {
+	local, localCommit, err := g.read(g.Ref)
+	if err != nil {
+		return State{}, err
+	}
+	if g.Remote == "" {
+		return local, nil
+	}
+
+	remote, remoteCommit, err := g.fetch()
+	if err != nil || remoteCommit == "" || remoteCommit == localCommit {
+		// Offline or nothing shared yet: the local copy is all we have.
+		return local, nil
+	}
+	return Merge(local, remote), nil
+}
+
+func (g *GitRefStore) Save(s State) error {
+	_, localCommit, err := g.read(g.Ref)
+	if err != nil {
+		return err
+	}
+	parents := []string{localCommit}
+	if g.Remote != "" {
+		// Merge the copy Load fetched, unless Ref already has it.
+		remote, remoteCommit, err := g.read(g.remoteRef())
+		if err != nil {
+			return err
+		}
+		if remoteCommit != "" && remoteCommit != localCommit && !g.isAncestor(remoteCommit, localCommit) {
+			s = Merge(s, remote)
+			parents = append(parents, remoteCommit)
+		}
+	}
+	commit, err := g.commit(s, parents...)
+	if err != nil {
+		return err
+	}
+	if g.Remote == "" {
+		return nil
+	}
+	if g.push() == nil {
+		return nil
+	}
+
+	// Someone pushed in the meantime: merge their changes and retry once.
+	remote, remoteCommit, err := g.fetch()
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s from %s: %w", g.Ref, g.Remote, err)
+	}
+	if _, err := g.commit(Merge(s, remote), commit, remoteCommit); err != nil {
+		return err
+	}
+	if err := g.push(); err != nil {
+		return fmt.Errorf("failed to push %s to %s: %w", g.Ref, g.Remote, err)
+	}
+	return nil
+}
+
+// Update holds the same lock as a FileStore in GitDir, since both keep the
+// repository's metadata.
+func (g *GitRefStore) Update(fn func(*State) error) error {
+	unlock, err := Lock(FilePath(g.GitDir))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return update(g, fn)
+}
+
+// remoteRef is where the remote's copy of Ref is fetched to.
+func (g *GitRefStore) remoteRef() string {
+	return "refs/wt/remotes/" + g.Remote + "/" + strings.TrimPrefix(g.Ref, "refs/")
+}
+
+func (g *GitRefStore) fetch() (State, string, error) {
+	if err := g.git(nil, "fetch", "--quiet", g.Remote, "+"+g.Ref+":"+g.remoteRef()); err != nil {
+		return State{}, "", err
+	}
+	return g.read(g.remoteRef())
+}
+
+// isAncestor reports whether commit is in the history of descendant.
+func (g *GitRefStore) isAncestor(commit, descendant string) bool {
+	return descendant != "" && g.git(nil, "merge-base", "--is-ancestor", commit, descendant) == nil
+}
+
+func (g *GitRefStore) push() error {
+	return g.git(nil, "push", "--quiet", g.Remote, g.Ref+":"+g.Ref)
+}
+
+// read returns the state stored at ref and the commit it points to. A missing
+// ref yields an empty state and commit.
+func (g *GitRefStore) read(ref string) (State, string, error) {
+	commit, err := g.output(nil, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return State{}, "", nil
+	}
+	data, err := g.output(nil, "show", commit+":"+stateFile)
+	if err != nil {
+		return State{}, "", fmt.Errorf("failed to read %s: %w", ref, err)
+	}
+	s, err := decode([]byte(data))
+	if err != nil {
+		return State{}, "", fmt.Errorf("%s: %w", ref, err)
+	}
+	return s, commit, nil
+}
+
+// commit writes s as a new commit on Ref with the given parents.
+func (g *GitRefStore) commit(s State, parents ...string) (string, error) {
+	data, err := encode(s)
+	if err != nil {
+		return "", err
+	}
+	blob, err := g.output(data, "hash-object", "-w", "--stdin")
+	if err != nil {
+		return "", fmt.Errorf("failed to write state: %w", err)
+	}
+	tree, err := g.output([]byte(fmt.Sprintf("100644 blob %s\t%s\n", blob, stateFile)), "mktree")
+	if err != nil {
+		return "", fmt.Errorf("failed to write state: %w", err)
+	}
+
+	args := []string{"commit-tree", tree, "-m", "Update wt state"}
+	for _, p := range parents {
+		if p != "" {
+			args = append(args, "-p", p)
+		}
+	}
+	commit, err := g.output(nil, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to commit state: %w", err)
+	}
+	if err := g.git(nil, "update-ref", g.Ref, commit); err != nil {
+		return "", fmt.Errorf("failed to update %s: %w", g.Ref, err)
+	}
+	return commit, nil
+}
+
+func (g *GitRefStore) command(stdin []byte, args ...string) *exec.Cmd {
+	cmd := exec.Command("git", append([]string{"--git-dir", g.GitDir}, args...)...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	return cmd
+}
+
+func (g *GitRefStore) git(stdin []byte, args ...string) error {
+	output, err := runner.CombinedOutput(g.command(stdin, args...))
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (g *GitRefStore) output(stdin []byte, args ...string) (string, error) {
+	output, err := runner.Output(g.command(stdin, args...))
+	return strings.TrimSpace(string(output)), err
+}
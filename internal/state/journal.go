@@ -0,0 +1,151 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// JournalDir returns where wt keeps the operations in progress for the
+// repository whose common git directory is gitDir.
+func JournalDir(gitDir string) string {
+	return filepath.Join(gitDir, "wt", "journal")
+}
+
+// Operation kinds.
+const (
+	OpAdd    = "add"
+	OpRemove = "rm"
+)
+
+// Operation is a multi-step change to a worktree, written to the journal
+// before its first step so an interrupted one can be finished or rolled
+// back by wt recover.
+type Operation struct {
+	Kind        string    `json:"kind"`
+	Path        string    `json:"path"`
+	Branch      string    `json:"branch,omitempty"`
+	NewBranch   bool      `json:"new_branch,omitempty"`   // wt add created Branch
+	StartCommit string    `json:"start_commit,omitempty"` // where wt add created Branch
+	Input       string    `json:"input,omitempty"`
+	BaseBranch  string    `json:"base_branch,omitempty"`
+	Scope       string    `json:"scope,omitempty"`
+	Profile     string    `json:"profile,omitempty"`
+	PID         int       `json:"pid"`
+	StartedAt   time.Time `json:"started_at"`
+	Steps       []string  `json:"steps"` // completed steps, in order
+
+	file string
+}
+
+// Journal is a directory holding one file per operation in progress, so
+// concurrent wt processes never write the same file.
+type Journal struct {
+	Dir string
+}
+
+// Begin records that op is starting.
+func (j Journal) Begin(op Operation) (*Operation, error) {
+	if err := os.MkdirAll(j.Dir, 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(j.Dir, op.Kind+"-*.json")
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	op.file = f.Name()
+	op.PID = os.Getpid()
+	op.StartedAt = time.Now().UTC()
+	op.Steps = []string{}
+	if err := op.save(); err != nil {
+		os.Remove(op.file)
+		return nil, err
+	}
+	return &op, nil
+}
+
+// Pending returns the operations in the journal, oldest first.
+func (j Journal) Pending() ([]*Operation, error) {
+	entries, err := os.ReadDir(j.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ops []*Operation
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		op := &Operation{file: filepath.Join(j.Dir, e.Name())}
+		if err := readJSON(op.file, nil, op); err != nil {
+			return nil, err
+		}
+		if op.Kind == "" {
+			// Created by Begin but not written yet.
+			continue
+		}
+		ops = append(ops, op)
+	}
+	sort.SliceStable(ops, func(i, k int) bool { return ops[i].StartedAt.Before(ops[k].StartedAt) })
+	return ops, nil
+}
+
+// Step records that step completed. Operations not backed by a journal file
+// ignore it.
+func (o *Operation) Step(step string) error {
+	if o.file == "" {
+		return nil
+	}
+	o.Steps = append(o.Steps, step)
+	return o.save()
+}
+
+// Did reports whether step completed.
+func (o *Operation) Did(step string) bool {
+	return slices.Contains(o.Steps, step)
+}
+
+// Done removes the operation from the journal.
+func (o *Operation) Done() error {
+	if o.file == "" {
+		return nil
+	}
+	if err := os.Remove(o.file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Running reports whether the process that began the operation is still
+// alive, in which case it was not interrupted. Where processes can't be
+// probed it reports false.
+func (o *Operation) Running() bool {
+	if o.PID == os.Getpid() {
+		return true
+	}
+	p, err := os.FindProcess(o.PID)
+	if err != nil {
+		return false
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
+
+func (o *Operation) String() string {
+	if o.Branch != "" {
+		return fmt.Sprintf("wt %s %s (%s)", o.Kind, o.Branch, o.Path)
+	}
+	return fmt.Sprintf("wt %s %s", o.Kind, o.Path)
+}
+
+func (o *Operation) save() error {
+	return writeJSON(o.file, nil, o)
+}
@@ -0,0 +1,66 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockTimeout is how long Lock waits for another wt process, long enough
+// for a GitRefStore to push.
+const lockTimeout = 30 * time.Second
+
+// Lock takes an exclusive advisory lock guarding the file at path, waiting
+// while another wt process holds it. Writers hold it across
+// load-modify-save so parallel invocations don't drop each other's changes;
+// readers don't need it because writes replace the file atomically. The
+// lock itself is path + ".lock", which is never removed.
+func Lock(path string) (unlock func(), err error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		locked, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("timed out waiting for another wt process to release %s", path)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// updateJSON applies fn to the versioned JSON file at path while holding
+// its lock, then writes the result back unless fn fails.
+func updateJSON[T any](path string, s schema, fn func(*T) error) error {
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var v T
+	if err := readJSON(path, s, &v); err != nil {
+		return err
+	}
+	if err := fn(&v); err != nil {
+		return err
+	}
+	return writeJSON(path, s, v)
+}
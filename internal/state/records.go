@@ -0,0 +1,107 @@
+package state
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// RecordsPath returns where wt keeps what it knew when it created each
+// worktree of the repository whose common git directory is gitDir.
+func RecordsPath(gitDir string) string {
+	return filepath.Join(gitDir, "wt", "worktrees.json")
+}
+
+// Record describes how wt created a worktree. Unlike Entry it is about this
+// clone's checkout, so it is keyed by path and never shared.
+type Record struct {
+	CreatedAt     time.Time `json:"created_at"`
+	Input         string    `json:"input,omitempty"`          // what was passed to wt add
+	InitialBranch string    `json:"initial_branch,omitempty"` // the branch the input became after preprocessing
+	BaseBranch    string    `json:"base_branch,omitempty"`    // base_branch or --base at the time
+	Scope         string    `json:"scope,omitempty"`          // --scope package
+	Profile       string    `json:"profile,omitempty"`        // --profile
+	Copied        []string  `json:"copied,omitempty"`         // paths copied by copy_patterns
+	Hooks         []HookRun `json:"hooks,omitempty"`          // post_hooks run or skipped, up to a failure
+	Adopted       bool      `json:"adopted,omitempty"`        // created outside wt and taken over by wt adopt
+	CI            bool      `json:"ci,omitempty"`             // created by wt ci, so wt ci teardown may remove it
+	ExpiresAt     time.Time `json:"expires_at,omitzero"`      // when a wt add --ttl worktree expires
+}
+
+// Expired reports whether the record has an expiry that is not after now.
+func (r Record) Expired(now time.Time) bool {
+	return !r.ExpiresAt.IsZero() && !r.ExpiresAt.After(now)
+}
+
+// HookRun is how a post-creation hook ended: "ok", "skipped", or "failed".
+type HookRun struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// Records maps worktree paths to their records.
+type Records struct {
+	Worktrees map[string]Record `json:"worktrees"`
+	// Kept maps the directories of worktrees unregistered by wt rm
+	// --keep-dir to when that happened, so wt clean leaves them alone.
+	Kept map[string]time.Time `json:"kept,omitempty"`
+}
+
+// LoadRecords reads the records file at path. A missing file has no records.
+func LoadRecords(path string) (Records, error) {
+	var r Records
+	if err := readJSON(path, recordsSchema, &r); err != nil {
+		return Records{}, err
+	}
+	return r, nil
+}
+
+// Save writes r to path.
+func (r Records) Save(path string) error {
+	return writeJSON(path, recordsSchema, r)
+}
+
+// UpdateRecords applies fn to the records file at path while holding its
+// lock, so records written by parallel wt add runs all survive.
+func UpdateRecords(path string, fn func(*Records) error) error {
+	return updateJSON(path, recordsSchema, fn)
+}
+
+// Set records rec for the worktree at path.
+func (r *Records) Set(path string, rec Record) {
+	if r.Worktrees == nil {
+		r.Worktrees = make(map[string]Record)
+	}
+	r.Worktrees[path] = rec
+	delete(r.Kept, path)
+}
+
+// SetKept records that the files of the worktree at path were kept after
+// it was unregistered, at time at.
+func (r *Records) SetKept(path string, at time.Time) {
+	if r.Kept == nil {
+		r.Kept = make(map[string]time.Time)
+	}
+	r.Kept[path] = at
+	delete(r.Worktrees, path)
+}
+
+// IsKept reports whether dir holds the files of an unregistered worktree
+// that were kept.
+func (r Records) IsKept(dir string) bool {
+	_, ok := r.Kept[dir]
+	return ok
+}
+
+// Keep drops the records of worktrees that are not in paths, e.g. because
+// they were removed.
+func (r *Records) Keep(paths []string) {
+	keep := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		keep[path] = true
+	}
+	for path := range r.Worktrees {
+		if !keep[path] {
+			delete(r.Worktrees, path)
+		}
+	}
+}
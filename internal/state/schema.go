@@ -0,0 +1,91 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// migration upgrades a decoded metadata file by one schema version, editing
+// its top-level keys in place.
+type migration func(doc map[string]json.RawMessage) error
+
+// schema is the migration history of one kind of metadata file: a file at
+// version n has been through the first n migrations. Files written before
+// wt versioned them have no version field and count as version 0, and an
+// empty schema means the file is not versioned at all.
+type schema []migration
+
+// version is the schema version this wt writes.
+func (s schema) version() int {
+	return len(s)
+}
+
+// addVersion is the migration of every file to version 1, which only added
+// the version field itself.
+func addVersion(map[string]json.RawMessage) error {
+	return nil
+}
+
+var (
+	stateSchema   = schema{addVersion}
+	recordsSchema = schema{addVersion}
+	usageSchema   = schema{addVersion}
+)
+
+// decode upgrades data to the current version of the schema and decodes it
+// into v. Files from a newer wt are refused rather than half understood.
+func (s schema) decode(data []byte, v any) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	version := 0
+	if raw, ok := doc["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return fmt.Errorf("invalid version: %w", err)
+		}
+	}
+	switch {
+	case version > s.version():
+		return fmt.Errorf("written by a newer wt (schema version %d, this wt reads up to %d); upgrade wt", version, s.version())
+	case version == s.version():
+		return json.Unmarshal(data, v)
+	}
+
+	if doc == nil {
+		doc = make(map[string]json.RawMessage)
+	}
+	for i, m := range s[version:] {
+		if err := m(doc); err != nil {
+			return fmt.Errorf("migrating to schema version %d: %w", version+i+1, err)
+		}
+	}
+	doc["version"] = json.RawMessage(strconv.Itoa(s.version()))
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(upgraded, v)
+}
+
+// encode marshals v as indented JSON stamped with the schema version.
+func (s schema) encode(v any) ([]byte, error) {
+	if s.version() > 0 {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var doc map[string]json.RawMessage
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		doc["version"] = json.RawMessage(strconv.Itoa(s.version()))
+		v = doc
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
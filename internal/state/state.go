@@ -0,0 +1,162 @@
+// Package state stores per-worktree metadata such as notes and preview URLs.
+//
+// The metadata lives behind the Store interface so it can stay local to one
+// clone (FileStore) or be shared with teammates through a git ref that is
+// pushed to a remote (GitRefStore).
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is the metadata recorded for one worktree, keyed by branch name so it
+// means the same thing in every clone.
+type Entry struct {
+	Note      string    `json:"note,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Empty reports whether the entry carries no metadata. Cleared entries are
+// kept so the clear wins when merged with older copies.
+func (e Entry) Empty() bool {
+	return e.Note == "" && e.URL == ""
+}
+
+// ParseTTL parses a time-to-live such as "2d", "1w", or "36h". On top of
+// time.ParseDuration units it accepts whole days (d) and weeks (w).
+func ParseTTL(s string) (time.Duration, error) {
+	var (
+		d   time.Duration
+		err error
+	)
+	switch unit := 24 * time.Hour; {
+	case strings.HasSuffix(s, "w"):
+		unit *= 7
+		fallthrough
+	case strings.HasSuffix(s, "d"):
+		var n int
+		n, err = strconv.Atoi(s[:len(s)-1])
+		d = time.Duration(n) * unit
+	default:
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid ttl %q (examples: 2d, 1w, 12h)", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid ttl %q: must be positive", s)
+	}
+	return d, nil
+}
+
+// State is the full set of worktree metadata.
+type State struct {
+	Worktrees map[string]Entry `json:"worktrees"`
+}
+
+// Set stores e under key.
+func (s *State) Set(key string, e Entry) {
+	if s.Worktrees == nil {
+		s.Worktrees = make(map[string]Entry)
+	}
+	s.Worktrees[key] = e
+}
+
+// Merge combines two states, keeping the most recently updated entry for
+// each key.
+func Merge(a, b State) State {
+	merged := State{Worktrees: make(map[string]Entry, len(a.Worktrees))}
+	for key, e := range a.Worktrees {
+		merged.Worktrees[key] = e
+	}
+	for key, e := range b.Worktrees {
+		if cur, ok := merged.Worktrees[key]; !ok || e.UpdatedAt.After(cur.UpdatedAt) {
+			merged.Worktrees[key] = e
+		}
+	}
+	return merged
+}
+
+// Store loads and saves State. Update is the way to change it: it holds a
+// lock across loading, fn and saving, so parallel wt processes never drop
+// each other's changes.
+type Store interface {
+	Load() (State, error)
+	Save(State) error
+	Update(fn func(*State) error) error
+}
+
+// update loads the state of store, applies fn and saves the result unless
+// fn fails or changed nothing, so a no-op never commits or pushes. Callers
+// hold the store's lock.
+func update(store Store, fn func(*State) error) error {
+	s, err := store.Load()
+	if err != nil {
+		return err
+	}
+	before, err := encode(s)
+	if err != nil {
+		return err
+	}
+	if err := fn(&s); err != nil {
+		return err
+	}
+	after, err := encode(s)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(before, after) {
+		return nil
+	}
+	return store.Save(s)
+}
+
+// Backend names a Store implementation.
+type Backend string
+
+const (
+	// BackendFile keeps state in a JSON file inside the repository's git directory.
+	BackendFile Backend = "file"
+	// BackendGit keeps state on a git ref that can be pushed to a remote.
+	BackendGit Backend = "git"
+)
+
+// ParseBackend validates a backend name. An empty name means BackendFile.
+func ParseBackend(name string) (Backend, error) {
+	switch Backend(name) {
+	case "", BackendFile:
+		return BackendFile, nil
+	case BackendGit:
+		return BackendGit, nil
+	default:
+		return "", fmt.Errorf("unknown state backend %q (supported: file, git)", name)
+	}
+}
+
+// Open returns the store for backend. gitDir is the repository's common git
+// directory; remote is only used by BackendGit and may be empty to keep the
+// ref local.
+func Open(backend Backend, gitDir, remote string) Store {
+	if backend == BackendGit {
+		return &GitRefStore{GitDir: gitDir, Ref: DefaultRef, Remote: remote}
+	}
+	return &FileStore{Path: FilePath(gitDir)}
+}
+
+func decode(data []byte) (State, error) {
+	var s State
+	if err := stateSchema.decode(data, &s); err != nil {
+		return State{}, fmt.Errorf("invalid state: %w", err)
+	}
+	return s, nil
+}
+
+func encode(s State) ([]byte, error) {
+	return stateSchema.encode(s)
+}
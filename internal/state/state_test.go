@@ -0,0 +1,291 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	a := State{Worktrees: map[string]Entry{
+		"feature": {Note: "mine", UpdatedAt: newer},
+		"review":  {Note: "stale", UpdatedAt: older},
+		"local":   {Note: "only here", UpdatedAt: older},
+	}}
+	b := State{Worktrees: map[string]Entry{
+		"feature": {Note: "theirs", UpdatedAt: older},
+		"review":  {UpdatedAt: newer}, // cleared
+		"remote":  {URL: "https://preview.example.com", UpdatedAt: older},
+	}}
+
+	got := Merge(a, b)
+	want := State{Worktrees: map[string]Entry{
+		"feature": {Note: "mine", UpdatedAt: newer},
+		"review":  {UpdatedAt: newer},
+		"local":   {Note: "only here", UpdatedAt: older},
+		"remote":  {URL: "https://preview.example.com", UpdatedAt: older},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseTTL(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"2d":  48 * time.Hour,
+		"1w":  7 * 24 * time.Hour,
+		"36h": 36 * time.Hour,
+		"90m": 90 * time.Minute,
+	} {
+		got, err := ParseTTL(in)
+		if err != nil || got != want {
+			t.Errorf("ParseTTL(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "d", "2x", "-1d", "0h"} {
+		if _, err := ParseTTL(in); err == nil {
+			t.Errorf("ParseTTL(%q): expected error", in)
+		}
+	}
+}
+
+func TestRecordExpired(t *testing.T) {
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	if (Record{}).Expired(now) {
+		t.Fatal("record without expiry reported expired")
+	}
+	if (Record{ExpiresAt: now.Add(time.Minute)}).Expired(now) {
+		t.Fatal("future expiry reported expired")
+	}
+	if !(Record{ExpiresAt: now}).Expired(now) {
+		t.Fatal("expiry at now not reported expired")
+	}
+}
+
+func TestParseBackend(t *testing.T) {
+	for name, want := range map[string]Backend{"": BackendFile, "file": BackendFile, "git": BackendGit} {
+		got, err := ParseBackend(name)
+		if err != nil || got != want {
+			t.Errorf("ParseBackend(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseBackend("s3"); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "wt", "state.json")}
+
+	empty, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load missing file: %v", err)
+	}
+	if len(empty.Worktrees) != 0 {
+		t.Fatalf("expected empty state, got %+v", empty)
+	}
+
+	var s State
+	s.Set("feature", Entry{Note: "wip", UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+	if err := store.Save(s); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !reflect.DeepEqual(got, s) {
+		t.Fatalf("got %+v, want %+v", got, s)
+	}
+}
+
+func TestUsageFrecency(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	var u Usage
+	for range 3 {
+		u.Visit("/wt/old", now.Add(-30*24*time.Hour))
+	}
+	u.Visit("/wt/recent", now.Add(-10*time.Minute))
+	u.Visit("/wt/gone", now)
+
+	if got := u.Frecency("/wt/old", now); got != 0.75 {
+		t.Errorf("Frecency(old) = %v, want 0.75", got)
+	}
+	if got := u.Frecency("/wt/recent", now); got != 4 {
+		t.Errorf("Frecency(recent) = %v, want 4", got)
+	}
+	if got := u.Frecency("/wt/never", now); got != 0 {
+		t.Errorf("Frecency(never) = %v, want 0", got)
+	}
+
+	u.Keep([]string{"/wt/old", "/wt/recent"})
+	path := filepath.Join(t.TempDir(), "wt", "usage.json")
+	if err := u.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadUsage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.Worktrees["/wt/gone"]; ok {
+		t.Error("Keep() did not drop /wt/gone")
+	}
+	if got := loaded.Worktrees["/wt/old"].Count; got != 3 {
+		t.Errorf("loaded count = %d, want 3", got)
+	}
+}
+
+func TestRecords(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	var r Records
+	r.Set("/wt/login", Record{CreatedAt: created, Input: "PROJ-1 Login", InitialBranch: "proj-1-login", BaseBranch: "main"})
+	r.Set("/wt/gone", Record{CreatedAt: created})
+	r.Keep([]string{"/repo", "/wt/login"})
+
+	path := filepath.Join(t.TempDir(), "wt", "worktrees.json")
+	if err := r.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadRecords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Records{Worktrees: map[string]Record{
+		"/wt/login": {CreatedAt: created, Input: "PROJ-1 Login", InitialBranch: "proj-1-login", BaseBranch: "main"},
+	}}
+	if !reflect.DeepEqual(loaded, want) {
+		t.Fatalf("got %+v, want %+v", loaded, want)
+	}
+}
+
+func TestJournal(t *testing.T) {
+	j := Journal{Dir: filepath.Join(t.TempDir(), "wt", "journal")}
+	if ops, err := j.Pending(); err != nil || len(ops) != 0 {
+		t.Fatalf("empty journal: got %v, %v", ops, err)
+	}
+
+	add, err := j.Begin(Operation{Kind: OpAdd, Path: "/wt/login", Branch: "login", NewBranch: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := add.Step("create"); err != nil {
+		t.Fatal(err)
+	}
+	rm, err := j.Begin(Operation{Kind: OpRemove, Path: "/wt/old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ops, err := j.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 2 {
+		t.Fatalf("got %+v, want the add and the rm", ops)
+	}
+	if ops[0].Kind != OpAdd {
+		ops[0], ops[1] = ops[1], ops[0]
+	}
+	if !ops[0].Did("create") || ops[1].Did("create") {
+		t.Errorf("steps: add %v, rm %v", ops[0].Steps, ops[1].Steps)
+	}
+	if !ops[0].NewBranch || !ops[0].Running() {
+		t.Errorf("got %+v, want a new branch begun by this process", ops[0])
+	}
+
+	if err := rm.Done(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ops[0].Done(); err != nil {
+		t.Fatal(err)
+	}
+	if ops, err := j.Pending(); err != nil || len(ops) != 0 {
+		t.Fatalf("after Done: got %v, %v", ops, err)
+	}
+}
+
+func TestSchemaVersions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "usage.json")
+	// A file from before versioning.
+	if err := os.WriteFile(path, []byte(`{"worktrees":{"/wt/a":{"count":2}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	u, err := LoadUsage(path)
+	if err != nil || u.Worktrees["/wt/a"].Count != 2 {
+		t.Fatalf("LoadUsage(version 0) = %+v, %v", u, err)
+	}
+
+	if err := u.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"version": 1`) {
+		t.Errorf("saved file has no version:\n%s", data)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"version":99,"worktrees":{}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadUsage(path); err == nil || !strings.Contains(err.Error(), "newer wt") {
+		t.Errorf("LoadUsage(version 99) error = %v, want a newer wt error", err)
+	}
+}
+
+func TestUpdateConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	usagePath := filepath.Join(dir, "wt", "usage.json")
+	store := &FileStore{Path: filepath.Join(dir, "wt", "state.json")}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 40)
+	for i := range 20 {
+		key := fmt.Sprintf("/wt/%d", i)
+		wg.Go(func() {
+			errs <- UpdateUsage(usagePath, func(u *Usage) error {
+				u.Visit(key, time.Now())
+				return nil
+			})
+		})
+		wg.Go(func() {
+			errs <- store.Update(func(s *State) error {
+				s.Set(key, Entry{Note: key, UpdatedAt: time.Now()})
+				return nil
+			})
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	u, err := LoadUsage(usagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(u.Worktrees) != 20 {
+		t.Errorf("usage has %d worktrees, want 20", len(u.Worktrees))
+	}
+	s, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Worktrees) != 20 {
+		t.Errorf("state has %d worktrees, want 20", len(s.Worktrees))
+	}
+}
@@ -0,0 +1,88 @@
+package state
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// UsagePath returns where worktree usage is recorded for the repository
+// whose common git directory is gitDir. Usage is personal, so unlike notes
+// it never leaves the clone.
+func UsagePath(gitDir string) string {
+	return filepath.Join(gitDir, "wt", "usage.json")
+}
+
+// Visits counts how often a worktree was entered and when it was last.
+type Visits struct {
+	Count int       `json:"count"`
+	Last  time.Time `json:"last"`
+}
+
+// Usage maps worktree paths to their visits.
+type Usage struct {
+	Worktrees map[string]Visits `json:"worktrees"`
+}
+
+// LoadUsage reads the usage file at path. A missing file is empty usage.
+func LoadUsage(path string) (Usage, error) {
+	var u Usage
+	if err := readJSON(path, usageSchema, &u); err != nil {
+		return Usage{}, err
+	}
+	return u, nil
+}
+
+// Save writes u to path.
+func (u Usage) Save(path string) error {
+	return writeJSON(path, usageSchema, u)
+}
+
+// UpdateUsage applies fn to the usage file at path while holding its lock.
+func UpdateUsage(path string, fn func(*Usage) error) error {
+	return updateJSON(path, usageSchema, fn)
+}
+
+// Visit records that the worktree at path was entered at now.
+func (u *Usage) Visit(path string, now time.Time) {
+	if u.Worktrees == nil {
+		u.Worktrees = make(map[string]Visits)
+	}
+	v := u.Worktrees[path]
+	v.Count++
+	v.Last = now
+	u.Worktrees[path] = v
+}
+
+// Keep drops the usage of worktrees that are not in paths, e.g. because
+// they were removed.
+func (u *Usage) Keep(paths []string) {
+	keep := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		keep[path] = true
+	}
+	for path := range u.Worktrees {
+		if !keep[path] {
+			delete(u.Worktrees, path)
+		}
+	}
+}
+
+// Frecency scores the worktree at path like zoxide does: the visit count,
+// weighted by how recent the last visit is.
+func (u Usage) Frecency(path string, now time.Time) float64 {
+	v, ok := u.Worktrees[path]
+	if !ok {
+		return 0
+	}
+	count := float64(v.Count)
+	switch age := now.Sub(v.Last); {
+	case age < time.Hour:
+		return count * 4
+	case age < 24*time.Hour:
+		return count * 2
+	case age < 7*24*time.Hour:
+		return count / 2
+	default:
+		return count / 4
+	}
+}
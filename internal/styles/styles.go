@@ -1,7 +1,9 @@
 package styles
 
 import (
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -12,21 +14,113 @@ func init() {
 	if os.Getenv("CLICOLOR_FORCE") == "" {
 		os.Setenv("CLICOLOR_FORCE", "1")
 	}
+	Apply(Themes[0])
 }
 
+// The active styles. They start out as the default theme; Apply switches them.
 var (
-	// BranchStyle is used for highlighting branch names (purple/magenta)
-	BranchStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("170"))
+	// BranchStyle is used for highlighting branch names (default theme: purple/magenta)
+	BranchStyle lipgloss.Style
 
-	// DimStyle is used for dimmed text like paths and help text (gray)
-	DimStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	// DimStyle is used for dimmed text like paths and help text (default theme: gray)
+	DimStyle lipgloss.Style
 
-	// CursorStyle is used for cursor indicator and badges (cyan)
-	CursorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("86"))
+	// CursorStyle is used for cursor indicator and badges (default theme: cyan)
+	CursorStyle lipgloss.Style
 
 	// NormalStyle is the default style with no formatting
 	NormalStyle = lipgloss.NewStyle()
 
-	// MatchStyle is used for highlighting fuzzy match characters (green, bold)
-	MatchStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("82")).Bold(true)
+	// MatchStyle is used for highlighting fuzzy match characters (default theme: green, bold)
+	MatchStyle lipgloss.Style
+
+	// DirtyStyle marks worktrees with uncommitted changes (default theme: red)
+	DirtyStyle lipgloss.Style
+
+	// AheadStyle marks worktrees with unpushed commits (default theme: yellow)
+	AheadStyle lipgloss.Style
 )
+
+// Theme is a named set of styles.
+type Theme struct {
+	Name   string
+	Branch lipgloss.Style
+	Dim    lipgloss.Style
+	Cursor lipgloss.Style
+	Match  lipgloss.Style
+	Dirty  lipgloss.Style
+	Ahead  lipgloss.Style
+}
+
+func color(c string) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(c))
+}
+
+// Themes lists the built-in themes; the first one is the default.
+var Themes = []Theme{
+	{
+		Name:   "default",
+		Branch: color("170"),
+		Dim:    color("240"),
+		Cursor: color("86"),
+		Match:  color("82").Bold(true),
+		Dirty:  color("196"),
+		Ahead:  color("214"),
+	},
+	{
+		// Bright colors and bold text on the most important elements.
+		Name:   "high-contrast",
+		Branch: color("15").Bold(true),
+		Dim:    color("250"),
+		Cursor: color("51").Bold(true),
+		Match:  color("226").Bold(true).Underline(true),
+		Dirty:  color("196").Bold(true),
+		Ahead:  color("226").Bold(true),
+	},
+	{
+		// Blue/orange palette that avoids red-green distinctions.
+		Name:   "deuteranopia-safe",
+		Branch: color("33"),
+		Dim:    color("245"),
+		Cursor: color("75"),
+		Match:  color("214").Bold(true).Underline(true),
+		Dirty:  color("202").Bold(true),
+		Ahead:  color("39"),
+	},
+	{
+		// No colors: emphasis through text attributes only.
+		Name:   "monochrome",
+		Branch: lipgloss.NewStyle().Bold(true),
+		Dim:    lipgloss.NewStyle().Faint(true),
+		Cursor: lipgloss.NewStyle().Bold(true),
+		Match:  lipgloss.NewStyle().Bold(true).Underline(true),
+		Dirty:  lipgloss.NewStyle().Bold(true),
+		Ahead:  lipgloss.NewStyle().Bold(true),
+	},
+}
+
+// LookupTheme returns the built-in theme called name. An empty name means
+// the default theme.
+func LookupTheme(name string) (Theme, error) {
+	if name == "" {
+		return Themes[0], nil
+	}
+	names := make([]string, len(Themes))
+	for i, t := range Themes {
+		if t.Name == name {
+			return t, nil
+		}
+		names[i] = t.Name
+	}
+	return Theme{}, fmt.Errorf("unknown theme %q (supported: %s)", name, strings.Join(names, ", "))
+}
+
+// Apply makes t the active theme.
+func Apply(t Theme) {
+	BranchStyle = t.Branch
+	DimStyle = t.Dim
+	CursorStyle = t.Cursor
+	MatchStyle = t.Match
+	DirtyStyle = t.Dirty
+	AheadStyle = t.Ahead
+}
@@ -0,0 +1,19 @@
+package styles
+
+import "testing"
+
+func TestLookupTheme(t *testing.T) {
+	def, err := LookupTheme("")
+	if err != nil || def.Name != "default" {
+		t.Fatalf("LookupTheme(\"\") = %q, %v; want default", def.Name, err)
+	}
+	for _, theme := range Themes {
+		got, err := LookupTheme(theme.Name)
+		if err != nil || got.Name != theme.Name {
+			t.Errorf("LookupTheme(%q) = %q, %v", theme.Name, got.Name, err)
+		}
+	}
+	if _, err := LookupTheme("solarized"); err == nil {
+		t.Error("expected error for unknown theme")
+	}
+}
@@ -0,0 +1,69 @@
+package term
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/default-anton/wt/internal/runner"
+)
+
+// TmuxLocation identifies a tmux window.
+type TmuxLocation struct {
+	Session string
+	Window  string
+}
+
+// String returns the location as a tmux target, e.g. "work:2".
+func (l TmuxLocation) String() string {
+	return l.Session + ":" + l.Window
+}
+
+// TmuxLocations returns, for each of paths, the tmux windows that have a pane
+// whose working directory is inside it. Panes are attributed to the most
+// specific path, so a pane in a nested worktree doesn't count for its parent.
+// Returns an empty map when tmux isn't running.
+func TmuxLocations(paths []string) map[string][]TmuxLocation {
+	locations := make(map[string][]TmuxLocation)
+
+	cmd := exec.Command("tmux", "list-panes", "-a", "-F", "#{session_name}\t#{window_index}\t#{pane_current_path}")
+	output, err := runner.Output(cmd)
+	if err != nil {
+		return locations
+	}
+
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		loc := TmuxLocation{Session: parts[0], Window: parts[1]}
+
+		owner := ""
+		for _, p := range paths {
+			if isWithin(parts[2], p) && len(p) > len(owner) {
+				owner = p
+			}
+		}
+		if owner == "" || seen[owner+"\x00"+loc.String()] {
+			continue
+		}
+		seen[owner+"\x00"+loc.String()] = true
+		locations[owner] = append(locations[owner], loc)
+	}
+	return locations
+}
+
+// JumpTmux switches the current tmux client to loc.
+func JumpTmux(loc TmuxLocation) error {
+	if os.Getenv("TMUX") == "" {
+		return runner.Run(exec.Command("tmux", "attach-session", "-t", loc.String()))
+	}
+	return runner.Run(exec.Command("tmux", "switch-client", "-t", loc.String()))
+}
+
+func isWithin(path, root string) bool {
+	return path == root || strings.HasPrefix(path, root+string(filepath.Separator))
+}
@@ -0,0 +1,182 @@
+package term
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/default-anton/wt/internal/runner"
+	"github.com/default-anton/wt/internal/shquote"
+)
+
+// WezTerm opens worktrees in the current WezTerm window through wezterm cli,
+// in a new tab or a split according to Mode.
+type WezTerm struct {
+	Mode TerminalMode
+}
+
+// Open opens target.Path in a new WezTerm tab titled after the branch, or a
+// split of the current pane.
+func (w WezTerm) Open(target Target) error {
+	if os.Getenv("WEZTERM_PANE") == "" {
+		return fmt.Errorf("not inside WezTerm")
+	}
+	var args []string
+	switch w.Mode {
+	case "", TerminalTab:
+		args = []string{"cli", "spawn"}
+	case TerminalSplitHorizontal:
+		args = []string{"cli", "split-pane", "--horizontal"}
+	case TerminalSplitVertical:
+		args = []string{"cli", "split-pane", "--bottom"}
+	default:
+		return fmt.Errorf("unknown terminal mode %q", w.Mode)
+	}
+	if target.Path != "" {
+		args = append(args, "--cwd", target.Path)
+	}
+	if len(target.Command) > 0 {
+		args = append(append(args, "--"), target.Command...)
+	}
+	output, err := runner.Output(exec.Command("wezterm", args...))
+	if err != nil {
+		return err
+	}
+	// Splits share the tab, and its title, with the pane they split.
+	pane := strings.TrimSpace(string(output))
+	if pane == "" || args[1] == "split-pane" {
+		return nil
+	}
+	return runner.Run(exec.Command("wezterm", "cli", "set-tab-title", "--pane-id", pane, paneTitle(target)))
+}
+
+// Kitty opens worktrees in kitty by remote control, which needs
+// allow_remote_control in kitty.conf, in a new tab or a split according to
+// Mode. Splits need the splits layout.
+type Kitty struct {
+	Mode TerminalMode
+}
+
+// Open opens target.Path in a new kitty tab or window titled after the
+// branch, with target.Env in its environment.
+func (k Kitty) Open(target Target) error {
+	if os.Getenv("KITTY_WINDOW_ID") == "" {
+		return fmt.Errorf("not inside kitty")
+	}
+	var args []string
+	switch k.Mode {
+	case "", TerminalTab:
+		args = []string{"@", "launch", "--type=tab", "--tab-title", paneTitle(target)}
+	case TerminalSplitHorizontal:
+		args = []string{"@", "launch", "--type=window", "--location=vsplit", "--title", paneTitle(target)}
+	case TerminalSplitVertical:
+		args = []string{"@", "launch", "--type=window", "--location=hsplit", "--title", paneTitle(target)}
+	default:
+		return fmt.Errorf("unknown terminal mode %q", k.Mode)
+	}
+	for _, kv := range target.Env {
+		args = append(args, "--env", kv)
+	}
+	if target.Path != "" {
+		args = append(args, "--cwd", target.Path)
+	}
+	return runner.Run(exec.Command("kitty", append(args, target.Command...)...))
+}
+
+// WindowsTerminal opens worktrees in the current Windows Terminal window, in
+// a new tab or a split according to Mode.
+type WindowsTerminal struct {
+	Mode TerminalMode
+}
+
+// Open opens target.Path in a new Windows Terminal tab or pane titled after
+// the branch.
+func (w WindowsTerminal) Open(target Target) error {
+	if os.Getenv("WT_SESSION") == "" {
+		return fmt.Errorf("not inside Windows Terminal")
+	}
+	args := []string{"-w", "0"}
+	switch w.Mode {
+	case "", TerminalTab:
+		args = append(args, "new-tab")
+	case TerminalSplitHorizontal:
+		args = append(args, "split-pane", "-V")
+	case TerminalSplitVertical:
+		args = append(args, "split-pane", "-H")
+	default:
+		return fmt.Errorf("unknown terminal mode %q", w.Mode)
+	}
+	args = append(args, "--title", paneTitle(target))
+	if target.Path != "" {
+		args = append(args, "-d", target.Path)
+	}
+	return runner.Run(exec.Command(windowsTerminalPath(), append(args, target.Command...)...))
+}
+
+// windowsTerminalPath locates Windows Terminal's wt.exe. It shares its name
+// with wt itself, so the app execution alias is preferred over a PATH lookup
+// that may well find this program.
+func windowsTerminalPath() string {
+	if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
+		alias := filepath.Join(dir, "Microsoft", "WindowsApps", "wt.exe")
+		if _, err := os.Stat(alias); err == nil {
+			return alias
+		}
+	}
+	return "wt.exe"
+}
+
+// ITerm2 opens worktrees in the current iTerm2 window, in a new tab or a
+// split according to Mode. iTerm2's escape codes can't create sessions, so
+// this goes through AppleScript, which asks for automation permission once.
+type ITerm2 struct {
+	Mode TerminalMode
+}
+
+// Open opens target.Path in a new iTerm2 tab or split named after the branch,
+// typing the cd (or target.Command) into its shell.
+func (it ITerm2) Open(target Target) error {
+	if os.Getenv("TERM_PROGRAM") != "iTerm.app" {
+		return fmt.Errorf("not inside iTerm2")
+	}
+	var create string
+	switch it.Mode {
+	case "", TerminalTab:
+		create = "tell current window to set s to current session of (create tab with default profile)"
+	case TerminalSplitHorizontal:
+		create = "tell current session of current window to set s to (split vertically with default profile)"
+	case TerminalSplitVertical:
+		create = "tell current session of current window to set s to (split horizontally with default profile)"
+	default:
+		return fmt.Errorf("unknown terminal mode %q", it.Mode)
+	}
+
+	var commands []string
+	if target.Path != "" {
+		commands = append(commands, "cd "+shquote.Quote(target.Path))
+	}
+	if len(target.Command) > 0 {
+		quoted := make([]string, len(target.Command))
+		for i, arg := range target.Command {
+			quoted[i] = shquote.Quote(arg)
+		}
+		commands = append(commands, strings.Join(quoted, " "))
+	}
+	script := strings.Join([]string{
+		`tell application "iTerm2"`,
+		create,
+		"tell s",
+		"set name to " + appleScriptString(paneTitle(target)),
+		"write text " + appleScriptString(strings.Join(commands, " && ")),
+		"end tell",
+		"end tell",
+	}, "\n")
+	return runner.Run(exec.Command("osascript", "-e", script))
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,143 @@
+package term
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/default-anton/wt/internal/runner"
+)
+
+// Target describes the worktree to open.
+type Target struct {
+	Path   string
+	Branch string
+	// Command, if set, runs in the new window instead of a shell, e.g. an ssh
+	// session into a worktree on another machine. Path may then be empty.
+	Command []string
+	// Env holds KEY=VALUE pairs set in the new pane's environment (tmux only).
+	Env []string
+	// Name, if set, names the new tmux window or session and titles the pane
+	// in place of the branch.
+	Name string
+}
+
+// Opener opens a worktree in a new terminal window, tab, or pane.
+type Opener interface {
+	Open(target Target) error
+}
+
+// TmuxMode selects how Tmux opens a worktree.
+type TmuxMode string
+
+const (
+	// TmuxWindow opens a new window in the current session.
+	TmuxWindow TmuxMode = "window"
+	// TmuxSplitHorizontal splits the current pane side by side.
+	TmuxSplitHorizontal TmuxMode = "split-horizontal"
+	// TmuxSplitVertical splits the current pane top and bottom.
+	TmuxSplitVertical TmuxMode = "split-vertical"
+	// TmuxSession creates (or reuses) a session named after the branch and switches to it.
+	TmuxSession TmuxMode = "session"
+)
+
+// TmuxModes lists the supported tmux modes.
+var TmuxModes = []TmuxMode{TmuxWindow, TmuxSplitHorizontal, TmuxSplitVertical, TmuxSession}
+
+// ParseTmuxMode validates a tmux mode name. An empty name means TmuxWindow.
+func ParseTmuxMode(name string) (TmuxMode, error) {
+	if name == "" {
+		return TmuxWindow, nil
+	}
+	for _, mode := range TmuxModes {
+		if string(mode) == name {
+			return mode, nil
+		}
+	}
+	names := make([]string, len(TmuxModes))
+	for i, mode := range TmuxModes {
+		names[i] = string(mode)
+	}
+	return "", fmt.Errorf("unknown tmux mode %q (supported: %s)", name, strings.Join(names, ", "))
+}
+
+// Tmux opens worktrees in tmux according to Mode.
+type Tmux struct {
+	Mode TmuxMode
+}
+
+// Open opens target.Path in a tmux window, split, or session.
+func (t Tmux) Open(target Target) error {
+	if os.Getenv("TMUX") == "" {
+		return fmt.Errorf("not inside a tmux session")
+	}
+
+	switch t.Mode {
+	case "", TmuxWindow:
+		if target.Name != "" {
+			return tmuxNewPane(target, "new-window", "-n", target.Name)
+		}
+		return tmuxNewPane(target, "new-window")
+	case TmuxSplitHorizontal:
+		return tmuxNewPane(target, "split-window", "-h")
+	case TmuxSplitVertical:
+		return tmuxNewPane(target, "split-window", "-v")
+	case TmuxSession:
+		name := SessionName(target)
+		if runner.Run(exec.Command("tmux", "has-session", "-t", "="+name)) != nil {
+			if err := tmuxNewPane(target, "new-session", "-d", "-s", name); err != nil {
+				return err
+			}
+		}
+		return runner.Run(exec.Command("tmux", "switch-client", "-t", "="+name))
+	default:
+		return fmt.Errorf("unknown tmux mode %q", t.Mode)
+	}
+}
+
+// tmuxNewPane runs a tmux command that creates a window, pane, or session for
+// target, then titles the new pane after the branch.
+func tmuxNewPane(target Target, args ...string) error {
+	args = append(args, "-P", "-F", "#{pane_id}")
+	output, err := runner.Output(exec.Command("tmux", tmuxArgs(target, args...)...))
+	if err != nil {
+		return err
+	}
+	pane := strings.TrimSpace(string(output))
+	if pane == "" {
+		return nil
+	}
+	return runner.Run(exec.Command("tmux", "select-pane", "-t", pane, "-T", paneTitle(target)))
+}
+
+// tmuxArgs appends the environment, working directory, and command of target
+// to a tmux command that creates a window, pane, or session.
+func tmuxArgs(target Target, args ...string) []string {
+	for _, kv := range target.Env {
+		args = append(args, "-e", kv)
+	}
+	if target.Path != "" {
+		args = append(args, "-c", target.Path)
+	}
+	return append(args, target.Command...)
+}
+
+// paneTitle returns the name of target, or else its branch, or the directory
+// name for detached worktrees.
+func paneTitle(target Target) string {
+	if target.Name != "" {
+		return target.Name
+	}
+	if target.Branch != "" {
+		return target.Branch
+	}
+	return filepath.Base(target.Path)
+}
+
+// SessionName returns a tmux-safe session name for target: its pane title
+// with '.' and ':' replaced by '-'.
+func SessionName(target Target) string {
+	return strings.NewReplacer(".", "-", ":", "-").Replace(paneTitle(target))
+}
@@ -0,0 +1,357 @@
+package term
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/default-anton/wt/internal/runner"
+)
+
+func TestTmuxOpen(t *testing.T) {
+	target := Target{Path: "/repo/.worktrees/feature", Branch: "feature/v1.2", Env: []string{"WT_BRANCH=feature/v1.2"}}
+	pane := runner.Response{Stdout: "%7\n"}
+
+	tests := []struct {
+		name  string
+		mode  TmuxMode
+		fake  *runner.Fake
+		calls []string
+	}{
+		{
+			name: "default window",
+			fake: runner.NewFake().On("tmux new-window", pane).On("tmux select-pane", runner.Response{}),
+			calls: []string{
+				"tmux new-window -P -F #{pane_id} -e WT_BRANCH=feature/v1.2 -c /repo/.worktrees/feature",
+				"tmux select-pane -t %7 -T feature/v1.2",
+			},
+		},
+		{
+			name: "horizontal split",
+			mode: TmuxSplitHorizontal,
+			fake: runner.NewFake().On("tmux split-window", pane).On("tmux select-pane", runner.Response{}),
+			calls: []string{
+				"tmux split-window -h -P -F #{pane_id} -e WT_BRANCH=feature/v1.2 -c /repo/.worktrees/feature",
+				"tmux select-pane -t %7 -T feature/v1.2",
+			},
+		},
+		{
+			name: "vertical split",
+			mode: TmuxSplitVertical,
+			fake: runner.NewFake().On("tmux split-window", pane).On("tmux select-pane", runner.Response{}),
+			calls: []string{
+				"tmux split-window -v -P -F #{pane_id} -e WT_BRANCH=feature/v1.2 -c /repo/.worktrees/feature",
+				"tmux select-pane -t %7 -T feature/v1.2",
+			},
+		},
+		{
+			name: "new session",
+			mode: TmuxSession,
+			fake: runner.NewFake().
+				On("tmux has-session", runner.Response{Err: errors.New("exit status 1")}).
+				On("tmux new-session", pane).
+				On("tmux select-pane", runner.Response{}).
+				On("tmux switch-client", runner.Response{}),
+			calls: []string{
+				"tmux has-session -t =feature/v1-2",
+				"tmux new-session -d -s feature/v1-2 -P -F #{pane_id} -e WT_BRANCH=feature/v1.2 -c /repo/.worktrees/feature",
+				"tmux select-pane -t %7 -T feature/v1.2",
+				"tmux switch-client -t =feature/v1-2",
+			},
+		},
+		{
+			name: "existing session",
+			mode: TmuxSession,
+			fake: runner.NewFake().
+				On("tmux has-session", runner.Response{}).
+				On("tmux switch-client", runner.Response{}),
+			calls: []string{
+				"tmux has-session -t =feature/v1-2",
+				"tmux switch-client -t =feature/v1-2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+			t.Cleanup(runner.Set(tt.fake))
+
+			if err := (Tmux{Mode: tt.mode}).Open(target); err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+
+			var got []string
+			for _, c := range tt.fake.Calls() {
+				got = append(got, c.String())
+			}
+			if !reflect.DeepEqual(got, tt.calls) {
+				t.Fatalf("calls = %v, want %v", got, tt.calls)
+			}
+		})
+	}
+}
+
+func TestTmuxOpenNamed(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	target := Target{Path: "/repo/.worktrees/feature", Branch: "feature", Name: "shop:feature"}
+	pane := runner.Response{Stdout: "%7\n"}
+
+	fake := runner.NewFake().On("tmux new-window", pane).On("tmux select-pane", runner.Response{})
+	t.Cleanup(runner.Set(fake))
+	if err := (Tmux{}).Open(target); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	want := []string{
+		"tmux new-window -n shop:feature -P -F #{pane_id} -c /repo/.worktrees/feature",
+		"tmux select-pane -t %7 -T shop:feature",
+	}
+	var got []string
+	for _, c := range fake.Calls() {
+		got = append(got, c.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+
+	if name := SessionName(target); name != "shop-feature" {
+		t.Errorf("SessionName = %q, want shop-feature", name)
+	}
+}
+
+func TestTmuxOpenOutsideSession(t *testing.T) {
+	t.Setenv("TMUX", "")
+	fake := runner.NewFake()
+	t.Cleanup(runner.Set(fake))
+
+	if err := (Tmux{}).Open(Target{Path: "/repo"}); err == nil {
+		t.Fatal("expected error outside tmux")
+	}
+	if len(fake.Calls()) != 0 {
+		t.Fatalf("expected no calls, got %v", fake.Calls())
+	}
+}
+
+func TestTmuxOpenCommand(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	fake := runner.NewFake().On("tmux new-window", runner.Response{})
+	t.Cleanup(runner.Set(fake))
+
+	target := Target{Branch: "feature", Command: []string{"ssh", "-t", "devbox", "cd src"}}
+	if err := (Tmux{}).Open(target); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	calls := fake.Calls()
+	if len(calls) != 1 || !reflect.DeepEqual(calls[0].Args, []string{"tmux", "new-window", "-P", "-F", "#{pane_id}", "ssh", "-t", "devbox", "cd src"}) {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+}
+
+func TestParseTmuxMode(t *testing.T) {
+	if mode, err := ParseTmuxMode(""); err != nil || mode != TmuxWindow {
+		t.Fatalf("ParseTmuxMode(\"\") = %q, %v", mode, err)
+	}
+	if mode, err := ParseTmuxMode("session"); err != nil || mode != TmuxSession {
+		t.Fatalf("ParseTmuxMode(\"session\") = %q, %v", mode, err)
+	}
+	if _, err := ParseTmuxMode("pane"); err == nil {
+		t.Fatal("expected error for unknown mode")
+	}
+}
+
+func TestZellijOpen(t *testing.T) {
+	target := Target{Path: "/repo/.worktrees/feature", Branch: "feature/x"}
+
+	tests := []struct {
+		name string
+		mode ZellijMode
+		want string
+	}{
+		{name: "tab", mode: ZellijTab, want: "zellij action new-tab --cwd /repo/.worktrees/feature --name feature/x"},
+		{name: "pane", mode: ZellijPane, want: "zellij action new-pane --cwd /repo/.worktrees/feature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ZELLIJ", "0")
+			fake := runner.NewFake().On("zellij action", runner.Response{})
+			t.Cleanup(runner.Set(fake))
+
+			if err := (Zellij{Mode: tt.mode}).Open(target); err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			calls := fake.Calls()
+			if len(calls) != 1 || calls[0].String() != tt.want {
+				t.Fatalf("calls = %v, want %q", calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestTmuxLocations(t *testing.T) {
+	fake := runner.NewFake().On("tmux list-panes", runner.Response{
+		Stdout: "work\t1\t/repo\n" +
+			"work\t2\t/repo/.worktrees/feature/src\n" +
+			"work\t2\t/repo/.worktrees/feature\n" +
+			"review\t0\t/repo/.worktrees/feature-2\n" +
+			"other\t3\t/elsewhere\n",
+	})
+	t.Cleanup(runner.Set(fake))
+
+	got := TmuxLocations([]string{"/repo", "/repo/.worktrees/feature", "/repo/.worktrees/feature-2"})
+	want := map[string][]TmuxLocation{
+		"/repo":                      {{Session: "work", Window: "1"}},
+		"/repo/.worktrees/feature":   {{Session: "work", Window: "2"}},
+		"/repo/.worktrees/feature-2": {{Session: "review", Window: "0"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("TmuxLocations() = %v, want %v", got, want)
+	}
+}
+
+func TestTmuxLocationsWithoutTmux(t *testing.T) {
+	fake := runner.NewFake()
+	t.Cleanup(runner.Set(fake))
+
+	if got := TmuxLocations([]string{"/repo"}); len(got) != 0 {
+		t.Fatalf("expected no locations, got %v", got)
+	}
+}
+
+func TestParseTerminalMode(t *testing.T) {
+	if mode, err := ParseTerminalMode(""); err != nil || mode != TerminalTab {
+		t.Fatalf("ParseTerminalMode(\"\") = %q, %v", mode, err)
+	}
+	if _, err := ParseTerminalMode("window"); err == nil {
+		t.Fatal("expected error for unknown mode")
+	}
+}
+
+func TestDetectTerminal(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Terminal
+	}{
+		{name: "nothing", want: TerminalTmux},
+		{name: "tmux inside kitty", env: map[string]string{"TMUX": "/tmp/tmux", "KITTY_WINDOW_ID": "1"}, want: TerminalTmux},
+		{name: "zellij", env: map[string]string{"ZELLIJ": "0", "WEZTERM_PANE": "3"}, want: TerminalZellij},
+		{name: "wezterm", env: map[string]string{"WEZTERM_PANE": "3"}, want: TerminalWezTerm},
+		{name: "kitty", env: map[string]string{"KITTY_WINDOW_ID": "1"}, want: TerminalKitty},
+		{name: "windows terminal", env: map[string]string{"WT_SESSION": "abc"}, want: TerminalWindowsTerminal},
+		{name: "iterm2", env: map[string]string{"TERM_PROGRAM": "iTerm.app"}, want: TerminalITerm2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"TMUX", "ZELLIJ", "WEZTERM_PANE", "KITTY_WINDOW_ID", "WT_SESSION", "TERM_PROGRAM"} {
+				t.Setenv(name, tt.env[name])
+			}
+			if got := DetectTerminal(); got != tt.want {
+				t.Fatalf("DetectTerminal() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTabOpeners(t *testing.T) {
+	target := Target{Path: "/repo/.worktrees/feature", Branch: "feature/x", Env: []string{"WT_BRANCH=feature/x"}}
+
+	t.Run("wezterm", func(t *testing.T) {
+		t.Setenv("WEZTERM_PANE", "3")
+		fake := runner.NewFake().
+			On("wezterm cli spawn", runner.Response{Stdout: "7\n"}).
+			On("wezterm cli set-tab-title", runner.Response{})
+		t.Cleanup(runner.Set(fake))
+		if err := (WezTerm{}).Open(target); err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		assertCalls(t, fake,
+			"wezterm cli spawn --cwd /repo/.worktrees/feature",
+			"wezterm cli set-tab-title --pane-id 7 feature/x")
+	})
+
+	t.Run("kitty", func(t *testing.T) {
+		t.Setenv("KITTY_WINDOW_ID", "1")
+		fake := runner.NewFake().On("kitty @ launch", runner.Response{})
+		t.Cleanup(runner.Set(fake))
+		if err := (Kitty{}).Open(target); err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		assertCalls(t, fake, "kitty @ launch --type=tab --tab-title feature/x --env WT_BRANCH=feature/x --cwd /repo/.worktrees/feature")
+	})
+
+	t.Run("windows terminal", func(t *testing.T) {
+		t.Setenv("WT_SESSION", "abc")
+		t.Setenv("LOCALAPPDATA", "")
+		fake := runner.NewFake().On("wt.exe", runner.Response{})
+		t.Cleanup(runner.Set(fake))
+		if err := (WindowsTerminal{}).Open(Target{Path: "/repo/.worktrees/feature", Branch: "feature/x", Command: []string{"ssh", "box"}}); err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		assertCalls(t, fake, "wt.exe -w 0 new-tab --title feature/x -d /repo/.worktrees/feature ssh box")
+	})
+
+	t.Run("wezterm split", func(t *testing.T) {
+		t.Setenv("WEZTERM_PANE", "3")
+		fake := runner.NewFake().On("wezterm cli split-pane", runner.Response{Stdout: "8\n"})
+		t.Cleanup(runner.Set(fake))
+		if err := (WezTerm{Mode: TerminalSplitHorizontal}).Open(target); err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		assertCalls(t, fake, "wezterm cli split-pane --horizontal --cwd /repo/.worktrees/feature")
+	})
+
+	t.Run("kitty split", func(t *testing.T) {
+		t.Setenv("KITTY_WINDOW_ID", "1")
+		fake := runner.NewFake().On("kitty @ launch", runner.Response{})
+		t.Cleanup(runner.Set(fake))
+		if err := (Kitty{Mode: TerminalSplitVertical}).Open(Target{Path: "/wt", Branch: "x"}); err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		assertCalls(t, fake, "kitty @ launch --type=window --location=hsplit --title x --cwd /wt")
+	})
+
+	t.Run("iterm2", func(t *testing.T) {
+		t.Setenv("TERM_PROGRAM", "iTerm.app")
+		fake := runner.NewFake().On("osascript", runner.Response{})
+		t.Cleanup(runner.Set(fake))
+		if err := (ITerm2{Mode: TerminalSplitHorizontal}).Open(Target{Path: "/it's here", Branch: "feature/x"}); err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		calls := fake.Calls()
+		if len(calls) != 1 {
+			t.Fatalf("calls = %v", calls)
+		}
+		script := calls[0].Args[2]
+		for _, want := range []string{
+			"split vertically with default profile",
+			`set name to "feature/x"`,
+			`write text "cd '/it'\\''s here'"`,
+		} {
+			if !strings.Contains(script, want) {
+				t.Errorf("script lacks %q:\n%s", want, script)
+			}
+		}
+	})
+
+	t.Run("outside", func(t *testing.T) {
+		t.Setenv("KITTY_WINDOW_ID", "")
+		if err := (Kitty{}).Open(target); err == nil {
+			t.Fatal("expected an error outside kitty")
+		}
+	})
+}
+
+func assertCalls(t *testing.T, fake *runner.Fake, want ...string) {
+	t.Helper()
+	calls := fake.Calls()
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %q", calls, want)
+	}
+	for i, call := range calls {
+		if call.String() != want[i] {
+			t.Fatalf("call %d = %q, want %q", i, call.String(), want[i])
+		}
+	}
+}
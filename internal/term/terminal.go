@@ -0,0 +1,104 @@
+package term
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Terminal names where -t/--tmux opens worktrees.
+type Terminal string
+
+const (
+	// TerminalAuto picks the terminal wt is running in; see DetectTerminal.
+	TerminalAuto Terminal = "auto"
+	// TerminalTmux opens a window, split or session as tmux_mode says.
+	TerminalTmux Terminal = "tmux"
+	// TerminalZellij opens a tab or pane as zellij_mode says.
+	TerminalZellij Terminal = "zellij"
+	// TerminalWezTerm opens a WezTerm tab.
+	TerminalWezTerm Terminal = "wezterm"
+	// TerminalKitty opens a kitty tab.
+	TerminalKitty Terminal = "kitty"
+	// TerminalWindowsTerminal opens a Windows Terminal tab.
+	TerminalWindowsTerminal Terminal = "windows-terminal"
+	// TerminalITerm2 opens an iTerm2 tab.
+	TerminalITerm2 Terminal = "iterm2"
+)
+
+// Terminals lists the supported terminals.
+var Terminals = []Terminal{TerminalAuto, TerminalTmux, TerminalZellij, TerminalWezTerm, TerminalKitty, TerminalWindowsTerminal, TerminalITerm2}
+
+// TerminalMode selects whether terminal emulators open a worktree in a new
+// tab or split the current pane. tmux and zellij have modes of their own.
+type TerminalMode string
+
+const (
+	// TerminalTab opens a new tab.
+	TerminalTab TerminalMode = "tab"
+	// TerminalSplitHorizontal splits the current pane side by side.
+	TerminalSplitHorizontal TerminalMode = "split-horizontal"
+	// TerminalSplitVertical splits the current pane top and bottom.
+	TerminalSplitVertical TerminalMode = "split-vertical"
+)
+
+// TerminalModes lists the supported terminal modes.
+var TerminalModes = []TerminalMode{TerminalTab, TerminalSplitHorizontal, TerminalSplitVertical}
+
+// ParseTerminalMode validates a terminal mode name. An empty name means
+// TerminalTab.
+func ParseTerminalMode(name string) (TerminalMode, error) {
+	if name == "" {
+		return TerminalTab, nil
+	}
+	for _, mode := range TerminalModes {
+		if string(mode) == name {
+			return mode, nil
+		}
+	}
+	names := make([]string, len(TerminalModes))
+	for i, mode := range TerminalModes {
+		names[i] = string(mode)
+	}
+	return "", fmt.Errorf("unknown terminal mode %q (supported: %s)", name, strings.Join(names, ", "))
+}
+
+// ParseTerminal validates a terminal name. An empty name means TerminalAuto.
+func ParseTerminal(name string) (Terminal, error) {
+	if name == "" {
+		return TerminalAuto, nil
+	}
+	for _, t := range Terminals {
+		if string(t) == name {
+			return t, nil
+		}
+	}
+	names := make([]string, len(Terminals))
+	for i, t := range Terminals {
+		names[i] = string(t)
+	}
+	return "", fmt.Errorf("unknown terminal %q (supported: %s)", name, strings.Join(names, ", "))
+}
+
+// DetectTerminal returns the terminal wt is running in, judging by the
+// variables each one sets. Multiplexers come first, since they run inside
+// another terminal whose variables they inherit. Outside all of them it is
+// tmux, which then reports that there is no session.
+func DetectTerminal() Terminal {
+	switch {
+	case os.Getenv("TMUX") != "":
+		return TerminalTmux
+	case InZellij():
+		return TerminalZellij
+	case os.Getenv("WEZTERM_PANE") != "":
+		return TerminalWezTerm
+	case os.Getenv("KITTY_WINDOW_ID") != "":
+		return TerminalKitty
+	case os.Getenv("WT_SESSION") != "":
+		return TerminalWindowsTerminal
+	case os.Getenv("TERM_PROGRAM") == "iTerm.app":
+		return TerminalITerm2
+	default:
+		return TerminalTmux
+	}
+}
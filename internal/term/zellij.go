@@ -0,0 +1,68 @@
+package term
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/default-anton/wt/internal/runner"
+)
+
+// ZellijMode selects how Zellij opens a worktree.
+type ZellijMode string
+
+const (
+	// ZellijTab opens a new tab named after the branch.
+	ZellijTab ZellijMode = "tab"
+	// ZellijPane opens a new pane in the current tab.
+	ZellijPane ZellijMode = "pane"
+)
+
+// ParseZellijMode validates a zellij mode name. An empty name means ZellijTab.
+func ParseZellijMode(name string) (ZellijMode, error) {
+	switch ZellijMode(name) {
+	case "", ZellijTab:
+		return ZellijTab, nil
+	case ZellijPane:
+		return ZellijPane, nil
+	default:
+		return "", fmt.Errorf("unknown zellij mode %q (supported: tab, pane)", name)
+	}
+}
+
+// InZellij reports whether wt is running inside a zellij session.
+func InZellij() bool {
+	return os.Getenv("ZELLIJ") != ""
+}
+
+// Zellij opens worktrees in zellij according to Mode.
+type Zellij struct {
+	Mode ZellijMode
+}
+
+// Open opens target.Path in a new zellij tab or pane.
+func (z Zellij) Open(target Target) error {
+	if !InZellij() {
+		return fmt.Errorf("not inside a zellij session")
+	}
+
+	name := strings.TrimSpace(target.Branch)
+	if name == "" {
+		name = SessionName(target)
+	}
+
+	// Tabs can't start with a command, so commands always get a new pane.
+	if len(target.Command) > 0 {
+		return runner.Run(exec.Command("zellij", append([]string{"run", "--name", name, "--"}, target.Command...)...))
+	}
+
+	switch z.Mode {
+	case "", ZellijTab:
+		return runner.Run(exec.Command("zellij", "action", "new-tab", "--cwd", target.Path, "--name", name))
+	case ZellijPane:
+		return runner.Run(exec.Command("zellij", "action", "new-pane", "--cwd", target.Path))
+	default:
+		return fmt.Errorf("unknown zellij mode %q", z.Mode)
+	}
+}
@@ -0,0 +1,94 @@
+package tmpl
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DirVars holds the values available to dir_template.
+type DirVars struct {
+	Branch     string
+	BaseBranch string
+	Input      string
+	Date       string // YYYY-MM-DD
+	Hash       string // first 7 hex digits of the branch's SHA-1
+}
+
+// NewDirVars fills in the derived fields of DirVars.
+func NewDirVars(branch, baseBranch, input string, now time.Time) DirVars {
+	sum := sha1.Sum([]byte(branch))
+	return DirVars{
+		Branch:     branch,
+		BaseBranch: baseBranch,
+		Input:      input,
+		Date:       now.Format("2006-01-02"),
+		Hash:       hex.EncodeToString(sum[:])[:7],
+	}
+}
+
+var ticketPattern = regexp.MustCompile(`[A-Z][A-Z0-9]*-[0-9]+`)
+
+func dirFuncs(sanitize func(string) (string, error)) template.FuncMap {
+	return template.FuncMap{
+		"sanitize": sanitize,
+		"lower":    strings.ToLower,
+		// truncate keeps the first n characters; its argument order suits
+		// pipelines: {{.Branch | truncate 20}}.
+		"truncate": func(n int, s string) string {
+			if r := []rune(s); len(r) > n {
+				return string(r[:n])
+			}
+			return s
+		},
+		// ticket extracts the first issue key such as PROJ-123, or "".
+		"ticket": func(s string) string {
+			return ticketPattern.FindString(s)
+		},
+		// date formats the current time with a Go layout: {{date "0102"}}.
+		"date": func(layout string) string {
+			return time.Now().Format(layout)
+		},
+	}
+}
+
+// keep stands in for sanitize when templates are only validated.
+func keep(s string) (string, error) {
+	return s, nil
+}
+
+// ValidateDirTemplate checks that text parses and only uses known fields
+// and functions.
+func ValidateDirTemplate(text string) error {
+	t, err := template.New("dir_template").Funcs(dirFuncs(keep)).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return err
+	}
+	return t.Execute(io.Discard, NewDirVars("feature", "main", "feature", time.Now()))
+}
+
+// DirName renders the dir_template text into a worktree directory name.
+// sanitize backs the template's sanitize function. The result must be a
+// single, non-empty path component.
+func DirName(text string, vars DirVars, sanitize func(string) (string, error)) (string, error) {
+	t, err := template.New("dir_template").Funcs(dirFuncs(sanitize)).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("dir_template: %w", err)
+	}
+	name := strings.TrimSpace(buf.String())
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || filepath.Base(name) != name {
+		return "", fmt.Errorf("dir_template produced %q, which is not a single directory name (pipe values through sanitize)", name)
+	}
+	return name, nil
+}
@@ -0,0 +1,61 @@
+package tmpl
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDirName(t *testing.T) {
+	vars := NewDirVars("feature/PROJ-123-very-long-branch-name", "main", "PROJ-123", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC))
+	sanitize := func(s string) (string, error) { return strings.ReplaceAll(s, "/", "-"), nil }
+
+	tests := []struct {
+		text, want, wantErr string
+	}{
+		{text: "{{.Date}}-{{.Branch | sanitize}}", want: "2024-03-05-feature-PROJ-123-very-long-branch-name"},
+		{text: "{{.Branch | ticket}}-{{.Hash}}", want: "PROJ-123-" + vars.Hash},
+		{text: "{{.Branch | sanitize | truncate 12 | lower}}", want: "feature-proj"},
+		{text: "{{.Branch}}", wantErr: "not a single directory name"},
+		{text: "{{.Nope}}", wantErr: "dir_template"},
+	}
+	for _, tt := range tests {
+		got, err := DirName(tt.text, vars, sanitize)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("DirName(%q): expected error containing %q, got %v", tt.text, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("DirName(%q) = %q, %v; want %q", tt.text, got, err, tt.want)
+		}
+	}
+}
+
+func TestTmuxName(t *testing.T) {
+	vars := NameVars{Repo: "shop", Branch: "feature/PROJ-7-cart", Dir: "feature-PROJ-7-cart"}
+	sanitize := func(s string) (string, error) { return strings.ReplaceAll(s, "/", "-"), nil }
+
+	tests := []struct {
+		text, want, wantErr string
+	}{
+		{text: "{{.Repo}}:{{.Branch | sanitize}}", want: "shop:feature-PROJ-7-cart"},
+		{text: "{{.Repo}}/{{.Branch | ticket}}", want: "shop/PROJ-7"},
+		{text: "{{.Dir | lower}}", want: "feature-proj-7-cart"},
+		{text: "{{.Repo | truncate 0}}", wantErr: "empty name"},
+		{text: "{{.Nope}}", wantErr: "tmux_name"},
+	}
+	for _, tt := range tests {
+		got, err := TmuxName(tt.text, vars, sanitize)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("TmuxName(%q): expected error containing %q, got %v", tt.text, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("TmuxName(%q) = %q, %v; want %q", tt.text, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package tmpl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// NameVars holds the values available to tmux_name.
+type NameVars struct {
+	Repo   string // the repository's directory name
+	Branch string // the branch, or the directory name of a detached worktree
+	Dir    string // the worktree's directory name
+}
+
+// ValidateTmuxName checks that text parses and only uses known fields and
+// functions.
+func ValidateTmuxName(text string) error {
+	t, err := template.New("tmux_name").Funcs(dirFuncs(keep)).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return err
+	}
+	return t.Execute(io.Discard, NameVars{Repo: "repo", Branch: "feature", Dir: "feature"})
+}
+
+// TmuxName renders the tmux_name text into a tmux window or session name.
+// sanitize backs the template's sanitize function, as in dir_template.
+func TmuxName(text string, vars NameVars, sanitize func(string) (string, error)) (string, error) {
+	t, err := template.New("tmux_name").Funcs(dirFuncs(sanitize)).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("tmux_name: %w", err)
+	}
+	name := strings.TrimSpace(buf.String())
+	if name == "" {
+		return "", fmt.Errorf("tmux_name produced an empty name")
+	}
+	return name, nil
+}
@@ -0,0 +1,96 @@
+package tmpl
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/default-anton/wt/internal/log"
+)
+
+// Vars holds the values available to templates rendered into a new worktree.
+type Vars struct {
+	Branch       string
+	BaseBranch   string
+	Input        string
+	RepoRoot     string
+	WorktreePath string
+	DirName      string
+}
+
+// Render copies every file under templateDir into destDir, rendering each file's
+// contents as a Go text/template with vars. Directory structure and file modes are
+// preserved. Files that already exist in destDir (e.g., tracked by git) are skipped.
+func Render(templateDir, destDir string, vars Vars) error {
+	info, err := os.Stat(templateDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("template directory not found: %s", templateDir)
+		}
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("template path is not a directory: %s", templateDir)
+	}
+
+	return filepath.WalkDir(templateDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(templateDir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		destPath := filepath.Join(destDir, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(destPath, 0755)
+		}
+
+		if _, err := os.Lstat(destPath); err == nil {
+			log.Infof("Skipping template file %s: already exists", rel)
+			return nil
+		}
+
+		fileInfo, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		rendered, err := renderFile(path, vars)
+		if err != nil {
+			return fmt.Errorf("failed to render template %q: %w", rel, err)
+		}
+
+		if err := os.WriteFile(destPath, rendered, fileInfo.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %q: %w", rel, err)
+		}
+		log.Infof("Rendered: %s", rel)
+		return nil
+	})
+}
+
+func renderFile(path string, vars Vars) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := template.New(filepath.Base(path)).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,156 @@
+// Package trust remembers which repository configs the user has allowed to
+// run commands, like direnv's allow: trust is recorded per repository for
+// one version of its config file, so any edit asks again.
+package trust
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/default-anton/wt/internal/config"
+	"github.com/default-anton/wt/internal/state"
+)
+
+// Path returns the user-wide trust file: $XDG_STATE_HOME/wt/trust.json,
+// defaulting to ~/.local/state/wt/trust.json.
+func Path() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "wt", "trust.json"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "wt", "trust.json"), nil
+}
+
+// Hash identifies one version of a config file.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Store is a trust file mapping repository roots to the hash of the config
+// the user allowed.
+type Store struct {
+	Path string
+}
+
+// Trusted reports whether the config with hash is allowed for repo.
+func (s *Store) Trusted(repo, hash string) (bool, error) {
+	entries, err := s.load()
+	if err != nil {
+		return false, err
+	}
+	return entries[repo] == hash, nil
+}
+
+// Allow trusts the config with hash for repo, replacing earlier trust.
+func (s *Store) Allow(repo, hash string) error {
+	unlock, err := state.Lock(s.Path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	entries[repo] = hash
+	return s.save(entries)
+}
+
+// Revoke forgets any trust for repo. It reports whether there was any.
+func (s *Store) Revoke(repo string) (bool, error) {
+	unlock, err := state.Lock(s.Path)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	entries, err := s.load()
+	if err != nil {
+		return false, err
+	}
+	if _, ok := entries[repo]; !ok {
+		return false, nil
+	}
+	delete(entries, repo)
+	return true, s.save(entries)
+}
+
+func (s *Store) load() (map[string]string, error) {
+	entries := make(map[string]string)
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.Path, err)
+	}
+	return entries, nil
+}
+
+func (s *Store) save(entries map[string]string) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return state.WriteFile(s.Path, append(data, '\n'))
+}
+
+// Commands lists what cfg would run, one line per command, e.g.
+// "post_hooks: Install dependencies: npm ci".
+func Commands(cfg *config.Config) []string {
+	var lines []string
+	for _, script := range cfg.PreprocessScript {
+		lines = append(lines, "preprocess_script: "+script)
+	}
+	for _, command := range cfg.PreprocessCommand {
+		lines = append(lines, "preprocess_command: "+command)
+	}
+	hookLines := func(section string, hooks []config.Hook) {
+		for _, hook := range hooks {
+			lines = append(lines, fmt.Sprintf("%s: %s: %s", section, hook.Name, hook.Run))
+		}
+	}
+	if cfg.Direnv {
+		lines = append(lines, "direnv: direnv allow")
+	}
+	hookLines("pre_add_hooks", cfg.PreAddHooks)
+	hookLines("post_hooks", cfg.PostHooks)
+	hookLines("post_switch_hooks", cfg.PostSwitchHooks)
+	profiles := make([]string, 0, len(cfg.HookProfiles))
+	for name := range cfg.HookProfiles {
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+	for _, name := range profiles {
+		hookLines("hook_profiles."+name, cfg.HookProfiles[name])
+	}
+	names := make([]string, 0, len(cfg.Packages))
+	for name := range cfg.Packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		hookLines("packages."+name+".post_hooks", cfg.Packages[name].PostHooks)
+	}
+	names = names[:0]
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		hookLines("profiles."+name+".post_hooks", cfg.Profiles[name].PostHooks)
+	}
+	return lines
+}
@@ -0,0 +1,67 @@
+package trust
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/default-anton/wt/internal/config"
+)
+
+func TestStore(t *testing.T) {
+	s := &Store{Path: filepath.Join(t.TempDir(), "wt", "trust.json")}
+	hash := Hash([]byte("[[post_hooks]]\nrun = \"npm ci\"\n"))
+
+	if ok, err := s.Trusted("/repo", hash); err != nil || ok {
+		t.Fatalf("Trusted() before Allow = %v, %v; want false, nil", ok, err)
+	}
+	if err := s.Allow("/repo", hash); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := s.Trusted("/repo", hash); !ok {
+		t.Fatal("Trusted() after Allow = false")
+	}
+	if ok, _ := s.Trusted("/repo", Hash([]byte("changed"))); ok {
+		t.Fatal("Trusted() with a different hash = true")
+	}
+	if ok, _ := s.Trusted("/other", hash); ok {
+		t.Fatal("Trusted() for another repository = true")
+	}
+
+	if revoked, err := s.Revoke("/repo"); err != nil || !revoked {
+		t.Fatalf("Revoke() = %v, %v; want true, nil", revoked, err)
+	}
+	if ok, _ := s.Trusted("/repo", hash); ok {
+		t.Fatal("Trusted() after Revoke = true")
+	}
+	if revoked, _ := s.Revoke("/repo"); revoked {
+		t.Fatal("second Revoke() = true")
+	}
+}
+
+func TestCommands(t *testing.T) {
+	cfg := &config.Config{
+		PreprocessCommand: config.Steps{"python3 pre.py"},
+		Direnv:            true,
+		PreAddHooks:       []config.Hook{{Name: "Limit", Run: "bin/check"}},
+		PostHooks:         []config.Hook{{Name: "Install", Run: "npm ci"}},
+		PostSwitchHooks:   []config.Hook{{Name: "Tools", Run: "mise install"}},
+		HookProfiles:      map[string][]config.Hook{"ci": {{Name: "Deps", Run: "npm ci --offline"}}},
+		Packages: map[string]config.Package{
+			"web": {PostHooks: []config.Hook{{Name: "Build", Run: "make"}}},
+			"api": {Path: "apps/api"},
+		},
+	}
+	want := []string{
+		"preprocess_command: python3 pre.py",
+		"direnv: direnv allow",
+		"pre_add_hooks: Limit: bin/check",
+		"post_hooks: Install: npm ci",
+		"post_switch_hooks: Tools: mise install",
+		"hook_profiles.ci: Deps: npm ci --offline",
+		"packages.web.post_hooks: Build: make",
+	}
+	if got := Commands(cfg); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Commands() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/default-anton/wt/internal/runner"
+	"github.com/default-anton/wt/internal/shquote"
+	"github.com/default-anton/wt/internal/styles"
+)
+
+// Finder selects what the selectors run.
+type Finder string
+
+const (
+	// FinderBuiltin is wt's own Bubble Tea selector.
+	FinderBuiltin Finder = "builtin"
+	// FinderFzf pipes the items to the fzf binary, so its key bindings,
+	// layout and FZF_DEFAULT_OPTS apply.
+	FinderFzf Finder = "fzf"
+)
+
+// Finders lists the supported finders.
+var Finders = []Finder{FinderBuiltin, FinderFzf}
+
+// ParseFinder validates a finder name. An empty name means FinderBuiltin.
+func ParseFinder(name string) (Finder, error) {
+	if name == "" {
+		return FinderBuiltin, nil
+	}
+	for _, f := range Finders {
+		if string(f) == name {
+			return f, nil
+		}
+	}
+	names := make([]string, len(Finders))
+	for i, f := range Finders {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("unknown finder %q (supported: %s)", name, strings.Join(names, ", "))
+}
+
+var (
+	finder  = FinderBuiltin
+	preview []string
+)
+
+// SetFinder makes the selectors use f. With fzf, previewArgs followed by an
+// item's value is the preview command for selectors that have details; the
+// builtin selector calls Options.Details instead.
+func SetFinder(f Finder, previewArgs ...string) {
+	finder, preview = f, previewArgs
+}
+
+// fzfSelect runs fzf over items and returns the values picked and the key
+// that confirmed them ("enter" or one of opts.Keys). Nothing is picked when
+// the user cancels or nothing matches.
+func fzfSelect(items []Item, multi bool, opts Options) ([]string, string, error) {
+	discardUpdates(opts.Updates)
+
+	var input strings.Builder
+	for _, item := range items {
+		input.WriteString(item.Value + "\t" + itemRow(item) + "\n")
+	}
+	args := []string{"--ansi", "--height=40%", "--delimiter=\t", "--with-nth=2..", "--nth=1"}
+	if multi {
+		args = append(args, "--multi")
+	}
+	if opts.Query != "" {
+		args = append(args, "--query="+opts.Query)
+	}
+	if opts.Details != nil && len(preview) > 0 {
+		quoted := make([]string, len(preview))
+		for i, arg := range preview {
+			quoted[i] = shquote.Quote(arg)
+		}
+		args = append(args, "--preview="+strings.Join(quoted, " ")+" {1}")
+	}
+	var keys []string
+	for _, k := range opts.Keys {
+		keys = append(keys, strings.ReplaceAll(k.Key, "+", "-"))
+	}
+	if len(keys) > 0 && !multi {
+		args = append(args, "--expect="+strings.Join(keys, ","))
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command("fzf", args...)
+	cmd.Stdin = strings.NewReader(input.String())
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := runner.Run(cmd); err != nil {
+		// 1: no match, 130: cancelled with ESC or CTRL+C.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && (exitErr.ExitCode() == 1 || exitErr.ExitCode() == 130) {
+			return nil, "", nil
+		}
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, "", fmt.Errorf("finder = \"fzf\" needs fzf on PATH: %w", err)
+		}
+		return nil, "", fmt.Errorf("fzf: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(stdout.String(), "\n"), "\n")
+	key := "enter"
+	if len(keys) > 0 && !multi {
+		// With --expect the first line is the key pressed, empty for enter.
+		if lines[0] != "" {
+			key = strings.ReplaceAll(lines[0], "-", "+")
+		}
+		lines = lines[1:]
+	}
+	var values []string
+	for _, line := range lines {
+		if value, _, _ := strings.Cut(line, "\t"); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values, key, nil
+}
+
+// itemRow renders an item on one line as a row of the builtin selector
+// would, for fzf and numberedSelect.
+func itemRow(item Item) string {
+	row := item.Label
+	if defaultView == ViewCompact {
+		return row
+	}
+	if item.Badge != "" {
+		row += " " + item.Badge
+	}
+	if item.Detail != "" {
+		row += " " + styles.DimStyle.Render(item.Detail)
+	}
+	if item.Path != "" {
+		row += " " + styles.DimStyle.Render(item.Path)
+	}
+	return row
+}
@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+)
+
+// noTUI makes selectors ask for numbers instead of opening the finder.
+var noTUI bool
+
+// stdin buffers os.Stdin for all prompts: a reader per prompt would keep
+// the answers to later prompts in its buffer.
+var stdin = bufio.NewReader(os.Stdin)
+
+// SetNoTUI makes selectors list the items with numbers and read the choice
+// from stdin, as they do when /dev/tty can't be opened.
+func SetNoTUI(on bool) {
+	noTUI = on
+}
+
+// numberedFallback picks from items with numberedSelect on stdin and stderr
+// (stdout may be captured for the result), for a selector that couldn't
+// open the terminal. Without an answer on stdin it fails with ttyErr,
+// unless SetNoTUI asked for the numbered list; then it cancels.
+func numberedFallback(ttyErr error, items []Item, multi bool, opts Options) ([]string, error) {
+	values, err := numberedSelect(stdin, os.Stderr, items, multi, opts)
+	if errors.Is(err, io.EOF) {
+		if noTUI {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open /dev/tty: %w", ttyErr)
+	}
+	return values, err
+}
+
+// numberedSelect lists items with numbers on out, without colors unless out
+// is a terminal, and reads the numbers of the picked ones from in. An empty
+// answer cancels, the end of in is io.EOF, and invalid answers are asked
+// again. Options.Keys and Options.Details don't apply.
+func numberedSelect(in *bufio.Reader, out io.Writer, items []Item, multi bool, opts Options) ([]string, error) {
+	discardUpdates(opts.Updates)
+	if opts.Query != "" {
+		items = Filter(items, opts.Query)
+		if len(items) == 0 {
+			fmt.Fprintf(out, "No matches for %q.\n", opts.Query)
+			return nil, nil
+		}
+	}
+
+	width := len(strconv.Itoa(len(items)))
+	plain := !isTerminal(out)
+	for i, item := range items {
+		row := itemRow(item)
+		if plain {
+			row = ansi.Strip(row)
+		}
+		fmt.Fprintf(out, "%*d) %s\n", width, i+1, row)
+	}
+	prompt := fmt.Sprintf("Select one [1-%d, empty to cancel]: ", len(items))
+	if multi {
+		prompt = "Select [numbers or ranges like 1 3-5, a for all, empty to cancel]: "
+	}
+
+	for {
+		fmt.Fprint(out, prompt)
+		answer, err := readLine(in)
+		if err != nil {
+			fmt.Fprintln(out)
+			return nil, err
+		}
+		if answer == "" {
+			return nil, nil
+		}
+		picked, err := parseNumbers(answer, len(items), multi)
+		if err != nil {
+			fmt.Fprintf(out, "%v\n", err)
+			continue
+		}
+		values := make([]string, len(picked))
+		for i, n := range picked {
+			values[i] = items[n-1].Value
+		}
+		return values, nil
+	}
+}
+
+// parseNumbers parses an answer to numberedSelect into 1-based item
+// numbers, in the order of the items.
+func parseNumbers(answer string, count int, multi bool) ([]int, error) {
+	invalid := fmt.Errorf("invalid selection %q: enter a number from 1 to %d", answer, count)
+	if !multi {
+		n, err := strconv.Atoi(answer)
+		if err != nil || n < 1 || n > count {
+			return nil, invalid
+		}
+		return []int{n}, nil
+	}
+	if answer == "a" || answer == "all" {
+		answer = fmt.Sprintf("1-%d", count)
+	}
+
+	picked := make([]bool, count+1)
+	for _, field := range strings.FieldsFunc(answer, func(r rune) bool { return r == ' ' || r == ',' }) {
+		from, to, isRange := strings.Cut(field, "-")
+		first, err := strconv.Atoi(from)
+		last := first
+		if err == nil && isRange {
+			last, err = strconv.Atoi(to)
+		}
+		if err != nil || first < 1 || last > count || first > last {
+			return nil, invalid
+		}
+		for n := first; n <= last; n++ {
+			picked[n] = true
+		}
+	}
+	var numbers []int
+	for n, ok := range picked {
+		if ok {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers, nil
+}
+
+// confirmLine asks message on out and reads y or yes (true) or anything else
+// (false) from in, failing with io.EOF at its end.
+func confirmLine(in *bufio.Reader, out io.Writer, message string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N]: ", message)
+	answer, err := readLine(in)
+	if err != nil {
+		fmt.Fprintln(out)
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
+// readLine reads the next line from in without surrounding space. A last
+// line without a newline counts; after it comes io.EOF.
+func readLine(in *bufio.Reader) (string, error) {
+	line, err := in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimSpace(line), err
+}
+
+// isTerminal reports whether w writes to a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(f.Fd())
+}
+
+// discardUpdates drains updates, for selectors that can't show badges
+// arriving late, so the sender doesn't block.
+func discardUpdates(updates <-chan ItemUpdate) {
+	if updates == nil {
+		return
+	}
+	go func() {
+		for range updates {
+		}
+	}()
+}
+
+// openTTY opens the terminal the finder draws on. It fails without one,
+// and with SetNoTUI, so callers fall back to numberedSelect.
+func openTTY() (*os.File, error) {
+	if noTUI {
+		return nil, errors.New("the finder is turned off")
+	}
+	return os.OpenFile("/dev/tty", os.O_RDWR, 0)
+}
@@ -1,11 +1,16 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/cursor"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -18,6 +23,109 @@ import (
 type Item struct {
 	Label string
 	Value string
+	// Detail is shown dimmed after the label and is not matched by the filter.
+	Detail string
+	// Badge is a pre-styled status shown between the label and the detail.
+	Badge string
+	// Path is shown dimmed after the detail, except in the compact view.
+	// The filter matches its last element, the worktree's directory name,
+	// when the label doesn't match.
+	Path string
+	// Created is shown as an age in the detailed view.
+	Created time.Time
+}
+
+// View is how much each row of a selector shows. CTRL+L cycles through the
+// views while a selector is open.
+type View int
+
+const (
+	ViewNormal   View = iota // label, badge, detail and path
+	ViewCompact              // label only
+	ViewDetailed             // normal plus age
+)
+
+var viewNames = map[View]string{ViewNormal: "normal", ViewCompact: "compact", ViewDetailed: "detailed"}
+
+func (v View) String() string {
+	return viewNames[v]
+}
+
+// ParseView validates a view name. An empty name means ViewNormal.
+func ParseView(name string) (View, error) {
+	if name == "" {
+		return ViewNormal, nil
+	}
+	for v, n := range viewNames {
+		if n == name {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown selector view %q (supported: compact, normal, detailed)", name)
+}
+
+// next is the view CTRL+L switches to: compact, normal, detailed, and round
+// again.
+func (v View) next() View {
+	switch v {
+	case ViewCompact:
+		return ViewNormal
+	case ViewNormal:
+		return ViewDetailed
+	default:
+		return ViewCompact
+	}
+}
+
+// ItemUpdate sets the Badge of the item with Value once it becomes known,
+// e.g. from status collected in the background.
+type ItemUpdate struct {
+	Value string
+	Badge string
+}
+
+// KeyBinding is an extra key that confirms a single selection.
+type KeyBinding struct {
+	Key  string // e.g. "ctrl+t"
+	Help string // e.g. "jump to tmux"
+}
+
+// Options customizes a selector.
+type Options struct {
+	// Keys are extra keys that confirm the selection like ENTER does.
+	// The key that was pressed is reported in Result.Key.
+	Keys []KeyBinding
+	// Updates streams badge updates into the open selector. The sender
+	// closes it when done.
+	Updates <-chan ItemUpdate
+	// Query pre-fills the filter.
+	Query string
+	// Details, when set, renders details about the item with the given
+	// value. CTRL+O toggles them below the list for the highlighted item.
+	Details func(value string) string
+}
+
+// Result is the outcome of a single selection.
+type Result struct {
+	Value string
+	Key   string // "enter" or one of Options.Keys; empty if cancelled
+}
+
+// reducedMotion keeps the filter's cursor from blinking.
+var reducedMotion bool
+
+// defaultView is the view selectors open in.
+var defaultView View
+
+// SetView sets the view selectors open in.
+func SetView(v View) {
+	defaultView = v
+}
+
+// SetReducedMotion turns animation in selectors, such as the blinking
+// cursor, off or on.
+func SetReducedMotion(on bool) {
+	reducedMotion = on
 }
 
 // scoredItem holds an item with its fuzzy match score and positions.
@@ -39,12 +147,25 @@ type selectorModel struct {
 	checked     map[int]bool
 	cancelled   bool
 	slab        *util.Slab
+	keys        []KeyBinding
+	pressedKey  string
+	updates     <-chan ItemUpdate
+	details     func(string) string
+	showDetails bool
+	detailsFor  string // value the cached detailsText belongs to
+	detailsText string
+	view        View
+	now         time.Time // ages in the detailed view are relative to it
 }
 
-func newSelectorModel(items []Item, multiSelect bool) selectorModel {
+func newSelectorModel(items []Item, multiSelect bool, opts Options) selectorModel {
 	ti := textinput.New()
 	ti.Placeholder = "Type to filter..."
+	ti.SetValue(opts.Query)
 	ti.Focus()
+	if reducedMotion {
+		ti.Cursor.SetMode(cursor.CursorStatic)
+	}
 
 	// Convert initial items to scoredItems with no match positions
 	filtered := make([]scoredItem, len(items))
@@ -57,25 +178,79 @@ func newSelectorModel(items []Item, multiSelect bool) selectorModel {
 		}
 	}
 
-	return selectorModel{
+	m := selectorModel{
 		items:       items,
 		filtered:    filtered,
 		textInput:   ti,
 		multiSelect: multiSelect,
 		checked:     make(map[int]bool),
 		slab:        util.MakeSlab(100, 2048),
+		keys:        opts.Keys,
+		updates:     opts.Updates,
+		details:     opts.Details,
+		view:        defaultView,
+		now:         time.Now(),
 	}
+	if opts.Query != "" {
+		m.filterItems()
+	}
+	return m
 }
 
 func (m selectorModel) Init() tea.Cmd {
-	return textinput.Blink
+	if reducedMotion {
+		return waitForUpdate(m.updates)
+	}
+	return tea.Batch(textinput.Blink, waitForUpdate(m.updates))
+}
+
+// waitForUpdate delivers the next ItemUpdate as a message.
+func waitForUpdate(updates <-chan ItemUpdate) tea.Cmd {
+	if updates == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		u, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return u
+	}
 }
 
 func (m selectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
+	case ItemUpdate:
+		m.applyUpdate(msg)
+		return m, waitForUpdate(m.updates)
 	case tea.KeyMsg:
+		if !m.multiSelect && m.isExtraKey(msg.String()) {
+			if len(m.filtered) == 0 {
+				return m, nil
+			}
+			m.selected = m.filtered[m.cursor].item.Value
+			m.pressedKey = msg.String()
+			m.quitting = true
+			return m, tea.Quit
+		}
+		// In the single-select finder these keys edit the query instead
+		// (ctrl+a moves to its start). ctrl+i is tab to a terminal, so
+		// inverting has ctrl+r only.
+		if m.multiSelect {
+			switch msg.String() {
+			case "ctrl+a":
+				m.checkVisible(!m.allVisibleChecked())
+				return m, nil
+			case "ctrl+r":
+				for _, scored := range m.filtered {
+					m.checked[scored.origIndex] = !m.checked[scored.origIndex]
+				}
+				return m, nil
+			}
+		}
+
 		switch msg.String() {
 		case "ctrl+c", "esc":
 			m.quitting = true
@@ -89,6 +264,7 @@ func (m selectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.selected = m.filtered[m.cursor].item.Value
 				}
 			}
+			m.pressedKey = "enter"
 			m.quitting = true
 			return m, tea.Quit
 		case "up", "ctrl+p":
@@ -107,17 +283,90 @@ func (m selectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.cursor++
 				}
 			}
+		case "ctrl+o":
+			if m.details != nil {
+				m.showDetails = !m.showDetails
+			}
+		case "ctrl+l":
+			m.view = m.view.next()
 		default:
 			m.textInput, cmd = m.textInput.Update(msg)
 			m.filterItems()
+			m.refreshDetails()
 			return m, cmd
 		}
+		m.refreshDetails()
 	}
 
 	m.textInput, cmd = m.textInput.Update(msg)
 	return m, cmd
 }
 
+// allVisibleChecked reports whether every item matching the query is
+// checked.
+func (m selectorModel) allVisibleChecked() bool {
+	for _, scored := range m.filtered {
+		if !m.checked[scored.origIndex] {
+			return false
+		}
+	}
+	return len(m.filtered) > 0
+}
+
+// checkVisible checks or unchecks every item matching the query, leaving
+// the checks on filtered-out items alone.
+func (m *selectorModel) checkVisible(check bool) {
+	for _, scored := range m.filtered {
+		m.checked[scored.origIndex] = check
+	}
+}
+
+// checkedCount returns how many items are checked, visible or not.
+func (m selectorModel) checkedCount() int {
+	n := 0
+	for _, checked := range m.checked {
+		if checked {
+			n++
+		}
+	}
+	return n
+}
+
+// refreshDetails renders the details of the highlighted item if they are
+// shown and not rendered yet.
+func (m *selectorModel) refreshDetails() {
+	if !m.showDetails || len(m.filtered) == 0 {
+		return
+	}
+	value := m.filtered[m.cursor].item.Value
+	if value != m.detailsFor {
+		m.detailsFor = value
+		m.detailsText = m.details(value)
+	}
+}
+
+func (m *selectorModel) applyUpdate(u ItemUpdate) {
+	for i := range m.items {
+		if m.items[i].Value == u.Value {
+			m.items[i].Badge = u.Badge
+		}
+	}
+	for i := range m.filtered {
+		if m.filtered[i].item.Value == u.Value {
+			m.filtered[i].item.Badge = u.Badge
+		}
+	}
+}
+
+func (m selectorModel) isExtraKey(key string) bool {
+	for _, k := range m.keys {
+		if k.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *selectorModel) filterItems() {
 	query := m.textInput.Value()
 
@@ -136,12 +385,36 @@ func (m *selectorModel) filterItems() {
 		return
 	}
 
+	m.filtered = match(m.items, query, m.slab)
+
+	// Reset cursor, ensure it's within bounds
+	if m.cursor >= len(m.filtered) {
+		m.cursor = max(0, len(m.filtered)-1)
+	}
+}
+
+// Filter returns the items whose label or directory name fuzzy-matches
+// query, best match first, as the selector would show them.
+func Filter(items []Item, query string) []Item {
+	scored := match(items, query, util.MakeSlab(100, 2048))
+	matched := make([]Item, len(scored))
+	for i, s := range scored {
+		matched[i] = s.item
+	}
+	return matched
+}
+
+// match scores items against a non-empty query and returns the matches
+// sorted by score, best first. An item whose label doesn't match is scored
+// on the last element of its path instead, without positions to highlight:
+// the rest of the path is the same for every worktree.
+func match(items []Item, query string, slab *util.Slab) []scoredItem {
 	// Convert query to lowercase runes for case-insensitive matching
 	patternRunes := []rune(strings.ToLower(query))
 
 	var scored []scoredItem
 
-	for i, item := range m.items {
+	for i, item := range items {
 		// Convert item label to util.Chars
 		chars := util.ToChars([]byte(item.Label))
 
@@ -157,8 +430,12 @@ func (m *selectorModel) filterItems() {
 			&chars,       // input text
 			patternRunes, // pattern (already lowercase)
 			true,         // withPos (need positions for highlighting)
-			m.slab,       // reusable memory slab
+			slab,         // reusable memory slab
 		)
+		if result.Score <= 0 && item.Path != "" {
+			chars = util.ToChars([]byte(filepath.Base(item.Path)))
+			result, positions = algo.FuzzyMatchV2(false, true, true, &chars, patternRunes, false, slab)
+		}
 
 		// Score > 0 means we have a match
 		if result.Score > 0 {
@@ -178,16 +455,10 @@ func (m *selectorModel) filterItems() {
 	}
 
 	// Sort by score descending (best matches first)
-	sort.Slice(scored, func(i, j int) bool {
+	sort.SliceStable(scored, func(i, j int) bool {
 		return scored[i].score > scored[j].score
 	})
-
-	m.filtered = scored
-
-	// Reset cursor, ensure it's within bounds
-	if m.cursor >= len(m.filtered) {
-		m.cursor = max(0, len(m.filtered)-1)
-	}
+	return scored
 }
 
 // renderHighlightedLabel renders a label with matched characters highlighted.
@@ -262,17 +533,43 @@ func (m selectorModel) View() string {
 			)
 		}
 
-		b.WriteString(fmt.Sprintf("%s%s%s\n", cursor, check, label))
+		detail := ""
+		if m.view != ViewCompact {
+			if scored.item.Badge != "" {
+				detail = " " + scored.item.Badge
+			}
+			if scored.item.Detail != "" {
+				detail += " " + styles.DimStyle.Render(scored.item.Detail)
+			}
+			if scored.item.Path != "" {
+				detail += " " + styles.DimStyle.Render(scored.item.Path)
+			}
+		}
+		if m.view == ViewDetailed && !scored.item.Created.IsZero() {
+			detail += " " + styles.DimStyle.Render(FormatAge(m.now.Sub(scored.item.Created)))
+		}
+
+		b.WriteString(fmt.Sprintf("%s%s%s%s\n", cursor, check, label, detail))
 	}
 
 	if len(m.filtered) == 0 {
 		b.WriteString(styles.DimStyle.Render("  No matches"))
+	} else if m.showDetails {
+		b.WriteString("\n" + strings.TrimRight(m.detailsText, "\n") + "\n")
 	}
 
+	detailsHelp := ", CTRL+L for " + m.view.next().String() + " view"
+	if m.details != nil {
+		detailsHelp = ", CTRL+O for details" + detailsHelp
+	}
 	if m.multiSelect {
-		b.WriteString(styles.DimStyle.Render("\n\nTAB to select, ENTER to confirm, ESC to cancel"))
+		b.WriteString(styles.DimStyle.Render(fmt.Sprintf("\n\n%d selected · TAB to select, CTRL+A to select all, CTRL+R to invert, ENTER to confirm", m.checkedCount()) + detailsHelp + ", ESC to cancel"))
 	} else {
-		b.WriteString(styles.DimStyle.Render("\n\nENTER to select, ESC to cancel"))
+		help := "ENTER to select"
+		for _, k := range m.keys {
+			help += fmt.Sprintf(", %s to %s", strings.ToUpper(k.Key), k.Help)
+		}
+		b.WriteString(styles.DimStyle.Render("\n\n" + help + detailsHelp + ", ESC to cancel"))
 	}
 
 	return b.String()
@@ -280,19 +577,37 @@ func (m selectorModel) View() string {
 
 // Select shows a single-select fuzzy finder and returns the selected item's value.
 func Select(items []Item) (string, error) {
+	result, err := SelectWith(items, Options{})
+	return result.Value, err
+}
+
+// SelectWith shows a single-select fuzzy finder with extra options and returns
+// the selected item's value along with the key that confirmed it.
+func SelectWith(items []Item, opts Options) (Result, error) {
 	if len(items) == 0 {
-		return "", fmt.Errorf("no items to select")
+		return Result{}, fmt.Errorf("no items to select")
+	}
+	if finder == FinderFzf && !noTUI {
+		values, key, err := fzfSelect(items, false, opts)
+		if err != nil || len(values) == 0 {
+			return Result{}, err
+		}
+		return Result{Value: values[0], Key: key}, nil
 	}
 
 	// Open /dev/tty directly to ensure TUI works even when stdout is captured
 	// (e.g., in shell command substitution like result=$(wt cd --print-path))
-	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	tty, err := openTTY()
 	if err != nil {
-		return "", fmt.Errorf("failed to open /dev/tty: %w", err)
+		values, err := numberedFallback(err, items, false, opts)
+		if err != nil || len(values) == 0 {
+			return Result{}, err
+		}
+		return Result{Value: values[0], Key: "enter"}, nil
 	}
 	defer tty.Close()
 
-	m := newSelectorModel(items, false)
+	m := newSelectorModel(items, false, opts)
 	p := tea.NewProgram(
 		m,
 		tea.WithInput(tty),
@@ -300,30 +615,39 @@ func Select(items []Item) (string, error) {
 	)
 	finalModel, err := p.Run()
 	if err != nil {
-		return "", err
+		return Result{}, err
 	}
 
 	result := finalModel.(selectorModel)
 	if result.cancelled {
-		return "", nil
+		return Result{}, nil
 	}
-	return result.selected, nil
+	return Result{Value: result.selected, Key: result.pressedKey}, nil
 }
 
 // MultiSelect shows a multi-select fuzzy finder and returns the selected items' values.
 func MultiSelect(items []Item) ([]string, error) {
+	return MultiSelectWith(items, Options{})
+}
+
+// MultiSelectWith is MultiSelect with extra options. Options.Keys is ignored.
+func MultiSelectWith(items []Item, opts Options) ([]string, error) {
 	if len(items) == 0 {
 		return nil, fmt.Errorf("no items to select")
 	}
+	if finder == FinderFzf && !noTUI {
+		values, _, err := fzfSelect(items, true, opts)
+		return values, err
+	}
 
 	// Open /dev/tty directly to ensure TUI works even when stdout is captured
-	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	tty, err := openTTY()
 	if err != nil {
-		return nil, fmt.Errorf("failed to open /dev/tty: %w", err)
+		return numberedFallback(err, items, true, opts)
 	}
 	defer tty.Close()
 
-	m := newSelectorModel(items, true)
+	m := newSelectorModel(items, true, opts)
 	p := tea.NewProgram(
 		m,
 		tea.WithInput(tty),
@@ -348,6 +672,20 @@ func MultiSelect(items []Item) ([]string, error) {
 	return selected, nil
 }
 
+// FormatAge shortens d to its largest unit, e.g. "5m", "3h", "4d", "2w".
+func FormatAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 14*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	default:
+		return fmt.Sprintf("%dw", int(d.Hours()/24/7))
+	}
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
@@ -432,10 +770,19 @@ func (m confirmModel) View() string {
 }
 
 // Confirm shows a yes/no confirmation prompt and returns true if the user selects Yes.
+// Without a terminal it asks on stdin and stderr instead, and fails if
+// stdin has no answer (or, with SetNoTUI, takes that as no).
 func Confirm(message string) (bool, error) {
-	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	tty, err := openTTY()
 	if err != nil {
-		return false, fmt.Errorf("failed to open /dev/tty: %w", err)
+		ok, lineErr := confirmLine(stdin, os.Stderr, message)
+		if errors.Is(lineErr, io.EOF) {
+			if noTUI {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to open /dev/tty: %w", err)
+		}
+		return ok, lineErr
 	}
 	defer tty.Close()
 
@@ -1,9 +1,17 @@
 package tui
 
 import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/charmbracelet/bubbles/cursor"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/junegunn/fzf/src/util"
 )
 
 func TestFuzzyMatching(t *testing.T) {
@@ -77,7 +85,7 @@ func TestFuzzyMatching(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			m := newSelectorModel(tt.items, false)
+			m := newSelectorModel(tt.items, false, Options{})
 			m.textInput.SetValue(tt.query)
 			m.filterItems()
 
@@ -102,7 +110,7 @@ func TestFuzzyMatching(t *testing.T) {
 }
 
 func TestMatchPositions(t *testing.T) {
-	m := newSelectorModel([]Item{{Label: "feature", Value: "f"}}, false)
+	m := newSelectorModel([]Item{{Label: "feature", Value: "f"}}, false, Options{})
 	m.textInput.SetValue("ft")
 	m.filterItems()
 
@@ -124,7 +132,7 @@ func TestOriginalIndexPreserved(t *testing.T) {
 		{Label: "third", Value: "3"},
 	}
 
-	m := newSelectorModel(items, true)
+	m := newSelectorModel(items, true, Options{})
 	m.textInput.SetValue("ir") // matches "first" and "third"
 	m.filterItems()
 
@@ -149,6 +157,48 @@ func TestOriginalIndexPreserved(t *testing.T) {
 	}
 }
 
+func TestQueryPrefiltersSelector(t *testing.T) {
+	items := []Item{
+		{Label: "feature-login", Value: "1"},
+		{Label: "bugfix", Value: "2"},
+		{Label: "feature-search", Value: "3"},
+	}
+
+	m := newSelectorModel(items, false, Options{Query: "feat"})
+	if got := m.textInput.Value(); got != "feat" {
+		t.Errorf("filter = %q, want %q", got, "feat")
+	}
+	if len(m.filtered) != 2 {
+		t.Fatalf("got %d items, want 2", len(m.filtered))
+	}
+
+	matched := Filter(items, "fsearch")
+	if len(matched) != 1 || matched[0].Value != "3" {
+		t.Errorf("Filter(fsearch) = %+v, want only feature-search", matched)
+	}
+}
+
+func TestFilterMatchesPath(t *testing.T) {
+	items := []Item{
+		{Label: "feature-login", Value: "1", Path: "/src/app/.worktrees/login"},
+		{Label: "(detached at 1a2b3c4)", Value: "2", Path: "/src/app/.worktrees/hotfix"},
+	}
+
+	if matched := Filter(items, "app"); len(matched) != 0 {
+		t.Errorf("Filter(app) = %+v, want nothing: only the directory name counts", matched)
+	}
+
+	matched := Filter(items, "hotfix")
+	if len(matched) != 1 || matched[0].Value != "2" {
+		t.Errorf("Filter(hotfix) = %+v, want only the worktree at .worktrees/hotfix", matched)
+	}
+
+	scored := match(items, "login", util.MakeSlab(100, 2048))
+	if len(scored) != 1 || len(scored[0].positions) == 0 {
+		t.Errorf("match(login) = %+v, want the label matched with positions", scored)
+	}
+}
+
 func TestRenderHighlightedLabel(t *testing.T) {
 	baseStyle := lipgloss.NewStyle()
 	matchStyle := lipgloss.NewStyle()
@@ -188,3 +238,241 @@ func TestRenderHighlightedLabel(t *testing.T) {
 		})
 	}
 }
+
+func TestExtraKeyConfirmsSelection(t *testing.T) {
+	items := []Item{
+		{Label: "feature", Value: "/wt/feature", Detail: "[tmux work:2]"},
+		{Label: "bugfix", Value: "/wt/bugfix"},
+	}
+	m := newSelectorModel(items, false, Options{Keys: []KeyBinding{{Key: "ctrl+t", Help: "jump to tmux"}}})
+
+	if view := m.View(); !strings.Contains(view, "[tmux work:2]") || !strings.Contains(view, "CTRL+T to jump to tmux") {
+		t.Fatalf("expected detail and key help in view:\n%s", view)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	result := updated.(selectorModel)
+
+	if cmd == nil || !result.quitting {
+		t.Fatal("expected extra key to quit the selector")
+	}
+	if result.selected != "/wt/bugfix" || result.pressedKey != "ctrl+t" {
+		t.Fatalf("got selected=%q key=%q", result.selected, result.pressedKey)
+	}
+}
+
+func TestItemUpdateSetsBadge(t *testing.T) {
+	items := []Item{
+		{Label: "feature", Value: "/wt/feature"},
+		{Label: "bugfix", Value: "/wt/bugfix"},
+	}
+	updates := make(chan ItemUpdate, 1)
+	updates <- ItemUpdate{Value: "/wt/bugfix", Badge: "↑3"}
+	close(updates)
+
+	m := newSelectorModel(items, true, Options{Updates: updates})
+	msg := waitForUpdate(m.updates)()
+	updated, cmd := m.Update(msg)
+	if cmd == nil {
+		t.Fatal("expected selector to keep listening for updates")
+	}
+	if next := cmd(); next != nil {
+		t.Fatalf("expected nil message after channel close, got %v", next)
+	}
+
+	result := updated.(selectorModel)
+	if result.items[1].Badge != "↑3" || result.filtered[1].item.Badge != "↑3" {
+		t.Fatalf("badge not applied: %+v", result.items)
+	}
+	if view := result.View(); !strings.Contains(view, "bugfix ↑3") {
+		t.Fatalf("expected badge in view:\n%s", view)
+	}
+}
+
+func TestReducedMotionStopsBlinking(t *testing.T) {
+	SetReducedMotion(true)
+	t.Cleanup(func() { SetReducedMotion(false) })
+
+	m := newSelectorModel([]Item{{Label: "main", Value: "main"}}, false, Options{})
+	if cmd := m.Init(); cmd != nil {
+		t.Fatalf("Init() returned a command (cursor blink), want none")
+	}
+	if mode := m.textInput.Cursor.Mode(); mode != cursor.CursorStatic {
+		t.Fatalf("cursor mode = %v, want static", mode)
+	}
+}
+
+func TestCtrlOTogglesDetails(t *testing.T) {
+	items := []Item{
+		{Label: "feature", Value: "/wt/feature"},
+		{Label: "bugfix", Value: "/wt/bugfix"},
+	}
+	calls := 0
+	details := func(value string) string {
+		calls++
+		return "details of " + value
+	}
+	var m tea.Model = newSelectorModel(items, false, Options{Details: details})
+
+	if view := m.View(); strings.Contains(view, "details of") || !strings.Contains(view, "CTRL+O for details") {
+		t.Fatalf("expected only the help for details before CTRL+O:\n%s", view)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	if view := m.View(); !strings.Contains(view, "details of /wt/feature") {
+		t.Fatalf("expected details of the highlighted item:\n%s", view)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if view := m.View(); !strings.Contains(view, "details of /wt/bugfix") {
+		t.Fatalf("expected details to follow the cursor:\n%s", view)
+	}
+	m.View()
+	if calls != 2 {
+		t.Fatalf("details rendered %d times, want 2 (once per item)", calls)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	if view := m.View(); strings.Contains(view, "details of") {
+		t.Fatalf("expected CTRL+O to hide the details:\n%s", view)
+	}
+}
+
+func TestCtrlLCyclesViews(t *testing.T) {
+	item := Item{Label: "feature", Value: "/wt/feature", Detail: "wip", Path: "/wt/feature"}
+	var m tea.Model = newSelectorModel([]Item{item}, false, Options{})
+	sm := m.(selectorModel)
+	sm.items[0].Created = sm.now.Add(-3 * 24 * time.Hour)
+	sm.filterItems()
+	m = sm
+
+	if view := m.View(); !strings.Contains(view, "/wt/feature") || strings.Contains(view, "3d") || !strings.Contains(view, "CTRL+L for detailed view") {
+		t.Fatalf("normal view: expected the path, no age:\n%s", view)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlL})
+	if view := m.View(); !strings.Contains(view, "3d") {
+		t.Fatalf("detailed view: expected the age:\n%s", view)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlL})
+	if view := m.View(); strings.Contains(view, "/wt/feature") || strings.Contains(view, "wip") {
+		t.Fatalf("compact view: expected the label only:\n%s", view)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlL})
+	if got := m.(selectorModel).view; got != ViewNormal {
+		t.Fatalf("view after three CTRL+L = %v, want normal", got)
+	}
+}
+
+func TestParseView(t *testing.T) {
+	for name, want := range map[string]View{"": ViewNormal, "normal": ViewNormal, "compact": ViewCompact, "detailed": ViewDetailed} {
+		if got, err := ParseView(name); err != nil || got != want {
+			t.Errorf("ParseView(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseView("dense"); err == nil {
+		t.Error("expected error for unknown view")
+	}
+}
+
+func TestSelectAllAndInvert(t *testing.T) {
+	items := []Item{
+		{Label: "feature-a", Value: "/wt/feature-a"},
+		{Label: "feature-b", Value: "/wt/feature-b"},
+		{Label: "bugfix", Value: "/wt/bugfix"},
+	}
+	checked := func(m tea.Model) []string {
+		var values []string
+		for i, item := range items {
+			if m.(selectorModel).checked[i] {
+				values = append(values, item.Value)
+			}
+		}
+		return values
+	}
+	var m tea.Model = newSelectorModel(items, true, Options{Query: "feature"})
+	if view := m.View(); !strings.Contains(view, "0 selected") {
+		t.Fatalf("expected the counter at 0:\n%s", view)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlA})
+	if got := checked(m); !reflect.DeepEqual(got, []string{"/wt/feature-a", "/wt/feature-b"}) {
+		t.Fatalf("CTRL+A checked %v, want only the visible items", got)
+	}
+	if view := m.View(); !strings.Contains(view, "2 selected") {
+		t.Fatalf("expected the counter at 2:\n%s", view)
+	}
+	if q := m.(selectorModel).textInput.Value(); q != "feature" {
+		t.Fatalf("query = %q, CTRL+A must not edit it", q)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlA})
+	if got := checked(m); got != nil {
+		t.Fatalf("second CTRL+A left %v checked, want none", got)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	if got := checked(m); !reflect.DeepEqual(got, []string{"/wt/feature-b"}) {
+		t.Fatalf("CTRL+R checked %v, want the visible items inverted", got)
+	}
+}
+
+func TestParseNumbers(t *testing.T) {
+	tests := []struct {
+		answer  string
+		multi   bool
+		want    []int
+		wantErr bool
+	}{
+		{answer: "2", want: []int{2}},
+		{answer: "0", wantErr: true},
+		{answer: "4", wantErr: true},
+		{answer: "1 2", wantErr: true},
+		{answer: "3 1", multi: true, want: []int{1, 3}},
+		{answer: "1-2,3", multi: true, want: []int{1, 2, 3}},
+		{answer: "a", multi: true, want: []int{1, 2, 3}},
+		{answer: "2-1", multi: true, wantErr: true},
+		{answer: "x", multi: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseNumbers(tt.answer, 3, tt.multi)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseNumbers(%q, multi=%v) = %v, %v; want %v (error: %v)", tt.answer, tt.multi, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNumberedSelect(t *testing.T) {
+	items := []Item{
+		{Label: "feature-auth", Value: "auth"},
+		{Label: "feature-api", Value: "api"},
+		{Label: "bugfix", Value: "bug"},
+	}
+	var out strings.Builder
+	got, err := numberedSelect(bufio.NewReader(strings.NewReader("7\n2\n")), &out, items, false, Options{Query: "feature"})
+	if err != nil || !reflect.DeepEqual(got, []string{"api"}) {
+		t.Fatalf("numberedSelect = %v, %v; want [api]", got, err)
+	}
+	if strings.Contains(out.String(), "bugfix") || !strings.Contains(out.String(), "invalid selection") {
+		t.Errorf("output = %q; want the filtered list and a retry", out.String())
+	}
+
+	if got, err := numberedSelect(bufio.NewReader(strings.NewReader("\n")), &out, items, true, Options{}); err != nil || got != nil {
+		t.Errorf("empty answer = %v, %v; want a cancel", got, err)
+	}
+	if _, err := numberedSelect(bufio.NewReader(strings.NewReader("")), &out, items, true, Options{}); err != io.EOF {
+		t.Errorf("no answer = %v; want io.EOF", err)
+	}
+	if strings.Contains(out.String(), "\x1b[") {
+		t.Errorf("output = %q; want no colors outside a terminal", out.String())
+	}
+
+	// Later prompts read on where the earlier ones stopped.
+	in := bufio.NewReader(strings.NewReader("1\ny"))
+	if got, err := numberedSelect(in, &out, items, false, Options{}); err != nil || !reflect.DeepEqual(got, []string{"auth"}) {
+		t.Fatalf("first prompt = %v, %v; want [auth]", got, err)
+	}
+	if ok, err := confirmLine(in, &out, "Sure?"); err != nil || !ok {
+		t.Errorf("second prompt = %v, %v; want yes", ok, err)
+	}
+}
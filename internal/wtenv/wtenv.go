@@ -0,0 +1,119 @@
+package wtenv
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/default-anton/wt/internal/shquote"
+)
+
+// Var is a single environment variable.
+type Var struct {
+	Key   string
+	Value string
+}
+
+// Info describes the worktree whose environment is being built.
+type Info struct {
+	WorktreePath string
+	Branch       string
+	BaseBranch   string
+	RepoRoot     string
+}
+
+// Build returns the WT_* variables for the worktree followed by the variables
+// loaded from envFiles. Env file paths are resolved relative to the worktree;
+// files that don't exist are skipped.
+func Build(info Info, envFiles []string) ([]Var, error) {
+	vars := []Var{
+		{Key: "WT_WORKTREE_PATH", Value: info.WorktreePath},
+		{Key: "WT_BRANCH", Value: info.Branch},
+		{Key: "WT_BASE_BRANCH", Value: info.BaseBranch},
+		{Key: "WT_REPO_ROOT", Value: info.RepoRoot},
+		{Key: "WT_DIR_NAME", Value: filepath.Base(info.WorktreePath)},
+	}
+
+	for _, envFile := range envFiles {
+		path := envFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(info.WorktreePath, path)
+		}
+		fileVars, err := ReadEnvFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read env file %s: %w", envFile, err)
+		}
+		vars = append(vars, fileVars...)
+	}
+
+	return vars, nil
+}
+
+// validKey matches the variable names a POSIX shell accepts, so FormatShell
+// output can't run anything but export.
+var validKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ReadEnvFile parses a dotenv-style file of KEY=VALUE lines. Blank lines, comments,
+// and an optional leading "export " are ignored; matching surrounding quotes are stripped.
+// A key that isn't a valid variable name is an error.
+func ReadEnvFile(path string) ([]Var, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var vars []Var
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if !validKey.MatchString(key) {
+			return nil, fmt.Errorf("%s:%d: invalid variable name %q", path, n, key)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars = append(vars, Var{Key: key, Value: value})
+	}
+	return vars, scanner.Err()
+}
+
+// FormatShell renders vars as POSIX shell export statements suitable for eval.
+func FormatShell(vars []Var) string {
+	var b strings.Builder
+	for _, v := range vars {
+		fmt.Fprintf(&b, "export %s=%s\n", v.Key, shquote.Quote(v.Value))
+	}
+	return b.String()
+}
+
+// FormatJSON renders vars as a JSON object. Later duplicates override earlier ones.
+func FormatJSON(vars []Var) (string, error) {
+	m := make(map[string]string, len(vars))
+	for _, v := range vars {
+		m[v.Key] = v.Value
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
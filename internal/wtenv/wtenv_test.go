@@ -0,0 +1,107 @@
+package wtenv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeEnvFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadEnvFile(t *testing.T) {
+	path := writeEnvFile(t, t.TempDir(), ".env", `# comment
+
+export PORT=3001
+NAME = "my app"
+SINGLE='it is'
+_UNDER=1
+EMPTY=
+no equals sign
+`)
+	got, err := ReadEnvFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Var{
+		{Key: "PORT", Value: "3001"},
+		{Key: "NAME", Value: "my app"},
+		{Key: "SINGLE", Value: "it is"},
+		{Key: "_UNDER", Value: "1"},
+		{Key: "EMPTY", Value: ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadEnvFile() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadEnvFileRejectsInvalidKeys(t *testing.T) {
+	dir := t.TempDir()
+	for _, key := range []string{"X;rm -rf ~", "1ABC", "A-B", "$(id)", "A B"} {
+		path := writeEnvFile(t, dir, ".env", "OK=1\n"+key+"=value\n")
+		vars, err := ReadEnvFile(path)
+		if err == nil {
+			t.Errorf("ReadEnvFile() with key %q = %+v, want an error", key, vars)
+			continue
+		}
+		if !strings.Contains(err.Error(), ":2: invalid variable name") {
+			t.Errorf("ReadEnvFile() with key %q: error %q doesn't name the line", key, err)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	writeEnvFile(t, dir, ".env", "PORT=3001\n")
+	info := Info{WorktreePath: dir, Branch: "feature", BaseBranch: "main", RepoRoot: "/repo"}
+	got, err := Build(info, []string{".env", ".env.missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Var{
+		{Key: "WT_WORKTREE_PATH", Value: dir},
+		{Key: "WT_BRANCH", Value: "feature"},
+		{Key: "WT_BASE_BRANCH", Value: "main"},
+		{Key: "WT_REPO_ROOT", Value: "/repo"},
+		{Key: "WT_DIR_NAME", Value: filepath.Base(dir)},
+		{Key: "PORT", Value: "3001"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Build() = %+v, want %+v", got, want)
+	}
+
+	writeEnvFile(t, dir, ".env", "BAD KEY=1\n")
+	if _, err := Build(info, []string{".env"}); err == nil {
+		t.Error("Build() with an invalid key succeeded, want an error")
+	}
+}
+
+func TestFormatShell(t *testing.T) {
+	got := FormatShell([]Var{
+		{Key: "A", Value: "plain"},
+		{Key: "B", Value: "it's $HOME; `id`"},
+	})
+	want := "export A=plain\nexport B='it'\\''s $HOME; `id`'\n"
+	if got != want {
+		t.Errorf("FormatShell() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatJSON(t *testing.T) {
+	got, err := FormatJSON([]Var{{Key: "A", Value: "1"}, {Key: "A", Value: "2"}, {Key: "B", Value: `"q"`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"A\": \"2\",\n  \"B\": \"\\\"q\\\"\"\n}\n"
+	if got != want {
+		t.Errorf("FormatJSON() = %q, want %q", got, want)
+	}
+}
@@ -2,20 +2,29 @@
 # Add this to your .bashrc or .zshrc:
 #   eval "$(wt shell-init bash)"  # for bash
 #   eval "$(wt shell-init zsh)"   # for zsh
+#
+# wt exits with 2 when there are no worktrees and 130 when a selection is
+# cancelled; the wrapper treats cancellation as a no-op.
 
 wt() {
-  if [[ "$1" == "cd" ]] && [[ ! " $* " =~ " --tmux " ]] && [[ ! " $* " =~ " -t " ]]; then
-    local result
+  if [[ "$1" == "cd" ]] && [[ ! " $* " =~ " --tmux " ]] && [[ ! " $* " =~ " -t " ]] && [[ ! " $* " =~ " --remote" ]]; then
+    local result rc
     result=$(command wt cd --print-path "${@:2}")
-    if [[ -n "$result" && -d "$result" ]]; then
+    rc=$?
+    [[ $rc -eq 130 ]] && return 0
+    if [[ $rc -eq 0 && -n "$result" && -d "$result" ]]; then
       cd "$result"
     fi
+    return $rc
   elif [[ "$1" == "add" ]] && [[ ! " $* " =~ " --tmux " ]] && [[ ! " $* " =~ " -t " ]]; then
-    local result
+    local result rc
     result=$(command wt add "${@:2}" --print-path)
-    if [[ -n "$result" && -d "$result" ]]; then
+    rc=$?
+    [[ $rc -eq 130 ]] && return 0
+    if [[ $rc -eq 0 && -n "$result" && -d "$result" ]]; then
       cd "$result"
     fi
+    return $rc
   else
     command wt "$@"
   fi
@@ -1,18 +1,27 @@
 # wt shell integration
 # Add this to your config.fish:
 #   wt shell-init fish | source
+#
+# wt exits with 2 when there are no worktrees and 130 when a selection is
+# cancelled; the wrapper treats cancellation as a no-op.
 
 function wt
-  if test "$argv[1]" = "cd"; and not contains -- --tmux $argv; and not contains -- -t $argv
+  if test "$argv[1]" = "cd"; and not contains -- --tmux $argv; and not contains -- -t $argv; and not string match -q -- '--remote*' $argv
     set -l result (command wt cd --print-path $argv[2..])
-    if test -n "$result"; and test -d "$result"
+    set -l rc $status
+    test $rc -eq 130; and return 0
+    if test $rc -eq 0; and test -n "$result"; and test -d "$result"
       cd $result
     end
+    return $rc
   else if test "$argv[1]" = "add"; and not contains -- --tmux $argv; and not contains -- -t $argv
     set -l result (command wt add $argv[2..] --print-path)
-    if test -n "$result"; and test -d "$result"
+    set -l rc $status
+    test $rc -eq 130; and return 0
+    if test $rc -eq 0; and test -n "$result"; and test -d "$result"
       cd $result
     end
+    return $rc
   else
     command wt $argv
   end